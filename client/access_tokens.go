@@ -0,0 +1,34 @@
+package client
+
+import "github.com/DavidMovas/Movies-Reviews/contracts"
+
+func (c *Client) CreateAccessToken(req *contracts.AuthenticatedRequest[*contracts.CreateAccessTokenRequest]) (*contracts.AccessTokenWithSecret, error) {
+	var token *contracts.AccessTokenWithSecret
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&token).
+		Post(c.path("/api/users/%d/tokens", req.Request.UserID))
+
+	return token, err
+}
+
+func (c *Client) GetAccessTokens(req *contracts.AuthenticatedRequest[*contracts.GetAccessTokensRequest]) ([]*contracts.AccessToken, error) {
+	var tokens []*contracts.AccessToken
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&tokens).
+		Get(c.path("/api/users/%d/tokens", req.Request.UserID))
+
+	return tokens, err
+}
+
+func (c *Client) DeleteAccessToken(req *contracts.AuthenticatedRequest[*contracts.DeleteAccessTokenRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		Delete(c.path("/api/users/%d/tokens/%d", req.Request.UserID, req.Request.TokenID))
+
+	return err
+}
@@ -23,3 +23,212 @@ func (c *Client) LoginUser(req *contracts.LoginUserRequest) (*contracts.LoginUse
 
 	return resp, err
 }
+
+func (c *Client) LoginTwoFactor(req *contracts.LoginTwoFactorRequest) (*contracts.LoginUserResponse, error) {
+	var resp *contracts.LoginUserResponse
+
+	_, err := c.client.R().
+		SetBody(req).
+		SetResult(&resp).
+		Post(c.path("/api/auth/login/2fa"))
+
+	return resp, err
+}
+
+// StartOIDCLogin returns the URL of the identity provider's login page the API redirects to.
+func (c *Client) StartOIDCLogin(provider string) (string, error) {
+	resp, err := c.client.R().
+		Get(c.path("/api/auth/oidc/%s/login", provider))
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Header().Get("Location"), nil
+}
+
+func (c *Client) FinishOIDCLogin(req *contracts.OIDCCallbackRequest) (*contracts.LoginUserResponse, error) {
+	var resp *contracts.LoginUserResponse
+
+	_, err := c.client.R().
+		SetQueryParams(map[string]string{"code": req.Code, "state": req.State}).
+		SetResult(&resp).
+		Get(c.path("/api/auth/oidc/%s/callback", req.Provider))
+
+	return resp, err
+}
+
+func (c *Client) RefreshToken(req *contracts.RefreshTokenRequest) (*contracts.RefreshTokenResponse, error) {
+	var resp *contracts.RefreshTokenResponse
+
+	_, err := c.client.R().
+		SetBody(req).
+		SetResult(&resp).
+		Post(c.path("/api/auth/refresh"))
+
+	return resp, err
+}
+
+func (c *Client) Logout(req *contracts.AuthenticatedRequest[*contracts.LogoutRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		Post(c.path("/api/auth/logout"))
+
+	return err
+}
+
+func (c *Client) RevokeUserSessions(req *contracts.AuthenticatedRequest[*contracts.RevokeUserSessionsRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetHeader("Content-Type", "application/json").
+		Delete(c.path("/api/users/%d/sessions", req.Request.UserID))
+
+	return err
+}
+
+func (c *Client) ImpersonateUser(req *contracts.AuthenticatedRequest[*contracts.ImpersonateUserRequest]) (*contracts.ImpersonateUserResponse, error) {
+	var resp *contracts.ImpersonateUserResponse
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&resp).
+		Post(c.path("/api/users/%d/impersonate", req.Request.UserID))
+
+	return resp, err
+}
+
+func (c *Client) GetImpersonations(req *contracts.AuthenticatedRequest[*contracts.GetImpersonationsRequest]) ([]*contracts.Impersonation, error) {
+	var impersonations []*contracts.Impersonation
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&impersonations).
+		Get(c.path("/api/users/%d/impersonations", req.Request.UserID))
+
+	return impersonations, err
+}
+
+func (c *Client) ForgotPassword(req *contracts.ForgotPasswordRequest) error {
+	_, err := c.client.R().
+		SetBody(req).
+		Post(c.path("/api/auth/password/forgot"))
+
+	return err
+}
+
+func (c *Client) ResetPassword(req *contracts.ResetPasswordRequest) error {
+	_, err := c.client.R().
+		SetBody(req).
+		Post(c.path("/api/auth/password/reset"))
+
+	return err
+}
+
+func (c *Client) ChangePassword(req *contracts.AuthenticatedRequest[*contracts.ChangePasswordRequest]) (*contracts.RefreshTokenResponse, error) {
+	var resp *contracts.RefreshTokenResponse
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&resp).
+		Post(c.path("/api/auth/password/change"))
+
+	return resp, err
+}
+
+func (c *Client) ChangeEmail(req *contracts.AuthenticatedRequest[*contracts.ChangeEmailRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		Post(c.path("/api/auth/email/change"))
+
+	return err
+}
+
+func (c *Client) ConfirmEmailChange(req *contracts.ConfirmEmailChangeRequest) error {
+	_, err := c.client.R().
+		SetBody(req).
+		Post(c.path("/api/auth/email/confirm"))
+
+	return err
+}
+
+func (c *Client) VerifyEmail(req *contracts.VerifyEmailRequest) error {
+	_, err := c.client.R().
+		SetBody(req).
+		Post(c.path("/api/auth/verify"))
+
+	return err
+}
+
+func (c *Client) ResendEmailVerification(req *contracts.ResendEmailVerificationRequest) error {
+	_, err := c.client.R().
+		SetBody(req).
+		Post(c.path("/api/auth/verify/resend"))
+
+	return err
+}
+
+func (c *Client) GetLockouts(accessToken string) ([]*contracts.Lockout, error) {
+	var lockouts []*contracts.Lockout
+
+	_, err := c.client.R().
+		SetAuthToken(accessToken).
+		SetResult(&lockouts).
+		Get(c.path("/api/auth/lockouts"))
+
+	return lockouts, err
+}
+
+func (c *Client) DeleteLockout(req *contracts.AuthenticatedRequest[*contracts.DeleteLockoutRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		Delete(c.path("/api/auth/lockouts/%s/%s", req.Request.Scope, req.Request.Subject))
+
+	return err
+}
+
+func (c *Client) EnrollTwoFactor(accessToken string) (*contracts.EnrollTwoFactorResponse, error) {
+	var resp *contracts.EnrollTwoFactorResponse
+
+	_, err := c.client.R().
+		SetAuthToken(accessToken).
+		SetResult(&resp).
+		Post(c.path("/api/auth/2fa/enroll"))
+
+	return resp, err
+}
+
+func (c *Client) ConfirmTwoFactor(req *contracts.AuthenticatedRequest[*contracts.ConfirmTwoFactorRequest]) (*contracts.RecoveryCodesResponse, error) {
+	var resp *contracts.RecoveryCodesResponse
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&resp).
+		Post(c.path("/api/auth/2fa/confirm"))
+
+	return resp, err
+}
+
+func (c *Client) DisableTwoFactor(req *contracts.AuthenticatedRequest[*contracts.DisableTwoFactorRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		Post(c.path("/api/auth/2fa/disable"))
+
+	return err
+}
+
+func (c *Client) RegenerateRecoveryCodes(req *contracts.AuthenticatedRequest[*contracts.RegenerateRecoveryCodesRequest]) (*contracts.RecoveryCodesResponse, error) {
+	var resp *contracts.RecoveryCodesResponse
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&resp).
+		Post(c.path("/api/auth/2fa/recovery-codes"))
+
+	return resp, err
+}
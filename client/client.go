@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/cookiejar"
 
 	"github.com/DavidMovas/Movies-Reviews/contracts"
 	"github.com/go-resty/resty/v2"
@@ -15,14 +16,22 @@ type Client struct {
 }
 
 func New(url string) *Client {
-	hc := &http.Client{}
+	// Keeps the session cookies of cookie logins
+	jar, _ := cookiejar.New(nil)
+	hc := &http.Client{
+		Jar: jar,
+		// Redirects lead to identity providers, the caller follows them
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
 	rc := resty.NewWithClient(hc)
 	rc.OnAfterResponse(func(_ *resty.Client, response *resty.Response) error {
 		if response.IsError() {
 			herr := contracts.HTTPError{}
 			_ = json.Unmarshal(response.Body(), &herr)
 
-			return &Error{Code: response.StatusCode(), Message: herr.Message}
+			return &Error{Code: response.StatusCode(), Message: herr.Message, ErrorCode: herr.Code}
 		}
 		return nil
 	})
@@ -33,6 +42,11 @@ func New(url string) *Client {
 	}
 }
 
+// SetCSRFToken sends the CSRF token of a cookie session with every request.
+func (c *Client) SetCSRFToken(token string) {
+	c.client.SetHeader("X-CSRF-Token", token)
+}
+
 func (c *Client) path(f string, args ...any) string {
 	return fmt.Sprintf(c.baseURL+f, args...)
 }
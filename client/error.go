@@ -10,6 +10,8 @@ var _ error = (*Error)(nil)
 type Error struct {
 	Code    int
 	Message string
+	// ErrorCode tells apart errors with the same status, e.g. contracts.AccountSuspendedErrorCode
+	ErrorCode string
 }
 
 func (e *Error) Error() string {
@@ -0,0 +1,37 @@
+package client
+
+import "github.com/DavidMovas/Movies-Reviews/contracts"
+
+func (c *Client) StartExport(req *contracts.AuthenticatedRequest[*contracts.StartExportRequest]) (*contracts.Export, error) {
+	var export *contracts.Export
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&export).
+		Post(c.path("/api/users/%d/export", req.Request.UserID))
+
+	return export, err
+}
+
+func (c *Client) GetExport(req *contracts.AuthenticatedRequest[*contracts.GetExportRequest]) (*contracts.Export, error) {
+	var export *contracts.Export
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&export).
+		Get(c.path("/api/users/%d/export", req.Request.UserID))
+
+	return export, err
+}
+
+// GetExportArchive downloads the zip archive of the latest completed export.
+func (c *Client) GetExportArchive(req *contracts.AuthenticatedRequest[*contracts.GetExportArchiveRequest]) ([]byte, error) {
+	res, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		Get(c.path("/api/users/%d/export/archive", req.Request.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Body(), nil
+}
@@ -0,0 +1,34 @@
+package client
+
+import "github.com/DavidMovas/Movies-Reviews/contracts"
+
+func (c *Client) CreateInvitation(req *contracts.AuthenticatedRequest[*contracts.CreateInvitationRequest]) (*contracts.CreateInvitationResponse, error) {
+	var invitation *contracts.CreateInvitationResponse
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&invitation).
+		Post(c.path("/api/invitations"))
+
+	return invitation, err
+}
+
+func (c *Client) GetInvitations(accessToken string) ([]*contracts.Invitation, error) {
+	var invitations []*contracts.Invitation
+
+	_, err := c.client.R().
+		SetAuthToken(accessToken).
+		SetResult(&invitations).
+		Get(c.path("/api/invitations"))
+
+	return invitations, err
+}
+
+func (c *Client) RevokeInvitation(req *contracts.AuthenticatedRequest[*contracts.RevokeInvitationRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		Delete(c.path("/api/invitations/%d", req.Request.InvitationID))
+
+	return err
+}
@@ -0,0 +1,13 @@
+package client
+
+import "github.com/DavidMovas/Movies-Reviews/contracts"
+
+func (c *Client) GetJWKS() (*contracts.JWKS, error) {
+	var jwks *contracts.JWKS
+
+	_, err := c.client.R().
+		SetResult(&jwks).
+		Get(c.path("/.well-known/jwks.json"))
+
+	return jwks, err
+}
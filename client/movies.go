@@ -1,16 +1,22 @@
 package client
 
 import (
+	"strconv"
+
 	"github.com/DavidMovas/Movies-Reviews/contracts"
 )
 
 func (c *Client) GetMovies(req *contracts.GetMoviesRequest) (*contracts.PaginatedResponseOrdered[*contracts.Movie], error) {
 	var resp *contracts.PaginatedResponseOrdered[*contracts.Movie]
 
-	_, err := c.client.R().
+	r := c.client.R().
 		SetResult(&resp).
-		SetQueryParams(req.ToQueryParams()).
-		Get(c.path("/api/movies"))
+		SetQueryParams(req.ToQueryParams())
+	for _, genreID := range req.GenreIDs {
+		r.QueryParam.Add("genre_ids", strconv.Itoa(genreID))
+	}
+
+	_, err := r.Get(c.path("/api/movies"))
 
 	return resp, err
 }
@@ -1,6 +1,10 @@
 package client
 
-import "github.com/DavidMovas/Movies-Reviews/contracts"
+import (
+	"iter"
+
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+)
 
 func Paginate[I any, Req contracts.PaginationSetter](
 	req Req,
@@ -25,3 +29,51 @@ func Paginate[I any, Req contracts.PaginationSetter](
 
 	return items, nil
 }
+
+// Iterate yields the items of all pages following the next cursors. Unlike Paginate, it doesn't skip or repeat
+// items when the list changes meanwhile. It stops at the first error.
+func Iterate[I any, Req contracts.CursorSetter](
+	req Req,
+	queryFn func(Req) (*contracts.PaginatedResponseOrdered[I], error),
+) iter.Seq2[I, error] {
+	return func(yield func(I, error) bool) {
+		for {
+			res, err := queryFn(req)
+			if err != nil {
+				var zero I
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range res.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if res.NextCursor == "" {
+				return
+			}
+
+			req.SetCursor(res.NextCursor)
+		}
+	}
+}
+
+// PaginateCursor collects the items of all pages following the next cursors.
+func PaginateCursor[I any, Req contracts.CursorSetter](
+	req Req,
+	queryFn func(Req) (*contracts.PaginatedResponseOrdered[I], error),
+) ([]I, error) {
+	var items []I
+
+	for item, err := range Iterate(req, queryFn) {
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,68 @@
+package client
+
+import "github.com/DavidMovas/Movies-Reviews/contracts"
+
+func (c *Client) GetPermissions(accessToken string) ([]*contracts.Permission, error) {
+	var permissions []*contracts.Permission
+
+	_, err := c.client.R().
+		SetAuthToken(accessToken).
+		SetResult(&permissions).
+		Get(c.path("/api/permissions"))
+
+	return permissions, err
+}
+
+func (c *Client) GetRoles(accessToken string) ([]*contracts.Role, error) {
+	var roles []*contracts.Role
+
+	_, err := c.client.R().
+		SetAuthToken(accessToken).
+		SetResult(&roles).
+		Get(c.path("/api/roles"))
+
+	return roles, err
+}
+
+func (c *Client) GetRoleByName(req *contracts.AuthenticatedRequest[*contracts.GetRoleRequest]) (*contracts.Role, error) {
+	var role *contracts.Role
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&role).
+		Get(c.path("/api/roles/%s", req.Request.Name))
+
+	return role, err
+}
+
+func (c *Client) CreateRole(req *contracts.AuthenticatedRequest[*contracts.CreateRoleRequest]) (*contracts.Role, error) {
+	var role *contracts.Role
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&role).
+		Post(c.path("/api/roles"))
+
+	return role, err
+}
+
+func (c *Client) UpdateRole(req *contracts.AuthenticatedRequest[*contracts.UpdateRoleRequest]) (*contracts.Role, error) {
+	var role *contracts.Role
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&role).
+		Put(c.path("/api/roles/%s", req.Request.Name))
+
+	return role, err
+}
+
+func (c *Client) DeleteRole(req *contracts.AuthenticatedRequest[*contracts.DeleteRoleRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		Delete(c.path("/api/roles/%s", req.Request.Name))
+
+	return err
+}
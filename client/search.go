@@ -0,0 +1,14 @@
+package client
+
+import "github.com/DavidMovas/Movies-Reviews/contracts"
+
+func (c *Client) Suggest(req *contracts.SuggestRequest) ([]*contracts.Suggestion, error) {
+	var suggestions []*contracts.Suggestion
+
+	_, err := c.client.R().
+		SetResult(&suggestions).
+		SetQueryParam("q", req.Query).
+		Get(c.path("/api/search/suggest"))
+
+	return suggestions, err
+}
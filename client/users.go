@@ -2,6 +2,18 @@ package client
 
 import "github.com/DavidMovas/Movies-Reviews/contracts"
 
+func (c *Client) GetUsers(req *contracts.AuthenticatedRequest[*contracts.GetUsersRequest]) (*contracts.PaginatedResponseOrdered[*contracts.DirectoryUser], error) {
+	var resp *contracts.PaginatedResponseOrdered[*contracts.DirectoryUser]
+
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&resp).
+		SetQueryParams(req.Request.ToQueryParams()).
+		Get(c.path("/api/users"))
+
+	return resp, err
+}
+
 func (c *Client) GetUserByID(req *contracts.GetUserByIDRequest) (*contracts.User, error) {
 	var user *contracts.User
 
@@ -51,3 +63,51 @@ func (c *Client) DeleteUserByID(req *contracts.AuthenticatedRequest[*contracts.D
 
 	return err
 }
+
+func (c *Client) RestoreUser(req *contracts.AuthenticatedRequest[*contracts.RestoreUserRequest]) (*contracts.User, error) {
+	var user *contracts.User
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&user).
+		Post(c.path("/api/users/%d/restore", req.Request.UserID))
+
+	return user, err
+}
+
+func (c *Client) AnonymizeUser(req *contracts.AuthenticatedRequest[*contracts.AnonymizeUserRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		Post(c.path("/api/users/%d/anonymize", req.Request.UserID))
+
+	return err
+}
+
+func (c *Client) SuspendUser(req *contracts.AuthenticatedRequest[*contracts.SuspendUserRequest]) (*contracts.Suspension, error) {
+	var suspension *contracts.Suspension
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetBody(req.Request).
+		SetResult(&suspension).
+		Post(c.path("/api/users/%d/suspensions", req.Request.UserID))
+
+	return suspension, err
+}
+
+func (c *Client) GetSuspensions(req *contracts.AuthenticatedRequest[*contracts.GetSuspensionsRequest]) ([]*contracts.Suspension, error) {
+	var suspensions []*contracts.Suspension
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		SetResult(&suspensions).
+		Get(c.path("/api/users/%d/suspensions", req.Request.UserID))
+
+	return suspensions, err
+}
+
+func (c *Client) LiftSuspension(req *contracts.AuthenticatedRequest[*contracts.LiftSuspensionRequest]) error {
+	_, err := c.client.R().
+		SetAuthToken(req.AccessToken).
+		Delete(c.path("/api/users/%d/suspensions/%d", req.Request.UserID, req.Request.SuspensionID))
+
+	return err
+}
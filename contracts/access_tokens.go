@@ -0,0 +1,42 @@
+package contracts
+
+import "time"
+
+const (
+	GenresWriteScope  = "genres:write"
+	StarsWriteScope   = "stars:write"
+	MoviesWriteScope  = "movies:write"
+	ReviewsWriteScope = "reviews:write"
+	UsersWriteScope   = "users:write"
+)
+
+type AccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type AccessTokenWithSecret struct {
+	*AccessToken
+	Token string `json:"token"`
+}
+
+type CreateAccessTokenRequest struct {
+	UserID    int        `json:"-"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type GetAccessTokensRequest struct {
+	UserID int `json:"-"`
+}
+
+type DeleteAccessTokenRequest struct {
+	UserID  int `json:"-"`
+	TokenID int `json:"-"`
+}
@@ -1,19 +1,152 @@
 package contracts
 
+import "time"
+
 type RegisterUserRequest struct {
-	Username string `json:"username" validate:"min=3,max=24"`
-	Email    string `json:"email" validate:"email"`
-	Password string `json:"password" validate:"password"`
+	Username       string `json:"username" validate:"min=3,max=24"`
+	Email          string `json:"email" validate:"email"`
+	Password       string `json:"password" validate:"password"`
+	InvitationCode string `json:"invitation_code,omitempty"`
 }
 
 type LoginUserRequest struct {
-	Email    string `json:"email" validate:"email"`
-	Password string `json:"password" validate:"password"`
+	Email      string `json:"email,omitempty" validate:"email"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password" validate:"password"`
+	Device     string `json:"device,omitempty"`
+	UseCookies bool   `json:"use_cookies,omitempty"`
 }
 
 type LoginUserResponse struct {
-	User        User   `json:"user"`
-	AccessToken string `json:"access_token" nonzero:"true"`
+	User              User   `json:"user"`
+	AccessToken       string `json:"access_token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	CSRFToken         string `json:"csrf_token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
+}
+
+type LoginTwoFactorRequest struct {
+	TwoFactorToken string `json:"two_factor_token" validate:"nonzero"`
+	Code           string `json:"code" validate:"nonzero"`
+	Device         string `json:"device,omitempty"`
+	UseCookies     bool   `json:"use_cookies,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type RefreshTokenResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	CSRFToken    string `json:"csrf_token,omitempty"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type RevokeUserSessionsRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"nonzero"`
+	Password string `json:"password" validate:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"nonzero"`
+	NewPassword     string `json:"new_password" validate:"password"`
+	Device          string `json:"device,omitempty"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"current_password" validate:"nonzero"`
+	NewEmail        string `json:"new_email" validate:"email"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"nonzero"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"nonzero"`
+}
+
+type ResendEmailVerificationRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type OIDCCallbackRequest struct {
+	Provider string `json:"-"`
+	Code     string `json:"-"`
+	State    string `json:"-"`
+}
+
+type EnrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"nonzero"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"nonzero"`
+	Code     string `json:"code" validate:"nonzero"`
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	Code string `json:"code" validate:"nonzero"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ImpersonateUserRequest struct {
+	UserID int    `json:"-" param:"userId" validate:"nonzero"`
+	Reason string `json:"reason" validate:"min=3,max=255"`
+}
+
+type ImpersonateUserResponse struct {
+	User        User      `json:"user"`
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type GetImpersonationsRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type Impersonation struct {
+	ID        int       `json:"id"`
+	ActorID   int       `json:"actorId"`
+	UserID    int       `json:"userId"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type Lockout struct {
+	Scope         string    `json:"scope"`
+	Subject       string    `json:"subject"`
+	Username      *string   `json:"username,omitempty"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+	LockedUntil   time.Time `json:"lockedUntil"`
+}
+
+type DeleteLockoutRequest struct {
+	Scope   string `json:"-" param:"scope" validate:"nonzero"`
+	Subject string `json:"-" param:"subject" validate:"nonzero"`
 }
 
 type AuthenticatedRequest[T any] struct {
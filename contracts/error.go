@@ -1,6 +1,10 @@
 package contracts
 
+// AccountSuspendedErrorCode is the code of errors rejecting suspended users.
+const AccountSuspendedErrorCode = "account_suspended"
+
 type HTTPError struct {
 	Message    string `json:"message"`
+	Code       string `json:"code,omitempty"`
 	IncidentID string `json:"incident_id,omitempty"`
 }
@@ -0,0 +1,31 @@
+package contracts
+
+import "time"
+
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+type Export struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"userId"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+type StartExportRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type GetExportRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type GetExportArchiveRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
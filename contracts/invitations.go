@@ -0,0 +1,29 @@
+package contracts
+
+import "time"
+
+type Invitation struct {
+	ID        int        `json:"id"`
+	Role      string     `json:"role"`
+	MaxUses   int        `json:"maxUses"`
+	Uses      int        `json:"uses"`
+	CreatedBy int        `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type CreateInvitationRequest struct {
+	Role      string     `json:"role,omitempty"`
+	MaxUses   int        `json:"max_uses,omitempty" validate:"min=0,max=10000"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateInvitationResponse struct {
+	Invitation
+	Code string `json:"code"`
+}
+
+type RevokeInvitationRequest struct {
+	InvitationID int `json:"-" param:"invitationId" validate:"nonzero"`
+}
@@ -1,18 +1,27 @@
 package contracts
 
 import (
-	"errors"
+	"strconv"
 	"time"
 )
 
 type Movie struct {
-	ID          int        `json:"id"`
-	Title       string     `json:"title"`
-	PosterURL   *string    `json:"posterUrl,omitempty"`
-	ReleaseDate time.Time  `json:"releaseDate"`
-	AvgRating   *float64   `json:"avgRating,omitempty"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	PosterURL   *string     `json:"posterUrl,omitempty"`
+	ReleaseDate time.Time   `json:"releaseDate"`
+	AvgRating   *float64    `json:"avgRating,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	DeletedAt   *time.Time  `json:"deletedAt,omitempty"`
+	Relevance   *float64    `json:"relevance,omitempty"`
+	Highlights  *Highlights `json:"highlights,omitempty"`
+}
+
+// Highlights are HTML: the title and description text is escaped, the matching words are wrapped in <mark> tags,
+// the only markup.
+type Highlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
 type MovieDetails struct {
@@ -65,9 +74,27 @@ type MovieCreditInfo struct {
 	IMDbURL  *string `json:"imdbUrl,omitempty"`
 }
 
+const (
+	GenreMatchAny = "any"
+	GenreMatchAll = "all"
+)
+
 type GetMoviesRequest struct {
 	PaginatedRequestOrdered
-	SearchTerm *string `json:"-" query:"q"`
+	SearchTerm     *string    `json:"-" query:"q"`
+	GenreIDs       []int      `json:"-" query:"genre_ids"`
+	GenreMatch     *string    `json:"-" query:"genre_match"`
+	StarID         *int       `json:"-" query:"star_id"`
+	StarRole       *string    `json:"-" query:"star_role"`
+	ReleasedAfter  *time.Time `json:"-" query:"released_after"`
+	ReleasedBefore *time.Time `json:"-" query:"released_before"`
+	MinAvgRating   *float64   `json:"-" query:"min_avg_rating"`
+	MaxAvgRating   *float64   `json:"-" query:"max_avg_rating"`
+	MinIMDbRating  *float64   `json:"-" query:"min_imdb_rating"`
+	MaxIMDbRating  *float64   `json:"-" query:"max_imdb_rating"`
+	MinMetascore   *int       `json:"-" query:"min_metascore"`
+	MaxMetascore   *int       `json:"-" query:"max_metascore"`
+	HasReviews     *bool      `json:"-" query:"has_reviews"`
 }
 
 type CreateMovieRequest struct {
@@ -102,18 +129,45 @@ type DeleteMovieRequest struct {
 	MovieID int `json:"-" param:"movieId" validate:"nonzero"`
 }
 
+// ToQueryParams returns the single valued parameters, GenreIDs is sent as a repeated genre_ids parameter.
 func (r *GetMoviesRequest) ToQueryParams() map[string]string {
 	params := r.PaginatedRequestOrdered.ToQueryParams()
 	if r.SearchTerm != nil {
 		params["q"] = *r.SearchTerm
 	}
+	if r.GenreMatch != nil {
+		params["genre_match"] = *r.GenreMatch
+	}
+	if r.StarID != nil {
+		params["star_id"] = strconv.Itoa(*r.StarID)
+	}
+	if r.StarRole != nil {
+		params["star_role"] = *r.StarRole
+	}
+	if r.ReleasedAfter != nil {
+		params["released_after"] = r.ReleasedAfter.Format(time.RFC3339Nano)
+	}
+	if r.ReleasedBefore != nil {
+		params["released_before"] = r.ReleasedBefore.Format(time.RFC3339Nano)
+	}
+	setFloatParam(params, "min_avg_rating", r.MinAvgRating)
+	setFloatParam(params, "max_avg_rating", r.MaxAvgRating)
+	setFloatParam(params, "min_imdb_rating", r.MinIMDbRating)
+	setFloatParam(params, "max_imdb_rating", r.MaxIMDbRating)
+	if r.MinMetascore != nil {
+		params["min_metascore"] = strconv.Itoa(*r.MinMetascore)
+	}
+	if r.MaxMetascore != nil {
+		params["max_metascore"] = strconv.Itoa(*r.MaxMetascore)
+	}
+	if r.HasReviews != nil {
+		params["has_reviews"] = strconv.FormatBool(*r.HasReviews)
+	}
 	return params
 }
 
-func ValidateSortRequest(sort string) error {
-	if sort != "id" && sort != "title" && sort != "releaseDate" && sort != "created_at" {
-		return errors.New("invalid sort field")
+func setFloatParam(params map[string]string, key string, value *float64) {
+	if value != nil {
+		params[key] = strconv.FormatFloat(*value, 'f', -1, 64)
 	}
-
-	return nil
 }
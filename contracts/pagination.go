@@ -2,9 +2,11 @@ package contracts
 
 import "strconv"
 
+// PaginatedRequest selects a page by number, or by Cursor, one of the cursors of a previous response.
 type PaginatedRequest struct {
-	Page int `json:"page" query:"page"`
-	Size int `json:"size" query:"size"`
+	Page   int    `json:"page" query:"page"`
+	Size   int    `json:"size" query:"size"`
+	Cursor string `json:"cursor,omitempty" query:"cursor"`
 }
 
 type PaginatedRequestOrdered struct {
@@ -18,6 +20,10 @@ type PaginationSetter interface {
 	SetSize(size int)
 }
 
+type CursorSetter interface {
+	SetCursor(cursor string)
+}
+
 type PaginationGetter[T any] interface {
 	GetPage() int
 	GetSize() int
@@ -25,11 +31,14 @@ type PaginationGetter[T any] interface {
 	GetItems() []T
 }
 
+// PaginatedResponse is a page of a list, Page is 0 when it was selected by cursor.
 type PaginatedResponse[T any] struct {
-	Page  int `json:"page" validate:"min=0"`
-	Size  int `json:"size" validate:"min=0"`
-	Total int `json:"total"`
-	Items []T `json:"items"`
+	Page       int    `json:"page" validate:"min=0"`
+	Size       int    `json:"size" validate:"min=0"`
+	Total      int    `json:"total"`
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 type PaginatedResponseOrdered[T any] struct {
@@ -46,6 +55,9 @@ func (req *PaginatedRequest) ToQueryParams() map[string]string {
 	if req.Size > 0 {
 		params["size"] = strconv.Itoa(req.Size)
 	}
+	if req.Cursor != "" {
+		params["cursor"] = req.Cursor
+	}
 	return params
 }
 
@@ -68,6 +80,10 @@ func (req *PaginatedRequest) SetSize(size int) {
 	req.Size = size
 }
 
+func (req *PaginatedRequest) SetCursor(cursor string) {
+	req.Cursor = cursor
+}
+
 func (res *PaginatedResponse[T]) GetPage() int {
 	return res.Page
 }
@@ -92,6 +108,10 @@ func (req *PaginatedRequestOrdered) SetSize(size int) {
 	req.PaginatedRequest.SetSize(size)
 }
 
+func (req *PaginatedRequestOrdered) SetCursor(cursor string) {
+	req.PaginatedRequest.SetCursor(cursor)
+}
+
 func (res *PaginatedResponseOrdered[T]) GetPage() int {
 	return res.PaginatedResponse.GetPage()
 }
@@ -41,7 +41,6 @@ type CreateReviewRequest struct {
 type UpdateReviewRequest struct {
 	UserID   int     `json:"-" param:"userId" validate:"nonzero"`
 	ReviewID int     `json:"-" param:"reviewId" validate:"nonzero"`
-	MovieID  int     `json:"movieId" validate:"nonzero"`
 	Title    *string `json:"title,omitempty" validate:"max=100"`
 	Content  *string `json:"description,omitempty" validate:"max=1000"`
 	Rating   *int    `json:"rating,omitempty" validate:"min=1,max=10"`
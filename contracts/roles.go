@@ -0,0 +1,47 @@
+package contracts
+
+import "time"
+
+const (
+	GenresWritePermission       = "genres.write"
+	StarsWritePermission        = "stars.write"
+	MoviesWritePermission       = "movies.write"
+	ReviewsModeratePermission   = "reviews.moderate"
+	UsersManagePermission       = "users.manage"
+	UsersImpersonatePermission  = "users.impersonate"
+	RolesManagePermission       = "roles.manage"
+	InvitationsManagePermission = "invitations.manage"
+)
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	BuiltIn     bool      `json:"builtIn"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type GetRoleRequest struct {
+	Name string `json:"-"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Name        string   `json:"-"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type DeleteRoleRequest struct {
+	Name string `json:"-"`
+}
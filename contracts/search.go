@@ -0,0 +1,19 @@
+package contracts
+
+const (
+	MovieSuggestionType = "movie"
+	StarSuggestionType  = "star"
+	GenreSuggestionType = "genre"
+)
+
+type Suggestion struct {
+	Type     string  `json:"type"`
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Year     *int    `json:"year,omitempty"`
+	KnownFor *string `json:"knownFor,omitempty"`
+}
+
+type SuggestRequest struct {
+	Query string `json:"-" query:"q"`
+}
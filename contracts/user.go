@@ -1,6 +1,9 @@
 package contracts
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 const (
 	AdminRole  = "admin"
@@ -9,14 +12,49 @@ const (
 )
 
 type User struct {
-	ID        int        `json:"id"`
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	AvatarURL string     `json:"avatarUrl"`
-	Bio       *string    `json:"bio,omitempty"`
-	Role      string     `json:"role"`
-	CreatedAt time.Time  `json:"createdAt"`
-	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	AvatarURL       string     `json:"avatarUrl"`
+	Bio             *string    `json:"bio,omitempty"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"`
+}
+
+type DirectoryUser struct {
+	User
+	ReviewsCount int `json:"reviewsCount"`
+}
+
+type GetUsersRequest struct {
+	PaginatedRequestOrdered
+	Search        *string    `json:"-" query:"q"`
+	Role          *string    `json:"-" query:"role"`
+	CreatedAfter  *time.Time `json:"-" query:"created_after"`
+	CreatedBefore *time.Time `json:"-" query:"created_before"`
+	Deleted       *bool      `json:"-" query:"deleted"`
+}
+
+func (r *GetUsersRequest) ToQueryParams() map[string]string {
+	params := r.PaginatedRequestOrdered.ToQueryParams()
+	if r.Search != nil {
+		params["q"] = *r.Search
+	}
+	if r.Role != nil {
+		params["role"] = *r.Role
+	}
+	if r.CreatedAfter != nil {
+		params["created_after"] = r.CreatedAfter.Format(time.RFC3339Nano)
+	}
+	if r.CreatedBefore != nil {
+		params["created_before"] = r.CreatedBefore.Format(time.RFC3339Nano)
+	}
+	if r.Deleted != nil {
+		params["deleted"] = strconv.FormatBool(*r.Deleted)
+	}
+	return params
 }
 
 type UserWithPassword struct {
@@ -40,7 +78,6 @@ type UpdateUserRoleRequest struct {
 type UpdateUserRequest struct {
 	UserID    int     `json:"-" param:"userId" validate:"nonzero"`
 	Username  *string `json:"username,omitempty" validate:"username"`
-	Password  *string `json:"password,omitempty" validate:"password"`
 	AvatarURL *string `json:"avatarUrl,omitempty"`
 	Bio       *string `json:"bio,omitempty"`
 }
@@ -49,6 +86,37 @@ type DeleteUserRequest struct {
 	UserID int `json:"-" param:"userId" validate:"nonzero"`
 }
 
-func ValidateRole(role string) bool {
-	return role == AdminRole || role == EditorRole || role == UserRole
+type RestoreUserRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type AnonymizeUserRequest struct {
+	UserID          int    `json:"-" param:"userId" validate:"nonzero"`
+	CurrentPassword string `json:"current_password,omitempty"`
+}
+
+type Suspension struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userId"`
+	Reason    string     `json:"reason"`
+	CreatedBy int        `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LiftedAt  *time.Time `json:"liftedAt,omitempty"`
+	LiftedBy  *int       `json:"liftedBy,omitempty"`
+}
+
+type SuspendUserRequest struct {
+	UserID    int        `json:"-" param:"userId" validate:"nonzero"`
+	Reason    string     `json:"reason" validate:"min=1,max=255"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type GetSuspensionsRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type LiftSuspensionRequest struct {
+	UserID       int `json:"-" param:"userId" validate:"nonzero"`
+	SuspensionID int `json:"-" param:"suspensionId" validate:"nonzero"`
 }
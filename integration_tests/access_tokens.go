@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/DavidMovas/Movies-Reviews/internal/totp"
+	"github.com/stretchr/testify/require"
+)
+
+// twoFactorRequiredRole is a role the test server requires two-factor authentication for.
+const twoFactorRequiredRole = "curator"
+
+func accessTokensAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	admin, err := c.GetUserByUsername(&contracts.GetUserByUsernameRequest{Username: cfg.Admin.Username})
+	require.NoError(t, err)
+	adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+
+	var token *contracts.AccessTokenWithSecret
+
+	t.Run("accesstokens.CreateAccessToken: unknown scope", func(t *testing.T) {
+		req := &contracts.CreateAccessTokenRequest{UserID: admin.ID, Name: "ci", Scopes: []string{"genres:delete"}}
+		_, err := c.CreateAccessToken(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "unknown scope \"genres:delete\"")
+	})
+
+	t.Run("accesstokens.CreateAccessToken: expiration in the past", func(t *testing.T) {
+		req := &contracts.CreateAccessTokenRequest{UserID: admin.ID, Name: "ci", Scopes: []string{contracts.GenresWriteScope}, ExpiresAt: ptr(time.Now().Add(-time.Hour))}
+		_, err := c.CreateAccessToken(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "expiration time must be in the future")
+	})
+
+	t.Run("accesstokens.CreateAccessToken: another user", func(t *testing.T) {
+		req := &contracts.CreateAccessTokenRequest{UserID: admin.ID, Name: "ci", Scopes: []string{contracts.GenresWriteScope}}
+		_, err := c.CreateAccessToken(contracts.NewAuthenticated(req, johnMooreToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("accesstokens.CreateAccessToken: success", func(t *testing.T) {
+		req := &contracts.CreateAccessTokenRequest{UserID: admin.ID, Name: "ci", Scopes: []string{contracts.GenresWriteScope}}
+		token, err = c.CreateAccessToken(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, admin.ID, token.UserID)
+		require.Equal(t, []string{contracts.GenresWriteScope}, token.Scopes)
+		require.Regexp(t, "^mrp_", token.Token)
+		require.Nil(t, token.LastUsedAt)
+	})
+
+	t.Run("genres.CreateGenre: with access token", func(t *testing.T) {
+		genre, err := c.CreateGenre(contracts.NewAuthenticated(contracts.CreateGenreRequest{Name: "Token genre"}, token.Token))
+		require.NoError(t, err)
+
+		err = c.DeleteGenreByID(contracts.NewAuthenticated(contracts.DeleteGenreRequest{GenreID: genre.ID}, token.Token))
+		require.NoError(t, err)
+	})
+
+	t.Run("stars.CreateStar: access token without scope", func(t *testing.T) {
+		req := &contracts.CreateStarRequest{FirstName: "Token", LastName: "Star", BirthDate: time.Now()}
+		_, err := c.CreateStar(contracts.NewAuthenticated(req, token.Token))
+		requireForbiddenError(t, err, "insufficient token scope")
+	})
+
+	t.Run("accesstokens.CreateAccessToken: with access token", func(t *testing.T) {
+		req := &contracts.CreateAccessTokenRequest{UserID: admin.ID, Name: "nested", Scopes: []string{contracts.GenresWriteScope}}
+		_, err := c.CreateAccessToken(contracts.NewAuthenticated(req, token.Token))
+		requireForbiddenError(t, err, "not allowed with a personal access token")
+	})
+
+	t.Run("accesstokens.GetAccessTokens: success", func(t *testing.T) {
+		req := &contracts.GetAccessTokensRequest{UserID: admin.ID}
+		tokens, err := c.GetAccessTokens(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		require.Equal(t, token.ID, tokens[0].ID)
+		require.NotNil(t, tokens[0].LastUsedAt)
+	})
+
+	t.Run("accesstokens.DeleteAccessToken: another user's token", func(t *testing.T) {
+		req := &contracts.DeleteAccessTokenRequest{UserID: johnMoore.ID, TokenID: token.ID}
+		err := c.DeleteAccessToken(contracts.NewAuthenticated(req, johnMooreToken))
+		requireNotFoundError(t, err, "access token", "id", token.ID)
+	})
+
+	t.Run("accesstokens.DeleteAccessToken: success", func(t *testing.T) {
+		req := &contracts.DeleteAccessTokenRequest{UserID: admin.ID, TokenID: token.ID}
+		err := c.DeleteAccessToken(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		_, err = c.CreateGenre(contracts.NewAuthenticated(contracts.CreateGenreRequest{Name: "Token genre"}, token.Token))
+		requireForbiddenError(t, err, "invalid token")
+	})
+
+	curator := registerRandomUser(t, c, "curator", "curator")
+	var curatorToken *contracts.AccessTokenWithSecret
+
+	t.Run("accesstokens.Authenticate: role requires two-factor authentication", func(t *testing.T) {
+		req := &contracts.CreateAccessTokenRequest{UserID: curator.ID, Name: "ci", Scopes: []string{contracts.GenresWriteScope}}
+		curatorToken, err = c.CreateAccessToken(contracts.NewAuthenticated(req, login(t, c, curator.Email, standardPassword)))
+		require.NoError(t, err)
+
+		role := &contracts.CreateRoleRequest{Name: twoFactorRequiredRole, Permissions: []string{contracts.GenresWritePermission}}
+		_, err = c.CreateRole(contracts.NewAuthenticated(role, adminToken))
+		require.NoError(t, err)
+
+		err = c.UpdateUserRole(contracts.NewAuthenticated(&contracts.UpdateUserRoleRequest{UserID: curator.ID, Role: twoFactorRequiredRole}, adminToken))
+		require.NoError(t, err)
+
+		_, err = c.CreateGenre(contracts.NewAuthenticated(contracts.CreateGenreRequest{Name: "Curated genre"}, curatorToken.Token))
+		requireForbiddenError(t, err, "two-factor authentication must be enabled first")
+	})
+
+	t.Run("accesstokens.Authenticate: two-factor authentication enabled", func(t *testing.T) {
+		accessToken := login(t, c, curator.Email, standardPassword)
+		enrolment, err := c.EnrollTwoFactor(accessToken)
+		require.NoError(t, err)
+
+		code, err := totp.Code(enrolment.Secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		_, err = c.ConfirmTwoFactor(contracts.NewAuthenticated(&contracts.ConfirmTwoFactorRequest{Code: code}, accessToken))
+		require.NoError(t, err)
+
+		genre, err := c.CreateGenre(contracts.NewAuthenticated(contracts.CreateGenreRequest{Name: "Curated genre"}, curatorToken.Token))
+		require.NoError(t, err)
+
+		err = c.DeleteGenreByID(contracts.NewAuthenticated(contracts.DeleteGenreRequest{GenreID: genre.ID}, curatorToken.Token))
+		require.NoError(t, err)
+	})
+}
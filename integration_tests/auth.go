@@ -28,7 +28,7 @@ const (
 	defaultAvatarURL = "https://gravatar.com/avatar/00000000000000000000000000000000?d=mp&f=y"
 )
 
-func authAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
+func authAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 	t.Run("auth.RegisterUser: wrong email", func(t *testing.T) {
 		req := &contracts.RegisterUserRequest{
 			Username: "johnmoore",
@@ -80,6 +80,9 @@ func authAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 			require.Equal(t, cc.req.Username, user.Username)
 			require.Equal(t, cc.req.Email, user.Email)
 			require.Equal(t, contracts.UserRole, user.Role)
+			require.Nil(t, user.EmailVerifiedAt)
+
+			verifyEmail(t, c, cfg, user.Email)
 		}
 	})
 
@@ -101,16 +104,66 @@ func authAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 		require.Equal(t, johnMoore.Username, res.User.Username)
 		require.Equal(t, contracts.UserRole, res.User.Role)
 		require.Equal(t, defaultAvatarURL, res.User.AvatarURL)
+		require.NotEmpty(t, res.RefreshToken)
 		johnMooreToken = res.AccessToken
 	})
 
+	t.Run("auth.RefreshToken: invalid token", func(t *testing.T) {
+		req := &contracts.RefreshTokenRequest{RefreshToken: "invalid-refresh-token"}
+		_, err := c.RefreshToken(req)
+		requireUnauthorizedError(t, err, "invalid refresh token")
+	})
+
+	t.Run("auth.RefreshToken: rotation and reuse detection", func(t *testing.T) {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{
+			Email:    markTwain.Email,
+			Password: markTwainPass,
+			Device:   "integration-tests",
+		})
+		require.NoError(t, err)
+
+		rotated, err := c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		require.NoError(t, err)
+		require.NotEmpty(t, rotated.AccessToken)
+		require.NotEmpty(t, rotated.RefreshToken)
+		require.NotEqual(t, res.RefreshToken, rotated.RefreshToken)
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		requireUnauthorizedError(t, err, "refresh token reuse detected")
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: rotated.RefreshToken})
+		requireUnauthorizedError(t, err, "invalid refresh token")
+	})
+
+	t.Run("auth.Logout: non-authenticated", func(t *testing.T) {
+		err := c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, ""))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.Logout: success", func(t *testing.T) {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{
+			Email:    markTwain.Email,
+			Password: markTwainPass,
+		})
+		require.NoError(t, err)
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{RefreshToken: res.RefreshToken}, res.AccessToken))
+		require.NoError(t, err)
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, res.AccessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		requireUnauthorizedError(t, err, "invalid refresh token")
+	})
+
 	t.Run("auth.LoginUser: wrong email", func(t *testing.T) {
 		req := &contracts.LoginUserRequest{
 			Email:    "noexistinguser@mail.com",
 			Password: standardPassword,
 		}
 		_, err := c.LoginUser(req)
-		requireNotFoundError(t, err, "user", "email", req.Email)
+		requireUnauthorizedError(t, err, "invalid credentials")
 	})
 
 	t.Run("auth.LoginUser: wrong password", func(t *testing.T) {
@@ -119,7 +172,7 @@ func authAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 			Password: johnMoorePass + "wrong",
 		}
 		_, err := c.LoginUser(req)
-		requireUnauthorizedError(t, err, "invalid password")
+		requireUnauthorizedError(t, err, "invalid credentials")
 	})
 }
 
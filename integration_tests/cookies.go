@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// cookieSessionAPIChecks takes a client of its own, it keeps the session cookies.
+func cookieSessionAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
+	user := registerRandomUser(t, c, "cookies", "cookies")
+	updateReq := &contracts.UpdateUserRequest{UserID: user.ID, Bio: ptr("Cookie session")}
+	var csrfToken string
+
+	t.Run("auth.LoginUser: cookies", func(t *testing.T) {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword, UseCookies: true})
+		require.NoError(t, err)
+		require.Empty(t, res.AccessToken)
+		require.Empty(t, res.RefreshToken)
+		require.NotEmpty(t, res.CSRFToken)
+		csrfToken = res.CSRFToken
+	})
+
+	t.Run("users.UpdateUserData: missing CSRF token", func(t *testing.T) {
+		_, err := c.UpdateUserData(contracts.NewAuthenticated(updateReq, ""))
+		requireForbiddenError(t, err, "invalid CSRF token")
+	})
+
+	t.Run("users.UpdateUserData: invalid CSRF token", func(t *testing.T) {
+		c.SetCSRFToken("invalid")
+		_, err := c.UpdateUserData(contracts.NewAuthenticated(updateReq, ""))
+		requireForbiddenError(t, err, "invalid CSRF token")
+	})
+
+	t.Run("auth.RefreshToken: cookies", func(t *testing.T) {
+		_, err := c.RefreshToken(&contracts.RefreshTokenRequest{})
+		requireForbiddenError(t, err, "invalid CSRF token")
+
+		c.SetCSRFToken(csrfToken)
+
+		tokens, err := c.RefreshToken(&contracts.RefreshTokenRequest{})
+		require.NoError(t, err)
+		require.Empty(t, tokens.AccessToken)
+		require.NotEmpty(t, tokens.CSRFToken)
+		c.SetCSRFToken(tokens.CSRFToken)
+	})
+
+	t.Run("users.UpdateUserData: cookies", func(t *testing.T) {
+		updated, err := c.UpdateUserData(contracts.NewAuthenticated(updateReq, ""))
+		require.NoError(t, err)
+		require.Equal(t, *updateReq.Bio, *updated.Bio)
+	})
+
+	t.Run("auth.Logout: cookies", func(t *testing.T) {
+		err := c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, ""))
+		require.NoError(t, err)
+
+		_, err = c.UpdateUserData(contracts.NewAuthenticated(updateReq, ""))
+		requireForbiddenError(t, err, "insufficient permissions")
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{})
+		requireBadRequestError(t, err, "refresh token is required")
+	})
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+const confirmationTokenPrefix = "Confirmation token: "
+
+func credentialsAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	user := registerRandomUser(t, c, "credentials", "credentials")
+	other := registerRandomUser(t, c, "credentialsOther", "credentials-other")
+	newPassword := "cHanged!123"
+	newEmail := "changed-" + user.Email
+
+	res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+	require.NoError(t, err)
+	accessToken := res.AccessToken
+
+	t.Run("auth.ChangePassword: wrong current password", func(t *testing.T) {
+		req := &contracts.ChangePasswordRequest{CurrentPassword: "wRong!123", NewPassword: newPassword}
+		_, err := c.ChangePassword(contracts.NewAuthenticated(req, accessToken))
+		requireUnauthorizedError(t, err, "invalid credentials")
+	})
+
+	t.Run("auth.ChangePassword: non-authenticated", func(t *testing.T) {
+		req := &contracts.ChangePasswordRequest{CurrentPassword: standardPassword, NewPassword: newPassword}
+		_, err := c.ChangePassword(contracts.NewAuthenticated(req, ""))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.ChangePassword: success", func(t *testing.T) {
+		pat, err := c.CreateAccessToken(contracts.NewAuthenticated(&contracts.CreateAccessTokenRequest{
+			UserID: user.ID,
+			Name:   "ci",
+			Scopes: []string{contracts.UsersWriteScope},
+		}, accessToken))
+		require.NoError(t, err)
+
+		req := &contracts.ChangePasswordRequest{CurrentPassword: standardPassword, NewPassword: newPassword}
+		tokens, err := c.ChangePassword(contracts.NewAuthenticated(req, accessToken))
+		require.NoError(t, err)
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		requireUnauthorizedError(t, err, "invalid refresh token")
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, accessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+
+		_, err = c.UpdateUserData(contracts.NewAuthenticated(&contracts.UpdateUserRequest{UserID: user.ID, Bio: ptr("bio")}, pat.Token))
+		requireForbiddenError(t, err, "invalid token")
+
+		_, err = c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		requireUnauthorizedError(t, err, "invalid credentials")
+
+		accessToken = tokens.AccessToken
+		_, err = c.UpdateUserData(contracts.NewAuthenticated(&contracts.UpdateUserRequest{UserID: user.ID, Bio: ptr("bio")}, accessToken))
+		require.NoError(t, err)
+	})
+
+	t.Run("auth.ChangeEmail: wrong current password", func(t *testing.T) {
+		req := &contracts.ChangeEmailRequest{CurrentPassword: standardPassword, NewEmail: newEmail}
+		err := c.ChangeEmail(contracts.NewAuthenticated(req, accessToken))
+		requireUnauthorizedError(t, err, "invalid credentials")
+	})
+
+	t.Run("auth.ChangeEmail: same email", func(t *testing.T) {
+		req := &contracts.ChangeEmailRequest{CurrentPassword: newPassword, NewEmail: user.Email}
+		err := c.ChangeEmail(contracts.NewAuthenticated(req, accessToken))
+		requireBadRequestError(t, err, "new email is the same as the current one")
+	})
+
+	t.Run("auth.ChangeEmail: email already in use", func(t *testing.T) {
+		req := &contracts.ChangeEmailRequest{CurrentPassword: newPassword, NewEmail: other.Email}
+		err := c.ChangeEmail(contracts.NewAuthenticated(req, accessToken))
+		requireAlreadyExistsError(t, err, "user", "email", other.Email)
+	})
+
+	t.Run("auth.ConfirmEmailChange: invalid token", func(t *testing.T) {
+		err := c.ConfirmEmailChange(&contracts.ConfirmEmailChangeRequest{Token: "invalid"})
+		requireBadRequestError(t, err, "invalid or expired token")
+	})
+
+	t.Run("auth.ConfirmEmailChange: success", func(t *testing.T) {
+		req := &contracts.ChangeEmailRequest{CurrentPassword: newPassword, NewEmail: newEmail}
+		err := c.ChangeEmail(contracts.NewAuthenticated(req, accessToken))
+		require.NoError(t, err)
+
+		token := lastMailToken(t, cfg.Mail.Dir, newEmail, confirmationTokenPrefix)
+
+		// Nothing changes until the token is confirmed
+		login(t, c, user.Email, newPassword)
+
+		err = c.ConfirmEmailChange(&contracts.ConfirmEmailChangeRequest{Token: token})
+		require.NoError(t, err)
+
+		err = c.ConfirmEmailChange(&contracts.ConfirmEmailChangeRequest{Token: token})
+		requireBadRequestError(t, err, "invalid or expired token")
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, accessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+
+		_, err = c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: newPassword})
+		requireUnauthorizedError(t, err, "invalid credentials")
+
+		loginRes, err := c.LoginUser(&contracts.LoginUserRequest{Email: newEmail, Password: newPassword})
+		require.NoError(t, err)
+		require.Equal(t, newEmail, loginRes.User.Email)
+		require.NotNil(t, loginRes.User.EmailVerifiedAt)
+	})
+}
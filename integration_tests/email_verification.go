@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const verificationTokenPrefix = "Verification token: "
+
+func emailVerificationAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	user := registerRandomUser(t, c, "verify", "verify")
+
+	t.Run("auth.Register: verification email sent", func(t *testing.T) {
+		require.Len(t, readMails(t, cfg.Mail.Dir, user.Email), 1)
+		require.Nil(t, user.EmailVerifiedAt)
+	})
+
+	t.Run("auth.VerifyEmail: unverified user can not create reviews", func(t *testing.T) {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		require.NoError(t, err)
+		require.False(t, emailVerifiedClaim(t, res.AccessToken))
+
+		req := &contracts.CreateReviewRequest{UserID: user.ID, MovieID: 1, Title: "Title", Content: "Content", Rating: 5}
+		_, err = c.CreateReview(*contracts.NewAuthenticated(req, res.AccessToken))
+		requireForbiddenError(t, err, "email is not verified")
+	})
+
+	t.Run("auth.VerifyEmail: invalid token", func(t *testing.T) {
+		err := c.VerifyEmail(&contracts.VerifyEmailRequest{Token: "invalid"})
+		requireBadRequestError(t, err, "invalid or expired token")
+	})
+
+	t.Run("auth.ResendEmailVerification: unknown email", func(t *testing.T) {
+		email := "unknown-verify@mail.com"
+		err := c.ResendEmailVerification(&contracts.ResendEmailVerificationRequest{Email: email})
+		require.NoError(t, err)
+		require.Empty(t, readMails(t, cfg.Mail.Dir, email))
+	})
+
+	t.Run("auth.VerifyEmail: success", func(t *testing.T) {
+		first := lastMailToken(t, cfg.Mail.Dir, user.Email, verificationTokenPrefix)
+
+		err := c.ResendEmailVerification(&contracts.ResendEmailVerificationRequest{Email: user.Email})
+		require.NoError(t, err)
+		require.Len(t, readMails(t, cfg.Mail.Dir, user.Email), 2)
+
+		err = c.VerifyEmail(&contracts.VerifyEmailRequest{Token: first})
+		requireBadRequestError(t, err, "invalid or expired token")
+
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		require.NoError(t, err)
+
+		verifyEmail(t, c, cfg, user.Email)
+
+		refreshed, err := c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		require.NoError(t, err)
+		require.True(t, emailVerifiedClaim(t, refreshed.AccessToken))
+
+		verified, err := c.GetUserByID(&contracts.GetUserByIDRequest{UserID: user.ID})
+		require.NoError(t, err)
+		require.NotNil(t, verified.EmailVerifiedAt)
+	})
+
+	t.Run("auth.ResendEmailVerification: already verified", func(t *testing.T) {
+		err := c.ResendEmailVerification(&contracts.ResendEmailVerificationRequest{Email: user.Email})
+		require.NoError(t, err)
+		require.Len(t, readMails(t, cfg.Mail.Dir, user.Email), 2)
+	})
+}
+
+func verifyEmail(t *testing.T, c *client.Client, cfg *config.Config, email string) {
+	token := lastMailToken(t, cfg.Mail.Dir, email, verificationTokenPrefix)
+	err := c.VerifyEmail(&contracts.VerifyEmailRequest{Token: token})
+	require.NoError(t, err)
+}
+
+func emailVerifiedClaim(t *testing.T, accessToken string) bool {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(accessToken, claims)
+	require.NoError(t, err)
+
+	verified, _ := claims["email_verified"].(bool)
+	return verified
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
 	"github.com/stretchr/testify/require"
 )
@@ -32,6 +33,18 @@ func requireAlreadyExistsError(t *testing.T, err error, subject, key string, val
 	requireAPIError(t, err, http.StatusConflict, msg)
 }
 
+func requireTooManyRequestsError(t *testing.T, err error, msg string) {
+	requireAPIError(t, err, http.StatusTooManyRequests, msg)
+}
+
+func requireSuspendedError(t *testing.T, err error, msg string) {
+	requireAPIError(t, err, http.StatusForbidden, msg)
+
+	var cerr *client.Error
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, contracts.AccountSuspendedErrorCode, cerr.ErrorCode)
+}
+
 func requireAPIError(t *testing.T, err error, statusCode int, msg string) {
 	var cerr *client.Error
 	ok := errors.As(err, &cerr)
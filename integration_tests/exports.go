@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func exportsAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
+	t.Run("exports.GetExport: not found", func(t *testing.T) {
+		req := &contracts.GetExportRequest{UserID: johnMoore.ID}
+		_, err := c.GetExport(contracts.NewAuthenticated(req, johnMooreToken))
+		requireNotFoundError(t, err, "export", "user id", johnMoore.ID)
+	})
+
+	t.Run("exports.StartExport: another user", func(t *testing.T) {
+		req := &contracts.StartExportRequest{UserID: johnMoore.ID}
+		_, err := c.StartExport(contracts.NewAuthenticated(req, markTwainToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("exports.StartExport: success", func(t *testing.T) {
+		req := &contracts.StartExportRequest{UserID: johnMoore.ID}
+		export, err := c.StartExport(contracts.NewAuthenticated(req, johnMooreToken))
+		require.NoError(t, err)
+		require.Equal(t, johnMoore.ID, export.UserID)
+
+		require.Eventually(t, func() bool {
+			res, getErr := c.GetExport(contracts.NewAuthenticated(&contracts.GetExportRequest{UserID: johnMoore.ID}, johnMooreToken))
+			return getErr == nil && res.Status == contracts.ExportStatusCompleted
+		}, 10*time.Second, 100*time.Millisecond)
+
+		export, err = c.GetExport(contracts.NewAuthenticated(&contracts.GetExportRequest{UserID: johnMoore.ID}, johnMooreToken))
+		require.NoError(t, err)
+		require.NotNil(t, export.ExpiresAt)
+	})
+
+	t.Run("exports.GetExportArchive: another user", func(t *testing.T) {
+		req := &contracts.GetExportArchiveRequest{UserID: johnMoore.ID}
+		_, err := c.GetExportArchive(contracts.NewAuthenticated(req, markTwainToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("exports.GetExportArchive: success", func(t *testing.T) {
+		req := &contracts.GetExportArchiveRequest{UserID: johnMoore.ID}
+		content, err := c.GetExportArchive(contracts.NewAuthenticated(req, johnMooreToken))
+		require.NoError(t, err)
+
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		require.NoError(t, err)
+
+		var data struct {
+			Profile *contracts.User     `json:"profile"`
+			Reviews []*contracts.Review `json:"reviews"`
+			Events  []struct {
+				Type string `json:"type"`
+			} `json:"events"`
+		}
+		require.NoError(t, json.Unmarshal(readZipFile(t, zr, "export.json"), &data))
+		require.Equal(t, johnMoore.ID, data.Profile.ID)
+		require.NotEmpty(t, data.Reviews)
+		require.Equal(t, "account_created", data.Events[0].Type)
+
+		reviews, err := csv.NewReader(bytes.NewReader(readZipFile(t, zr, "reviews.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, reviews, len(data.Reviews)+1)
+
+		events, err := csv.NewReader(bytes.NewReader(readZipFile(t, zr, "events.csv"))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, events, len(data.Events)+1)
+	})
+
+	t.Run("exports.GetExportArchive: not found", func(t *testing.T) {
+		req := &contracts.GetExportArchiveRequest{UserID: markTwain.ID}
+		_, err := c.GetExportArchive(contracts.NewAuthenticated(req, markTwainToken))
+		requireNotFoundError(t, err, "export archive", "user id", markTwain.ID)
+	})
+}
+
+func readZipFile(t *testing.T, zr *zip.Reader, name string) []byte {
+	f, err := zr.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return content
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func impersonationAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	admin, err := c.GetUserByUsername(&contracts.GetUserByUsernameRequest{Username: cfg.Admin.Username})
+	require.NoError(t, err)
+	adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+	target := registerRandomUser(t, c, "impersonated", "impersonated")
+	support := registerRandomUser(t, c, "support", "support")
+
+	var impersonationToken string
+
+	t.Run("auth.ImpersonateUser: insufficient permissions", func(t *testing.T) {
+		req := &contracts.ImpersonateUserRequest{UserID: target.ID, Reason: "Support request"}
+		_, err := c.ImpersonateUser(contracts.NewAuthenticated(req, markTwainToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.ImpersonateUser: yourself", func(t *testing.T) {
+		req := &contracts.ImpersonateUserRequest{UserID: admin.ID, Reason: "Support request"}
+		_, err := c.ImpersonateUser(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "can't impersonate yourself")
+	})
+
+	t.Run("auth.ImpersonateUser: user not found", func(t *testing.T) {
+		req := &contracts.ImpersonateUserRequest{UserID: target.ID + 1000, Reason: "Support request"}
+		_, err := c.ImpersonateUser(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "user", "id", target.ID+1000)
+	})
+
+	t.Run("auth.ImpersonateUser: success", func(t *testing.T) {
+		req := &contracts.ImpersonateUserRequest{UserID: target.ID, Reason: "Support request"}
+		res, err := c.ImpersonateUser(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, target.ID, res.User.ID)
+		require.NotEmpty(t, res.AccessToken)
+		impersonationToken = res.AccessToken
+
+		updateReq := &contracts.UpdateUserRequest{UserID: target.ID, Bio: ptr("Updated by support")}
+		user, err := c.UpdateUserData(contracts.NewAuthenticated(updateReq, impersonationToken))
+		require.NoError(t, err)
+		require.Equal(t, *updateReq.Bio, *user.Bio)
+
+		_, err = c.GetRoles(impersonationToken)
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.ChangePassword: impersonated", func(t *testing.T) {
+		req := &contracts.ChangePasswordRequest{CurrentPassword: standardPassword, NewPassword: "iMpersonated!123"}
+		_, err := c.ChangePassword(contracts.NewAuthenticated(req, impersonationToken))
+		requireForbiddenError(t, err, "not allowed while impersonating a user")
+	})
+
+	t.Run("auth.GetImpersonations: success", func(t *testing.T) {
+		req := &contracts.GetImpersonationsRequest{UserID: target.ID}
+		impersonations, err := c.GetImpersonations(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Len(t, impersonations, 1)
+		require.Equal(t, admin.ID, impersonations[0].ActorID)
+		require.Equal(t, "Support request", impersonations[0].Reason)
+	})
+
+	t.Run("roles.CreateRole: support", func(t *testing.T) {
+		req := &contracts.CreateRoleRequest{Name: "support", Permissions: []string{contracts.UsersImpersonatePermission}}
+		_, err := c.CreateRole(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		err = c.UpdateUserRole(contracts.NewAuthenticated(&contracts.UpdateUserRoleRequest{UserID: support.ID, Role: "support"}, adminToken))
+		require.NoError(t, err)
+	})
+
+	supportToken := login(t, c, support.Email, standardPassword)
+
+	t.Run("auth.ImpersonateUser: user with more permissions", func(t *testing.T) {
+		req := &contracts.ImpersonateUserRequest{UserID: admin.ID, Reason: "Support request"}
+		_, err := c.ImpersonateUser(contracts.NewAuthenticated(req, supportToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.RevokeUserSessions: impersonation token of the actor revoked", func(t *testing.T) {
+		req := &contracts.ImpersonateUserRequest{UserID: target.ID, Reason: "Support request"}
+		res, err := c.ImpersonateUser(contracts.NewAuthenticated(req, supportToken))
+		require.NoError(t, err)
+
+		err = c.RevokeUserSessions(contracts.NewAuthenticated(&contracts.RevokeUserSessionsRequest{UserID: support.ID}, adminToken))
+		require.NoError(t, err)
+
+		updateReq := &contracts.UpdateUserRequest{UserID: target.ID, Bio: ptr("Updated by support")}
+		_, err = c.UpdateUserData(contracts.NewAuthenticated(updateReq, res.AccessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+	})
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func invitationsAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	admin, err := c.GetUserByUsername(&contracts.GetUserByUsernameRequest{Username: cfg.Admin.Username})
+	require.NoError(t, err)
+	adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+
+	var editorInvitation *contracts.CreateInvitationResponse
+
+	t.Run("auth.CreateInvitation: insufficient permissions", func(t *testing.T) {
+		_, err := c.CreateInvitation(contracts.NewAuthenticated(&contracts.CreateInvitationRequest{}, markTwainToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.CreateInvitation: role not found", func(t *testing.T) {
+		req := &contracts.CreateInvitationRequest{Role: "unknown"}
+		_, err := c.CreateInvitation(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "role", "name", "unknown")
+	})
+
+	t.Run("auth.CreateInvitation: expired", func(t *testing.T) {
+		req := &contracts.CreateInvitationRequest{ExpiresAt: ptr(time.Now().Add(-time.Hour))}
+		_, err := c.CreateInvitation(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "invitation must expire in the future")
+	})
+
+	t.Run("auth.CreateInvitation: success", func(t *testing.T) {
+		req := &contracts.CreateInvitationRequest{Role: contracts.EditorRole, ExpiresAt: ptr(time.Now().Add(time.Hour))}
+		editorInvitation, err = c.CreateInvitation(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.NotEmpty(t, editorInvitation.Code)
+		require.Equal(t, contracts.EditorRole, editorInvitation.Role)
+		require.Equal(t, 1, editorInvitation.MaxUses)
+		require.Equal(t, admin.ID, editorInvitation.CreatedBy)
+	})
+
+	t.Run("auth.Register: invalid invitation", func(t *testing.T) {
+		_, err := c.RegisterUser(newInvitedUserRequest("invalid"))
+		requireBadRequestError(t, err, "invalid or expired invitation")
+	})
+
+	t.Run("auth.Register: failed registration keeps the invitation", func(t *testing.T) {
+		req := newInvitedUserRequest(editorInvitation.Code)
+		req.Username = admin.Username
+		_, err := c.RegisterUser(req)
+		requireAlreadyExistsError(t, err, "user", "username", admin.Username)
+	})
+
+	t.Run("auth.Register: with invitation", func(t *testing.T) {
+		user, err := c.RegisterUser(newInvitedUserRequest(editorInvitation.Code))
+		require.NoError(t, err)
+		require.Equal(t, contracts.EditorRole, user.Role)
+
+		_, err = c.RegisterUser(newInvitedUserRequest(editorInvitation.Code))
+		requireBadRequestError(t, err, "invalid or expired invitation")
+	})
+
+	t.Run("auth.GetInvitations: success", func(t *testing.T) {
+		invitations, err := c.GetInvitations(adminToken)
+		require.NoError(t, err)
+		require.NotEmpty(t, invitations)
+		require.Equal(t, editorInvitation.ID, invitations[0].ID)
+		require.Equal(t, 1, invitations[0].Uses)
+	})
+
+	t.Run("auth.RevokeInvitation: success", func(t *testing.T) {
+		invitation, err := c.CreateInvitation(contracts.NewAuthenticated(&contracts.CreateInvitationRequest{MaxUses: 10}, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, contracts.UserRole, invitation.Role)
+
+		req := &contracts.RevokeInvitationRequest{InvitationID: invitation.ID}
+		err = c.RevokeInvitation(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		_, err = c.RegisterUser(newInvitedUserRequest(invitation.Code))
+		requireBadRequestError(t, err, "invalid or expired invitation")
+
+		err = c.RevokeInvitation(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "invitation", "id", invitation.ID)
+	})
+}
+
+func newInvitedUserRequest(code string) *contracts.RegisterUserRequest {
+	r := rand.Intn(10000)
+
+	return &contracts.RegisterUserRequest{
+		Username:       fmt.Sprintf("invited%d", r),
+		Email:          fmt.Sprintf("invited%d@mail.com", r),
+		Password:       standardPassword,
+		InvitationCode: code,
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	currentSigningKeyID = "rsa-current"
+	retiredSigningKeyID = "ed25519-retired"
+)
+
+// writeSigningKeys generates the current RSA signing key and the public key of a retired Ed25519 key.
+func writeSigningKeys(t *testing.T) (privateKeys, publicKeys map[string]string) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaDER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+
+	edPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKIXPublicKey(edPublicKey)
+	require.NoError(t, err)
+
+	rsaPath := filepath.Join(dir, currentSigningKeyID+".pem")
+	require.NoError(t, os.WriteFile(rsaPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaDER}), 0o600))
+
+	edPath := filepath.Join(dir, retiredSigningKeyID+".pem")
+	require.NoError(t, os.WriteFile(edPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: edDER}), 0o600))
+
+	return map[string]string{currentSigningKeyID: rsaPath}, map[string]string{retiredSigningKeyID: edPath}
+}
+
+func jwksAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	t.Run("jwks.GetJWKS: success", func(t *testing.T) {
+		jwks, err := c.GetJWKS()
+		require.NoError(t, err)
+		require.Len(t, jwks.Keys, 2)
+
+		require.Equal(t, retiredSigningKeyID, jwks.Keys[0].KeyID)
+		require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+		require.Equal(t, "EdDSA", jwks.Keys[0].Algorithm)
+
+		require.Equal(t, currentSigningKeyID, jwks.Keys[1].KeyID)
+		require.Equal(t, "RSA", jwks.Keys[1].KeyType)
+		require.Equal(t, "RS256", jwks.Keys[1].Algorithm)
+	})
+
+	t.Run("jwks.GetJWKS: access token verifiable with published key", func(t *testing.T) {
+		jwks, err := c.GetJWKS()
+		require.NoError(t, err)
+
+		accessToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+		token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+			require.Equal(t, currentSigningKeyID, token.Header["kid"])
+			require.Equal(t, "RS256", token.Method.Alg())
+
+			return publicKeyFromJWK(t, jwks, token.Header["kid"].(string)), nil
+		})
+		require.NoError(t, err)
+		require.True(t, token.Valid)
+	})
+}
+
+func publicKeyFromJWK(t *testing.T, jwks *contracts.JWKS, kid string) crypto.PublicKey {
+	for _, key := range jwks.Keys {
+		if key.KeyID != kid {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		require.NoError(t, err)
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	require.Failf(t, "key not found", "kid %q", kid)
+	return nil
+}
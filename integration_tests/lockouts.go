@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func lockoutAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	user := registerRandomUser(t, c, "lockout", "lockout")
+	adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+
+	t.Run("auth.LoginUser: login by username", func(t *testing.T) {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Username: user.Username, Password: standardPassword})
+		require.NoError(t, err)
+		require.Equal(t, user.ID, res.User.ID)
+	})
+
+	t.Run("auth.LoginUser: account locked after failed attempts", func(t *testing.T) {
+		for i := 0; i < cfg.Auth.Lockout.UserThreshold; i++ {
+			_, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword + "wrong"})
+			requireUnauthorizedError(t, err, "invalid credentials")
+		}
+
+		_, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		requireTooManyRequestsError(t, err, "too many failed login attempts")
+	})
+
+	t.Run("auth.GetLockouts: non-admin", func(t *testing.T) {
+		_, err := c.GetLockouts(johnMooreToken)
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.GetLockouts: success", func(t *testing.T) {
+		lockouts, err := c.GetLockouts(adminToken)
+		require.NoError(t, err)
+
+		var found *contracts.Lockout
+		for _, lockout := range lockouts {
+			if lockout.Scope == "user" && lockout.Subject == strconv.Itoa(user.ID) {
+				found = lockout
+			}
+		}
+		require.NotNil(t, found)
+		require.Equal(t, cfg.Auth.Lockout.UserThreshold, found.Failures)
+		require.Equal(t, user.Username, *found.Username)
+		require.True(t, found.LockedUntil.After(found.LastFailureAt))
+	})
+
+	t.Run("auth.DeleteLockout: invalid scope", func(t *testing.T) {
+		req := &contracts.DeleteLockoutRequest{Scope: "device", Subject: strconv.Itoa(user.ID)}
+		err := c.DeleteLockout(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "invalid lockout scope")
+	})
+
+	t.Run("auth.DeleteLockout: not found", func(t *testing.T) {
+		req := &contracts.DeleteLockoutRequest{Scope: "user", Subject: "0"}
+		err := c.DeleteLockout(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "lockout", "user", "0")
+	})
+
+	t.Run("auth.DeleteLockout: success", func(t *testing.T) {
+		req := &contracts.DeleteLockoutRequest{Scope: "user", Subject: strconv.Itoa(user.ID)}
+		err := c.DeleteLockout(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		login(t, c, user.Email, standardPassword)
+	})
+}
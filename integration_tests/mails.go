@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readMails returns the bodies of all emails the file mailer wrote for the recipient, oldest first.
+func readMails(t *testing.T, dir, to string) []string {
+	paths, err := filepath.Glob(filepath.Join(dir, "*_"+to+".eml"))
+	require.NoError(t, err)
+	sort.Strings(paths)
+
+	mails := make([]string, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		mails = append(mails, string(data))
+	}
+
+	return mails
+}
+
+// lastMailToken extracts the token following the prefix (e.g. "Reset token: ") from the latest email to the recipient.
+func lastMailToken(t *testing.T, dir, to, prefix string) string {
+	mails := readMails(t, dir, to)
+	require.NotEmpty(t, mails)
+
+	matches := regexp.MustCompile(regexp.QuoteMeta(prefix) + `(\S+)`).FindStringSubmatch(mails[len(mails)-1])
+	require.Len(t, matches, 2)
+
+	return matches[1]
+}
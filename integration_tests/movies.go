@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -164,6 +166,120 @@ func moviesAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 		require.Equal(t, len([]*contracts.MovieDetails{titanic}), len(res.Items))
 	})
 
+	t.Run("movies.GetMovies: cursor: success", func(t *testing.T) {
+		req := &contracts.GetMoviesRequest{}
+		res, err := c.GetMovies(req)
+		require.NoError(t, err)
+		require.Equal(t, []int{godFather.ID, starWars.ID}, movieIDs(res.Items))
+		require.NotEmpty(t, res.NextCursor)
+		require.Empty(t, res.PrevCursor)
+
+		req.Cursor = res.NextCursor
+		res, err = c.GetMovies(req)
+		require.NoError(t, err)
+		require.Equal(t, 3, res.Total)
+		require.Equal(t, 0, res.Page)
+		require.Equal(t, []int{titanic.ID}, movieIDs(res.Items))
+		require.Empty(t, res.NextCursor)
+		require.NotEmpty(t, res.PrevCursor)
+
+		req.Cursor = res.PrevCursor
+		res, err = c.GetMovies(req)
+		require.NoError(t, err)
+		require.Equal(t, []int{godFather.ID, starWars.ID}, movieIDs(res.Items))
+		require.NotEmpty(t, res.NextCursor)
+		require.Empty(t, res.PrevCursor)
+	})
+
+	t.Run("movies.GetMovies: cursor: descending by title", func(t *testing.T) {
+		req := &contracts.GetMoviesRequest{
+			PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+				PaginatedRequest: contracts.PaginatedRequest{Size: 1},
+				Sort:             "title",
+				Order:            "desc",
+			},
+		}
+
+		var ids []int
+		for movie, err := range client.Iterate(req, c.GetMovies) {
+			require.NoError(t, err)
+			ids = append(ids, movie.ID)
+		}
+		require.Equal(t, []int{titanic.ID, godFather.ID, starWars.ID}, ids)
+	})
+
+	t.Run("movies.GetMovies: sort by several fields: success", func(t *testing.T) {
+		cases := []struct {
+			sort string
+			ids  []int
+		}{
+			{sort: "-releaseDate", ids: []int{titanic.ID, starWars.ID, godFather.ID}},
+			{sort: "-avgRating,title", ids: []int{starWars.ID, godFather.ID, titanic.ID}},
+			{sort: "avgRating, -title", ids: []int{titanic.ID, godFather.ID, starWars.ID}},
+		}
+
+		for _, cc := range cases {
+			req := &contracts.GetMoviesRequest{
+				PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+					PaginatedRequest: contracts.PaginatedRequest{Size: testPaginationMaxSize},
+					Sort:             cc.sort,
+				},
+			}
+			res, err := c.GetMovies(req)
+			require.NoError(t, err)
+			require.Equal(t, cc.ids, movieIDs(res.Items), cc.sort)
+		}
+	})
+
+	t.Run("movies.GetMovies: sort by several fields: invalid", func(t *testing.T) {
+		cases := []struct {
+			sort string
+			err  string
+		}{
+			{sort: "search_vector", err: `invalid sort field "search_vector", allowed: avgRating, createdAt, created_at, id, releaseDate, title`},
+			{sort: "title; DROP TABLE movies", err: `invalid sort field "title; DROP TABLE movies"`},
+			{sort: "title,-title", err: `duplicate sort field "title"`},
+		}
+
+		for _, cc := range cases {
+			req := &contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{Sort: cc.sort}}
+			_, err := c.GetMovies(req)
+			requireBadRequestError(t, err, cc.err)
+		}
+	})
+
+	t.Run("movies.GetMovies: cursor: invalid", func(t *testing.T) {
+		_, err := c.GetMovies(&contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+			PaginatedRequest: contracts.PaginatedRequest{Cursor: "invalid"},
+		}})
+		requireBadRequestError(t, err, "invalid cursor")
+
+		res, err := c.GetMovies(&contracts.GetMoviesRequest{})
+		require.NoError(t, err)
+
+		_, err = c.GetMovies(&contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+			PaginatedRequest: contracts.PaginatedRequest{Cursor: res.NextCursor},
+			Sort:             "title",
+		}})
+		requireBadRequestError(t, err, "invalid cursor")
+
+		for _, value := range []string{"abc", "99999999999", ""} {
+			_, err = c.GetMovies(&contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+				PaginatedRequest: contracts.PaginatedRequest{Cursor: tamperCursor(t, res.NextCursor, value)},
+			}})
+			requireBadRequestError(t, err, "invalid cursor")
+		}
+
+		res, err = c.GetMovies(&contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{Sort: "releaseDate"}})
+		require.NoError(t, err)
+
+		_, err = c.GetMovies(&contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+			PaginatedRequest: contracts.PaginatedRequest{Cursor: tamperCursor(t, res.NextCursor, "1977-13-45", "1")},
+			Sort:             "releaseDate",
+		}})
+		requireBadRequestError(t, err, "invalid cursor")
+	})
+
 	t.Run("movies.GetMovies: text-search: not found", func(t *testing.T) {
 		req := &contracts.GetMoviesRequest{
 			SearchTerm: ptr("MATCHES_NOTHING"),
@@ -202,6 +318,159 @@ func moviesAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 		require.Equal(t, 1, len(res.Items))
 	})
 
+	t.Run("movies.GetMovies: web search syntax: success", func(t *testing.T) {
+		cases := []struct {
+			term string
+			ids  []int
+		}{
+			{term: "aristocrat's love!", ids: []int{titanic.ID}},
+			{term: `"poor artist"`, ids: []int{titanic.ID}},
+			{term: `"artist poor"`, ids: []int{}},
+			{term: "aristocrat -love", ids: []int{}},
+			{term: "godfather or skywalker", ids: []int{godFather.ID, starWars.ID}},
+			{term: "dark knight", ids: []int{}},
+		}
+
+		for _, cc := range cases {
+			req := &contracts.GetMoviesRequest{
+				PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{Sort: "id"},
+				SearchTerm:              ptr(cc.term),
+			}
+			res, err := c.GetMovies(req)
+			require.NoError(t, err, cc.term)
+			require.Equal(t, cc.ids, movieIDs(res.Items), cc.term)
+			require.Equal(t, len(cc.ids), res.Total, cc.term)
+		}
+	})
+
+	t.Run("movies.GetMovies: prefix search: success", func(t *testing.T) {
+		res, err := c.GetMovies(&contracts.GetMoviesRequest{SearchTerm: ptr("aristocrat lo")})
+		require.NoError(t, err)
+		require.Equal(t, []int{titanic.ID}, movieIDs(res.Items))
+
+		res, err = c.GetMovies(&contracts.GetMoviesRequest{SearchTerm: ptr("godf")})
+		require.NoError(t, err)
+		require.Equal(t, []int{godFather.ID}, movieIDs(res.Items))
+		require.NotNil(t, res.Items[0].Relevance)
+		require.NotNil(t, res.Items[0].Highlights)
+		require.Equal(t, "The <mark>Godfather</mark>", res.Items[0].Highlights.Title)
+	})
+
+	t.Run("movies.GetMovies: fuzzy search: success", func(t *testing.T) {
+		res, err := c.GetMovies(&contracts.GetMoviesRequest{SearchTerm: ptr("Godfathr")})
+		require.NoError(t, err)
+		require.Equal(t, []int{godFather.ID}, movieIDs(res.Items))
+	})
+
+	t.Run("movies.GetMovies: highlights: success", func(t *testing.T) {
+		res, err := c.GetMovies(&contracts.GetMoviesRequest{SearchTerm: ptr("aristocrat")})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(res.Items))
+		require.Equal(t, titanic.Title, res.Items[0].Highlights.Title)
+		require.Contains(t, res.Items[0].Highlights.Description, "<mark>aristocrat</mark>")
+	})
+
+	t.Run("movies.GetMovies: highlights: escaped", func(t *testing.T) {
+		movie, err := c.CreateMovie(contracts.NewAuthenticated(&contracts.CreateMovieRequest{
+			Title:       "Tom & Jerry: <b>Remastered</b>",
+			ReleaseDate: time.Date(1940, 2, 10, 0, 0, 0, 0, time.UTC),
+			Description: `A cat chases a mouse, <script>alert("remastered")</script>`,
+			GenreIDs:    []int{actionGenre.ID},
+		}, johnMooreToken))
+		require.NoError(t, err)
+
+		res, err := c.GetMovies(&contracts.GetMoviesRequest{SearchTerm: ptr("remastered")})
+		require.NoError(t, err)
+		require.Equal(t, []int{movie.ID}, movieIDs(res.Items))
+
+		highlights := res.Items[0].Highlights
+		require.Contains(t, highlights.Title, "Tom &amp; Jerry: &lt;b&gt;<mark>Remastered</mark>&lt;/b&gt;")
+		require.NotContains(t, highlights.Description, "<script>")
+		require.Contains(t, highlights.Description, "&lt;script&gt;")
+
+		err = c.DeleteMovieByID(contracts.NewAuthenticated(&contracts.DeleteMovieRequest{MovieID: movie.ID}, johnMooreToken))
+		require.NoError(t, err)
+	})
+
+	t.Run("movies.GetMovies: relevance without search: invalid", func(t *testing.T) {
+		_, err := c.GetMovies(&contracts.GetMoviesRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{Sort: "relevance"}})
+		requireBadRequestError(t, err, `invalid sort field "relevance"`)
+	})
+
+	t.Run("movies.GetMovies: filter by genres: success", func(t *testing.T) {
+		cases := []struct {
+			req   *contracts.GetMoviesRequest
+			total int
+		}{
+			{req: &contracts.GetMoviesRequest{GenreIDs: []int{dramaGenre.ID}}, total: 2},
+			{req: &contracts.GetMoviesRequest{GenreIDs: []int{dramaGenre.ID, comedyGenre.ID}}, total: 2},
+			{req: &contracts.GetMoviesRequest{GenreIDs: []int{dramaGenre.ID, actionGenre.ID}, GenreMatch: ptr(contracts.GenreMatchAll)}, total: 2},
+			{req: &contracts.GetMoviesRequest{GenreIDs: []int{dramaGenre.ID, comedyGenre.ID}, GenreMatch: ptr(contracts.GenreMatchAll)}, total: 0},
+		}
+
+		for _, cc := range cases {
+			res, err := c.GetMovies(cc.req)
+			require.NoError(t, err)
+			require.Equal(t, cc.total, res.Total)
+			require.Equal(t, cc.total, len(res.Items))
+		}
+	})
+
+	t.Run("movies.GetMovies: filter by star: success", func(t *testing.T) {
+		res, err := c.GetMovies(&contracts.GetMoviesRequest{StarID: &denzelStar.ID})
+		require.NoError(t, err)
+		require.Equal(t, 3, res.Total)
+
+		res, err = c.GetMovies(&contracts.GetMoviesRequest{StarID: &denzelStar.ID, StarRole: ptr("director")})
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Total)
+		require.Equal(t, starWars.ID, res.Items[0].ID)
+	})
+
+	t.Run("movies.GetMovies: combined filters: success", func(t *testing.T) {
+		req := &contracts.GetMoviesRequest{
+			SearchTerm:     ptr("Godfather"),
+			GenreIDs:       []int{dramaGenre.ID},
+			ReleasedAfter:  ptr(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)),
+			ReleasedBefore: ptr(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)),
+			HasReviews:     ptr(false),
+		}
+		res, err := c.GetMovies(req)
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Total)
+		require.Equal(t, godFather.ID, res.Items[0].ID)
+
+		req.ReleasedAfter = ptr(time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC))
+		res, err = c.GetMovies(req)
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Total)
+	})
+
+	t.Run("movies.GetMovies: filter by ratings: movies without ratings don't match", func(t *testing.T) {
+		cases := []*contracts.GetMoviesRequest{
+			{MinAvgRating: ptr(0.0)},
+			{MaxIMDbRating: ptr(10.0)},
+			{MinMetascore: ptr(0), MaxMetascore: ptr(100)},
+		}
+
+		for _, req := range cases {
+			res, err := c.GetMovies(req)
+			require.NoError(t, err)
+			require.Equal(t, 0, res.Total)
+		}
+	})
+
+	t.Run("movies.GetMovies: invalid filters", func(t *testing.T) {
+		_, err := c.GetMovies(&contracts.GetMoviesRequest{GenreIDs: []int{dramaGenre.ID}, GenreMatch: ptr("some")})
+		requireBadRequestError(t, err, "invalid genre match")
+
+		_, err = c.GetMovies(&contracts.GetMoviesRequest{StarRole: ptr("director")})
+		requireBadRequestError(t, err, "star role requires star id")
+
+		_, err = c.GetMovies(&contracts.GetMoviesRequest{StarID: &denzelStar.ID, StarRole: ptr("stuntman")})
+		requireBadRequestError(t, err, "invalid star role")
+	})
+
 	t.Run("movies.GetStarsByMovieId: movie not found", func(t *testing.T) {
 		req := &contracts.GetMovieRequest{MovieID: 100}
 		_, err := c.GetStarsByMovieID(req)
@@ -303,3 +572,26 @@ func deepMovieCompare(t *testing.T, expected, actual *contracts.MovieDetails) {
 		require.Equal(t, cast.Star.ID, actual.Cast[i].Star.ID)
 	}
 }
+
+func movieIDs(movies []*contracts.Movie) []int {
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.ID
+	}
+	return ids
+}
+
+// tamperCursor replaces the sort key values of the cursor, keeping the sort it was made for.
+func tamperCursor(t *testing.T, cursor string, values ...string) string {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	decoded["v"] = values
+
+	data, err = json.Marshal(decoded)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
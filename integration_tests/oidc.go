@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oidcProviderName = "mock"
+	oidcClientID     = "movies-reviews"
+	oidcClientSecret = "mock-secret"
+	oidcRedirectURL  = "http://localhost:3000/auth/callback"
+	oidcKeyID        = "mock-key"
+)
+
+var oidcIssuer *mockIssuer
+
+type mockIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+type mockAuthorization struct {
+	identity      mockIdentity
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// mockIssuer is a minimal OpenID Connect provider. It signs in whoever is set with setIdentity without
+// asking and redirects straight back with an authorization code.
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	identity mockIdentity
+	codes    map[string]mockAuthorization
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockIssuer{
+		key:   key,
+		codes: make(map[string]mockAuthorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/jwks", m.jwks)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockIssuer) providerConfig() config.OIDCProviderConfig {
+	return config.OIDCProviderConfig{
+		Name:         oidcProviderName,
+		IssuerURL:    m.URL,
+		ClientID:     oidcClientID,
+		ClientSecret: oidcClientSecret,
+		RedirectURL:  oidcRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+func (m *mockIssuer) setIdentity(identity mockIdentity) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.identity = identity
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 m.URL,
+		"authorization_endpoint": m.URL + "/authorize",
+		"token_endpoint":         m.URL + "/token",
+		"jwks_uri":               m.URL + "/jwks",
+	})
+}
+
+func (m *mockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != oidcClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	code := uuid.NewString()
+	m.codes[code] = mockAuthorization{
+		identity:      m.identity,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		redirectURI:   q.Get("redirect_uri"),
+	}
+	m.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, "invalid token request", http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("client_id") != oidcClientID || r.PostForm.Get("client_secret") != oidcClientSecret {
+		http.Error(w, "invalid client", http.StatusUnauthorized)
+		return
+	}
+
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || auth.redirectURI != r.PostForm.Get("redirect_uri") || base64.RawURLEncoding.EncodeToString(challenge[:]) != auth.codeChallenge {
+		http.Error(w, "invalid grant", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                m.URL,
+		"sub":                auth.identity.Subject,
+		"aud":                oidcClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Minute).Unix(),
+		"nonce":              auth.nonce,
+		"email":              auth.identity.Email,
+		"email_verified":     auth.identity.EmailVerified,
+		"preferred_username": auth.identity.PreferredUsername,
+	})
+	token.Header["kid"] = oidcKeyID
+
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]string{"access_token": uuid.NewString(), "token_type": "Bearer", "id_token": idToken})
+}
+
+func (m *mockIssuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, contracts.JWKS{Keys: []contracts.JWK{{
+		KeyType:   "RSA",
+		KeyID:     oidcKeyID,
+		Use:       "sig",
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+	}}})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// authorizeOIDC starts a login with the mock provider as the identity and returns the callback parameters.
+func authorizeOIDC(t *testing.T, c *client.Client, identity mockIdentity) *contracts.OIDCCallbackRequest {
+	oidcIssuer.setIdentity(identity)
+
+	location, err := c.StartOIDCLogin(oidcProviderName)
+	require.NoError(t, err)
+	require.Contains(t, location, oidcIssuer.URL+"/authorize?")
+
+	noRedirects := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := noRedirects.Get(location)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, oidcRedirectURL, callback.Scheme+"://"+callback.Host+callback.Path)
+
+	return &contracts.OIDCCallbackRequest{
+		Provider: oidcProviderName,
+		Code:     callback.Query().Get("code"),
+		State:    callback.Query().Get("state"),
+	}
+}
+
+func oidcAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
+	ssoIdentity := mockIdentity{
+		Subject:           "sso-subject-1",
+		Email:             "sso.user@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "sso.user",
+	}
+	var ssoUser contracts.User
+
+	t.Run("auth.StartOIDCLogin: unknown provider", func(t *testing.T) {
+		_, err := c.StartOIDCLogin("unknown")
+		requireNotFoundError(t, err, "identity provider", "name", "unknown")
+	})
+
+	t.Run("auth.FinishOIDCLogin: invalid state", func(t *testing.T) {
+		req := authorizeOIDC(t, c, ssoIdentity)
+		req.State = "invalid"
+		_, err := c.FinishOIDCLogin(req)
+		requireBadRequestError(t, err, "invalid or expired state")
+	})
+
+	t.Run("auth.FinishOIDCLogin: new user", func(t *testing.T) {
+		res, err := c.FinishOIDCLogin(authorizeOIDC(t, c, ssoIdentity))
+		require.NoError(t, err)
+		require.NotEmpty(t, res.AccessToken)
+		require.NotEmpty(t, res.RefreshToken)
+		require.Equal(t, ssoIdentity.PreferredUsername, res.User.Username)
+		require.Equal(t, ssoIdentity.Email, res.User.Email)
+		require.Equal(t, contracts.UserRole, res.User.Role)
+		require.NotNil(t, res.User.EmailVerifiedAt)
+		require.True(t, emailVerifiedClaim(t, res.AccessToken))
+		ssoUser = res.User
+	})
+
+	t.Run("auth.FinishOIDCLogin: linked identity", func(t *testing.T) {
+		req := authorizeOIDC(t, c, ssoIdentity)
+		res, err := c.FinishOIDCLogin(req)
+		require.NoError(t, err)
+		require.Equal(t, ssoUser.ID, res.User.ID)
+
+		_, err = c.FinishOIDCLogin(req)
+		requireBadRequestError(t, err, "invalid or expired state")
+	})
+
+	t.Run("auth.FinishOIDCLogin: existing user by verified email", func(t *testing.T) {
+		res, err := c.FinishOIDCLogin(authorizeOIDC(t, c, mockIdentity{
+			Subject:       "sso-subject-2",
+			Email:         johnMoore.Email,
+			EmailVerified: true,
+		}))
+		require.NoError(t, err)
+		require.Equal(t, johnMoore.ID, res.User.ID)
+		require.Equal(t, johnMoore.Username, res.User.Username)
+	})
+
+	t.Run("auth.FinishOIDCLogin: unverified email", func(t *testing.T) {
+		_, err := c.FinishOIDCLogin(authorizeOIDC(t, c, mockIdentity{
+			Subject: "sso-subject-3",
+			Email:   markTwain.Email,
+		}))
+		requireForbiddenError(t, err, "email is not verified by the identity provider")
+	})
+}
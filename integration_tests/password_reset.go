@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+const resetTokenPrefix = "Reset token: "
+
+func passwordResetAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	user := registerRandomUser(t, c, "reset", "reset")
+	newPassword := "nEwpass!123"
+
+	t.Run("auth.ForgotPassword: invalid email", func(t *testing.T) {
+		err := c.ForgotPassword(&contracts.ForgotPasswordRequest{Email: "invalid"})
+		requireBadRequestError(t, err, "Email: mail: missing '@' or angle-addr")
+	})
+
+	t.Run("auth.ForgotPassword: unknown email", func(t *testing.T) {
+		email := "unknown-reset@mail.com"
+		err := c.ForgotPassword(&contracts.ForgotPasswordRequest{Email: email})
+		require.NoError(t, err)
+		require.Empty(t, readMails(t, cfg.Mail.Dir, email))
+	})
+
+	t.Run("auth.ResetPassword: invalid token", func(t *testing.T) {
+		err := c.ResetPassword(&contracts.ResetPasswordRequest{Token: "invalid", Password: newPassword})
+		requireBadRequestError(t, err, "invalid or expired token")
+	})
+
+	t.Run("auth.ResetPassword: only latest token works", func(t *testing.T) {
+		require.NoError(t, c.ForgotPassword(&contracts.ForgotPasswordRequest{Email: user.Email}))
+		first := lastMailToken(t, cfg.Mail.Dir, user.Email, resetTokenPrefix)
+
+		require.NoError(t, c.ForgotPassword(&contracts.ForgotPasswordRequest{Email: user.Email}))
+		second := lastMailToken(t, cfg.Mail.Dir, user.Email, resetTokenPrefix)
+		require.NotEqual(t, first, second)
+
+		err := c.ResetPassword(&contracts.ResetPasswordRequest{Token: first, Password: newPassword})
+		requireBadRequestError(t, err, "invalid or expired token")
+	})
+
+	t.Run("auth.ResetPassword: success", func(t *testing.T) {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		require.NoError(t, err)
+
+		pat, err := c.CreateAccessToken(contracts.NewAuthenticated(&contracts.CreateAccessTokenRequest{
+			UserID: user.ID,
+			Name:   "ci",
+			Scopes: []string{contracts.UsersWriteScope},
+		}, res.AccessToken))
+		require.NoError(t, err)
+
+		require.NoError(t, c.ForgotPassword(&contracts.ForgotPasswordRequest{Email: user.Email}))
+		token := lastMailToken(t, cfg.Mail.Dir, user.Email, resetTokenPrefix)
+
+		err = c.ResetPassword(&contracts.ResetPasswordRequest{Token: token, Password: newPassword})
+		require.NoError(t, err)
+
+		err = c.ResetPassword(&contracts.ResetPasswordRequest{Token: token, Password: newPassword})
+		requireBadRequestError(t, err, "invalid or expired token")
+
+		_, err = c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		requireUnauthorizedError(t, err, "invalid credentials")
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		requireUnauthorizedError(t, err, "invalid refresh token")
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, res.AccessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+
+		_, err = c.UpdateUserData(contracts.NewAuthenticated(&contracts.UpdateUserRequest{UserID: user.ID, Bio: ptr("bio")}, pat.Token))
+		requireForbiddenError(t, err, "invalid token")
+
+		login(t, c, user.Email, newPassword)
+	})
+}
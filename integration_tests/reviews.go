@@ -218,7 +218,6 @@ func reviewsAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 		wrongReviewID := 100
 		req := &contracts.UpdateReviewRequest{
 			UserID:   johnMoore.ID,
-			MovieID:  titanic.ID,
 			ReviewID: wrongReviewID,
 			Title:    ptr("title"),
 			Content:  ptr("content"),
@@ -228,11 +227,40 @@ func reviewsAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 		requireNotFoundError(t, err, "review", "id", wrongReviewID)
 	})
 
+	t.Run("reviews.UpdateReview: review of another user", func(t *testing.T) {
+		req := &contracts.UpdateReviewRequest{
+			UserID:   markTwain.ID,
+			ReviewID: starsWarsReview1.ID,
+			Rating:   ptr(1),
+		}
+		_, err := c.UpdateReview(*contracts.NewAuthenticated(req, markTwainToken))
+		requireNotFoundError(t, err, "review", "id", starsWarsReview1.ID)
+
+		review, err := c.GetReviewByID(&contracts.GetReviewRequest{ReviewID: starsWarsReview1.ID})
+		require.NoError(t, err)
+		require.Equal(t, starsWarsReview1.Rating, review.Rating)
+	})
+
+	t.Run("reviews.UpdateReview: not the author", func(t *testing.T) {
+		req := &contracts.UpdateReviewRequest{
+			UserID:   johnMoore.ID,
+			ReviewID: starsWarsReview1.ID,
+			Rating:   ptr(1),
+			Title:    ptr("changed"),
+		}
+		_, err := c.UpdateReview(*contracts.NewAuthenticated(req, markTwainToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+
+		review, err := c.GetReviewByID(&contracts.GetReviewRequest{ReviewID: starsWarsReview1.ID})
+		require.NoError(t, err)
+		require.Equal(t, starsWarsReview1.Rating, review.Rating)
+		require.Equal(t, starsWarsReview1.Title, review.Title)
+	})
+
 	t.Run("reviews.UpdateReview: success", func(t *testing.T) {
 		req := &contracts.UpdateReviewRequest{
 			UserID:   johnMoore.ID,
 			ReviewID: titanicReview1.ID,
-			MovieID:  titanic.ID,
 			Title:    ptr("title"),
 			Content:  ptr("content"),
 			Rating:   ptr(10),
@@ -264,6 +292,28 @@ func reviewsAPIChecks(t *testing.T, c *client.Client, _ *config.Config) {
 		requireNotFoundError(t, err, "review", "id", wrongReviewID)
 	})
 
+	t.Run("reviews.DeleteReview: review of another user", func(t *testing.T) {
+		req := &contracts.DeleteReviewRequest{
+			UserID:   markTwain.ID,
+			ReviewID: starsWarsReview1.ID,
+		}
+		err := c.DeleteReview(*contracts.NewAuthenticated(req, markTwainToken))
+		requireNotFoundError(t, err, "review", "id", starsWarsReview1.ID)
+	})
+
+	t.Run("reviews.DeleteReview: not the author", func(t *testing.T) {
+		req := &contracts.DeleteReviewRequest{
+			UserID:   johnMoore.ID,
+			ReviewID: starsWarsReview1.ID,
+		}
+		err := c.DeleteReview(*contracts.NewAuthenticated(req, markTwainToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+
+		review, err := c.GetReviewByID(&contracts.GetReviewRequest{ReviewID: starsWarsReview1.ID})
+		require.NoError(t, err)
+		require.Nil(t, review.DeletedAt)
+	})
+
 	t.Run("reviews.DeleteReview: success", func(t *testing.T) {
 		req := &contracts.DeleteReviewRequest{
 			UserID:   johnMoore.ID,
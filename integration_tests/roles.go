@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func rolesAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+	moderator := registerRandomUser(t, c, "moderator", "moderator")
+
+	t.Run("roles.GetRoles: insufficient permissions", func(t *testing.T) {
+		_, err := c.GetRoles(markTwainToken)
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("roles.GetRoles: success", func(t *testing.T) {
+		roles, err := c.GetRoles(adminToken)
+		require.NoError(t, err)
+		require.Len(t, roles, 3)
+		require.Equal(t, contracts.AdminRole, roles[0].Name)
+		require.True(t, roles[0].BuiltIn)
+		require.Contains(t, roles[0].Permissions, contracts.RolesManagePermission)
+	})
+
+	t.Run("roles.GetPermissions: success", func(t *testing.T) {
+		permissions, err := c.GetPermissions(adminToken)
+		require.NoError(t, err)
+		require.Len(t, permissions, 8)
+	})
+
+	t.Run("roles.CreateRole: unknown permission", func(t *testing.T) {
+		req := &contracts.CreateRoleRequest{Name: "moderator", Permissions: []string{"reviews.hide"}}
+		_, err := c.CreateRole(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "unknown permission")
+	})
+
+	t.Run("roles.CreateRole: success", func(t *testing.T) {
+		req := &contracts.CreateRoleRequest{
+			Name:        "moderator",
+			Description: "Moderates reviews",
+			Permissions: []string{contracts.ReviewsModeratePermission},
+		}
+		role, err := c.CreateRole(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, req.Permissions, role.Permissions)
+		require.False(t, role.BuiltIn)
+	})
+
+	t.Run("roles.CreateRole: already exists", func(t *testing.T) {
+		req := &contracts.CreateRoleRequest{Name: "moderator"}
+		_, err := c.CreateRole(contracts.NewAuthenticated(req, adminToken))
+		requireAlreadyExistsError(t, err, "role", "name", "moderator")
+	})
+
+	t.Run("users.UpdateUserRoleById: custom role", func(t *testing.T) {
+		req := &contracts.UpdateUserRoleRequest{UserID: moderator.ID, Role: "moderator"}
+		err := c.UpdateUserRole(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+	})
+
+	moderatorToken := login(t, c, moderator.Email, standardPassword)
+
+	t.Run("genres.CreateGenre: permission not granted", func(t *testing.T) {
+		_, err := c.CreateGenre(contracts.NewAuthenticated(contracts.CreateGenreRequest{Name: "Moderated"}, moderatorToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("reviews.DeleteReview: review of another user", func(t *testing.T) {
+		req := &contracts.DeleteReviewRequest{UserID: markTwain.ID, ReviewID: titanicReview2.ID}
+		err := c.DeleteReview(*contracts.NewAuthenticated(req, moderatorToken))
+		require.NoError(t, err)
+	})
+
+	t.Run("roles.UpdateRole: admin role", func(t *testing.T) {
+		req := &contracts.UpdateRoleRequest{Name: contracts.AdminRole}
+		_, err := c.UpdateRole(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "the admin role always has every permission")
+	})
+
+	t.Run("roles.UpdateRole: success", func(t *testing.T) {
+		req := &contracts.UpdateRoleRequest{
+			Name:        "moderator",
+			Description: "Moderates stars",
+			Permissions: []string{contracts.StarsWritePermission},
+		}
+		role, err := c.UpdateRole(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, req.Description, role.Description)
+		require.Equal(t, req.Permissions, role.Permissions)
+	})
+
+	t.Run("roles.UpdateRole: old token revoked", func(t *testing.T) {
+		req := &contracts.DeleteReviewRequest{UserID: markTwain.ID, ReviewID: titanicReview2.ID}
+		err := c.DeleteReview(*contracts.NewAuthenticated(req, moderatorToken))
+		requireForbiddenError(t, err, "token has been revoked")
+	})
+
+	t.Run("roles.DeleteRole: built-in role", func(t *testing.T) {
+		req := &contracts.DeleteRoleRequest{Name: contracts.EditorRole}
+		err := c.DeleteRole(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "built-in roles can't be deleted")
+	})
+
+	t.Run("roles.DeleteRole: role in use", func(t *testing.T) {
+		req := &contracts.DeleteRoleRequest{Name: "moderator"}
+		err := c.DeleteRole(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "role is assigned to users")
+	})
+
+	t.Run("roles.DeleteRole: success", func(t *testing.T) {
+		err := c.UpdateUserRole(contracts.NewAuthenticated(&contracts.UpdateUserRoleRequest{UserID: moderator.ID, Role: contracts.UserRole}, adminToken))
+		require.NoError(t, err)
+
+		err = c.DeleteRole(contracts.NewAuthenticated(&contracts.DeleteRoleRequest{Name: "moderator"}, adminToken))
+		require.NoError(t, err)
+
+		_, err = c.GetRoleByName(contracts.NewAuthenticated(&contracts.GetRoleRequest{Name: "moderator"}, adminToken))
+		requireNotFoundError(t, err, "role", "name", "moderator")
+	})
+
+	admin, err := c.GetUserByUsername(&contracts.GetUserByUsernameRequest{Username: cfg.Admin.Username})
+	require.NoError(t, err)
+	manager := registerRandomUser(t, c, "manager", "manager")
+	member := registerRandomUser(t, c, "member", "member")
+
+	t.Run("users.UpdateUserRoleById: prepare manager", func(t *testing.T) {
+		req := &contracts.CreateRoleRequest{Name: "manager", Permissions: []string{contracts.UsersManagePermission}}
+		_, err := c.CreateRole(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		err = c.UpdateUserRole(contracts.NewAuthenticated(&contracts.UpdateUserRoleRequest{UserID: manager.ID, Role: "manager"}, adminToken))
+		require.NoError(t, err)
+	})
+
+	managerToken := login(t, c, manager.Email, standardPassword)
+
+	t.Run("users.UpdateUserRoleById: role with permissions the caller lacks", func(t *testing.T) {
+		req := &contracts.UpdateUserRoleRequest{UserID: member.ID, Role: contracts.AdminRole}
+		err := c.UpdateUserRole(contracts.NewAuthenticated(req, managerToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+
+		user, err := c.GetUserByID(&contracts.GetUserByIDRequest{UserID: member.ID})
+		require.NoError(t, err)
+		require.Equal(t, contracts.UserRole, user.Role)
+	})
+
+	t.Run("users.UpdateUserRoleById: user with permissions the caller lacks", func(t *testing.T) {
+		req := &contracts.UpdateUserRoleRequest{UserID: admin.ID, Role: contracts.UserRole}
+		err := c.UpdateUserRole(contracts.NewAuthenticated(req, managerToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("users.UpdateUserRoleById: own role", func(t *testing.T) {
+		req := &contracts.UpdateUserRoleRequest{UserID: manager.ID, Role: contracts.AdminRole}
+		err := c.UpdateUserRole(contracts.NewAuthenticated(req, managerToken))
+		requireBadRequestError(t, err, "users can't change their own role")
+	})
+
+	t.Run("users.UpdateUserRoleById: role within the caller permissions", func(t *testing.T) {
+		req := &contracts.UpdateUserRoleRequest{UserID: member.ID, Role: "manager"}
+		err := c.UpdateUserRole(contracts.NewAuthenticated(req, managerToken))
+		require.NoError(t, err)
+
+		user, err := c.GetUserByID(&contracts.GetUserByIDRequest{UserID: member.ID})
+		require.NoError(t, err)
+		require.Equal(t, "manager", user.Role)
+	})
+}
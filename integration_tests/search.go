@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func searchAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	var (
+		westernGenre *contracts.Genre
+		merylStar    *contracts.Star
+		kramerMovie  *contracts.MovieDetails
+	)
+
+	t.Run("search.Suggest: prepare data", func(t *testing.T) {
+		var err error
+		westernGenre, err = c.CreateGenre(contracts.NewAuthenticated(contracts.CreateGenreRequest{Name: "western"}, johnMooreToken))
+		require.NoError(t, err)
+
+		merylStar, err = c.CreateStar(contracts.NewAuthenticated(&contracts.CreateStarRequest{
+			FirstName: "meryl",
+			LastName:  "streep",
+			BirthDate: time.Date(1949, 6, 22, 0, 0, 0, 0, time.UTC),
+		}, johnMooreToken))
+		require.NoError(t, err)
+
+		kramerMovie, err = c.CreateMovie(contracts.NewAuthenticated(&contracts.CreateMovieRequest{
+			Title:       "Kramer vs. Kramer",
+			ReleaseDate: time.Date(1979, 12, 19, 0, 0, 0, 0, time.UTC),
+			Description: "A man whose wife leaves him must learn to care for their son, then fight her for custody.",
+			GenreIDs:    []int{westernGenre.ID},
+			Cast: []contracts.MovieCreditInfo{
+				{
+					StarID: merylStar.ID,
+					Role:   "actress",
+				},
+			},
+		}, johnMooreToken))
+		require.NoError(t, err)
+	})
+
+	t.Run("search.Suggest: movie by prefix", func(t *testing.T) {
+		suggestions, err := c.Suggest(&contracts.SuggestRequest{Query: "kram"})
+		require.NoError(t, err)
+		require.NotEmpty(t, suggestions)
+
+		require.Equal(t, contracts.MovieSuggestionType, suggestions[0].Type)
+		require.Equal(t, kramerMovie.ID, suggestions[0].ID)
+		require.Equal(t, kramerMovie.Title, suggestions[0].Name)
+		require.Equal(t, ptr(1979), suggestions[0].Year)
+	})
+
+	t.Run("search.Suggest: star by word prefix", func(t *testing.T) {
+		suggestions, err := c.Suggest(&contracts.SuggestRequest{Query: "stree"})
+		require.NoError(t, err)
+		require.NotEmpty(t, suggestions)
+
+		require.Equal(t, contracts.StarSuggestionType, suggestions[0].Type)
+		require.Equal(t, merylStar.ID, suggestions[0].ID)
+		require.Equal(t, "meryl streep", suggestions[0].Name)
+		require.Equal(t, ptr(kramerMovie.Title), suggestions[0].KnownFor)
+	})
+
+	t.Run("search.Suggest: genre", func(t *testing.T) {
+		suggestions, err := c.Suggest(&contracts.SuggestRequest{Query: "weste"})
+		require.NoError(t, err)
+		require.NotEmpty(t, suggestions)
+
+		require.Equal(t, contracts.GenreSuggestionType, suggestions[0].Type)
+		require.Equal(t, westernGenre.ID, suggestions[0].ID)
+	})
+
+	t.Run("search.Suggest: typo", func(t *testing.T) {
+		suggestions, err := c.Suggest(&contracts.SuggestRequest{Query: "Kramr"})
+		require.NoError(t, err)
+		require.NotEmpty(t, suggestions)
+
+		require.Equal(t, kramerMovie.ID, suggestions[0].ID)
+	})
+
+	t.Run("search.Suggest: no matches", func(t *testing.T) {
+		suggestions, err := c.Suggest(&contracts.SuggestRequest{Query: "zzzzqqq"})
+		require.NoError(t, err)
+		require.Empty(t, suggestions)
+	})
+
+	t.Run("search.Suggest: empty query", func(t *testing.T) {
+		_, err := c.Suggest(&contracts.SuggestRequest{Query: ""})
+		requireBadRequestError(t, err, "Query: less than min")
+	})
+
+	t.Run("search.Suggest: matches are index backed", func(t *testing.T) {
+		ctx := context.Background()
+		conn, err := pgx.Connect(ctx, cfg.DBUrl)
+		require.NoError(t, err)
+		defer func() {
+			_ = conn.Close(ctx)
+		}()
+
+		// The tables are tiny, a sequential scan is planned only if some branch of the condition can't use an index
+		_, err = conn.Exec(ctx, "SET enable_seqscan = off")
+		require.NoError(t, err)
+
+		cases := []struct {
+			table    string
+			nameExpr string
+		}{
+			{"movies", "title"},
+			{"stars", "(first_name || ' ' || last_name)"},
+			{"genres", "name"},
+		}
+
+		for _, cc := range cases {
+			query := fmt.Sprintf("EXPLAIN SELECT id FROM %s WHERE %s ILIKE $1 OR %s ILIKE $2 OR $3 <%% %s", cc.table, cc.nameExpr, cc.nameExpr, cc.nameExpr)
+			rows, err := conn.Query(ctx, query, "kram%", "% kram%", "kram")
+			require.NoError(t, err)
+
+			plan, err := pgx.CollectRows(rows, pgx.RowTo[string])
+			require.NoError(t, err)
+			require.NotContains(t, strings.Join(plan, "\n"), "Seq Scan", cc.table)
+		}
+	})
+}
@@ -20,12 +20,60 @@ func TestService(t *testing.T) {
 }
 
 func runServer(t *testing.T, pgConnString string) {
+	privateKeys, publicKeys := writeSigningKeys(t)
+	oidcIssuer = newMockIssuer(t)
+
 	cfg := &config.Config{
 		DBUrl: pgConnString,
 		Port:  0,
 		JWT: config.JWTConfig{
-			Secret:           "secret",
-			AccessExpiration: time.Minute * 10,
+			Algorithm:         "RS256",
+			SigningKeyID:      currentSigningKeyID,
+			PrivateKeys:       privateKeys,
+			PublicKeys:        publicKeys,
+			AccessExpiration:  time.Minute * 10,
+			RefreshExpiration: time.Hour,
+			Denylist: config.DenylistConfig{
+				Store:           "postgres",
+				CleanupInterval: time.Minute,
+			},
+		},
+		Auth: config.AuthConfig{
+			PasswordResetExpiration:     time.Hour,
+			EmailVerificationExpiration: time.Hour,
+			AllowUnverifiedLogin:        true,
+			PasswordHash: config.PasswordHashConfig{
+				Memory:      1024,
+				Iterations:  1,
+				Parallelism: 1,
+			},
+			Lockout: config.LockoutConfig{
+				UserThreshold: 3,
+				IPThreshold:   1000,
+				BaseDuration:  time.Minute,
+				MaxDuration:   time.Hour,
+				FailureWindow: time.Hour,
+			},
+			TwoFactor: config.TwoFactorConfig{
+				Issuer:              "Movies-Reviews",
+				RequiredRoles:       []string{twoFactorRequiredRole},
+				ChallengeExpiration: time.Minute,
+				RecoveryCodes:       5,
+			},
+			Cookie: config.CookieConfig{
+				Enabled:  true,
+				Secure:   false,
+				SameSite: config.SameSite(http.SameSiteStrictMode),
+			},
+			OIDC: config.OIDCConfig{
+				StateExpiration: time.Minute,
+				Providers:       []config.OIDCProviderConfig{oidcIssuer.providerConfig()},
+			},
+		},
+		Mail: config.MailConfig{
+			Driver: "file",
+			From:   "no-reply@movies-reviews.test",
+			Dir:    t.TempDir(),
 		},
 		Admin: config.AdminConfig{
 			Username: "admin",
@@ -36,6 +84,15 @@ func runServer(t *testing.T, pgConnString string) {
 			DefaultSize: testPaginationDefaultSize,
 			MaxSize:     testPaginationMaxSize,
 		},
+		Exports: config.ExportsConfig{
+			PollInterval: 100 * time.Millisecond,
+			Expiration:   time.Hour,
+		},
+		Search: config.SearchConfig{
+			SuggestMovies: 5,
+			SuggestStars:  5,
+			SuggestGenres: 3,
+		},
 		Local: true,
 		Logger: config.LoggerConfig{
 			Level: "info",
@@ -71,9 +128,24 @@ func tests(t *testing.T, port int, cfg *config.Config) {
 	c := client.New(addr)
 
 	authAPIChecks(t, c, cfg)
+	jwksAPIChecks(t, c, cfg)
+	passwordResetAPIChecks(t, c, cfg)
+	emailVerificationAPIChecks(t, c, cfg)
+	credentialsAPIChecks(t, c, cfg)
+	cookieSessionAPIChecks(t, client.New(addr), cfg)
+	lockoutAPIChecks(t, c, cfg)
+	twoFactorAPIChecks(t, c, cfg)
+	oidcAPIChecks(t, c, cfg)
 	usersAPIChecks(t, c, cfg)
 	genresAPIChecks(t, c, cfg)
 	starsAPIChecks(t, c, cfg)
 	moviesAPIChecks(t, c, cfg)
 	reviewsAPIChecks(t, c, cfg)
+	rolesAPIChecks(t, c, cfg)
+	invitationsAPIChecks(t, c, cfg)
+	impersonationAPIChecks(t, c, cfg)
+	accessTokensAPIChecks(t, c, cfg)
+	exportsAPIChecks(t, c, cfg)
+	suspensionsAPIChecks(t, c, cfg)
+	searchAPIChecks(t, c, cfg)
 }
@@ -130,6 +130,21 @@ func starsAPIChecks(t *testing.T, client *client.Client, _ *config.Config) {
 		require.Equal(t, testPaginationDefaultSize, res.Size)
 	})
 
+	t.Run("stars.GetStars: sort by birth date (DESC): success", func(t *testing.T) {
+		req := &contracts.GetStarsRequest{
+			PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{
+				PaginatedRequest: contracts.PaginatedRequest{Size: testPaginationMaxSize},
+				Sort:             "-birthDate",
+			},
+		}
+		res, err := client.GetStars(req)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(res.Items))
+		require.Equal(t, denzelStar.ID, res.Items[0].ID)
+		require.Equal(t, jackStar.ID, res.Items[1].ID)
+		require.Equal(t, sophiaStar.ID, res.Items[2].ID)
+	})
+
 	t.Run("stars.GetStarById: success", func(t *testing.T) {
 		req := &contracts.GetStarRequest{
 			StarID: denzelStar.ID,
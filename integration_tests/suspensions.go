@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func suspensionsAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	admin, err := c.GetUserByUsername(&contracts.GetUserByUsernameRequest{Username: cfg.Admin.Username})
+	require.NoError(t, err)
+	adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+
+	user := registerRandomUser(t, c, "suspended", "suspended")
+	res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+	require.NoError(t, err)
+
+	var suspension *contracts.Suspension
+
+	t.Run("users.SuspendUser: insufficient permissions", func(t *testing.T) {
+		req := &contracts.SuspendUserRequest{UserID: user.ID, Reason: "spam"}
+		_, err := c.SuspendUser(contracts.NewAuthenticated(req, res.AccessToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("users.SuspendUser: yourself", func(t *testing.T) {
+		req := &contracts.SuspendUserRequest{UserID: admin.ID, Reason: "spam"}
+		_, err := c.SuspendUser(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "users can't suspend themselves")
+	})
+
+	t.Run("users.SuspendUser: expiration in the past", func(t *testing.T) {
+		req := &contracts.SuspendUserRequest{UserID: user.ID, Reason: "spam", ExpiresAt: ptr(time.Now().Add(-time.Hour))}
+		_, err := c.SuspendUser(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "expiration time must be in the future")
+	})
+
+	t.Run("users.SuspendUser: success", func(t *testing.T) {
+		req := &contracts.SuspendUserRequest{UserID: user.ID, Reason: "spam", ExpiresAt: ptr(time.Now().Add(time.Hour))}
+		suspension, err = c.SuspendUser(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, admin.ID, suspension.CreatedBy)
+		require.NotNil(t, suspension.ExpiresAt)
+	})
+
+	t.Run("users.SuspendUser: login rejected", func(t *testing.T) {
+		_, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		requireSuspendedError(t, err, "account suspended until")
+		requireSuspendedError(t, err, "spam")
+	})
+
+	t.Run("users.SuspendUser: tokens rejected", func(t *testing.T) {
+		req := &contracts.GetAccessTokensRequest{UserID: user.ID}
+		_, err := c.GetAccessTokens(contracts.NewAuthenticated(req, res.AccessToken))
+		requireSuspendedError(t, err, "spam")
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		requireSuspendedError(t, err, "spam")
+	})
+
+	t.Run("users.GetSuspensions: success", func(t *testing.T) {
+		req := &contracts.GetSuspensionsRequest{UserID: user.ID}
+		suspensions, err := c.GetSuspensions(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Len(t, suspensions, 1)
+		require.Equal(t, suspension.ID, suspensions[0].ID)
+		require.Nil(t, suspensions[0].LiftedAt)
+	})
+
+	t.Run("users.LiftSuspension: success", func(t *testing.T) {
+		req := &contracts.LiftSuspensionRequest{UserID: user.ID, SuspensionID: suspension.ID}
+		err := c.LiftSuspension(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		login(t, c, user.Email, standardPassword)
+
+		suspensions, err := c.GetSuspensions(contracts.NewAuthenticated(&contracts.GetSuspensionsRequest{UserID: user.ID}, adminToken))
+		require.NoError(t, err)
+		require.NotNil(t, suspensions[0].LiftedAt)
+		require.Equal(t, admin.ID, *suspensions[0].LiftedBy)
+	})
+
+	t.Run("users.LiftSuspension: lifted already", func(t *testing.T) {
+		req := &contracts.LiftSuspensionRequest{UserID: user.ID, SuspensionID: suspension.ID}
+		err := c.LiftSuspension(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "suspension", "id", suspension.ID)
+	})
+
+	t.Run("users.SuspendUser: reviews hidden", func(t *testing.T) {
+		reviewsReq := &contracts.GetReviewsByUserIDRequest{UserID: markTwain.ID}
+		before, err := c.GetReviewsByUserID(reviewsReq)
+		require.NoError(t, err)
+
+		req := &contracts.SuspendUserRequest{UserID: markTwain.ID, Reason: "spam"}
+		permanent, err := c.SuspendUser(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Nil(t, permanent.ExpiresAt)
+
+		reviews, err := c.GetReviewsByUserID(reviewsReq)
+		require.NoError(t, err)
+		require.Equal(t, 0, reviews.Total)
+
+		lift := &contracts.LiftSuspensionRequest{UserID: markTwain.ID, SuspensionID: permanent.ID}
+		err = c.LiftSuspension(contracts.NewAuthenticated(lift, adminToken))
+		require.NoError(t, err)
+
+		reviews, err = c.GetReviewsByUserID(reviewsReq)
+		require.NoError(t, err)
+		require.Equal(t, before.Total, reviews.Total)
+	})
+}
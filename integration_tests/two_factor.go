@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/client"
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/DavidMovas/Movies-Reviews/internal/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func twoFactorAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
+	user := registerRandomUser(t, c, "twofactor", "twofactor")
+	accessToken := login(t, c, user.Email, standardPassword)
+
+	var (
+		secret        string
+		confirmStep   int64
+		recoveryCodes []string
+	)
+
+	loginWithTwoFactor := func(t *testing.T) string {
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		require.NoError(t, err)
+		require.True(t, res.TwoFactorRequired)
+		require.Empty(t, res.AccessToken)
+		require.NotEmpty(t, res.TwoFactorToken)
+		return res.TwoFactorToken
+	}
+
+	t.Run("auth.ConfirmTwoFactor: enrolment not started", func(t *testing.T) {
+		req := &contracts.ConfirmTwoFactorRequest{Code: "123456"}
+		_, err := c.ConfirmTwoFactor(contracts.NewAuthenticated(req, accessToken))
+		requireBadRequestError(t, err, "two-factor enrolment is not started")
+	})
+
+	t.Run("auth.EnrollTwoFactor: success", func(t *testing.T) {
+		res, err := c.EnrollTwoFactor(accessToken)
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Secret)
+		require.Contains(t, res.URI, "otpauth://totp/Movies-Reviews:")
+		require.Contains(t, res.URI, "secret="+res.Secret)
+		secret = res.Secret
+	})
+
+	t.Run("auth.ConfirmTwoFactor: invalid code", func(t *testing.T) {
+		req := &contracts.ConfirmTwoFactorRequest{Code: "abcdef"}
+		_, err := c.ConfirmTwoFactor(contracts.NewAuthenticated(req, accessToken))
+		requireUnauthorizedError(t, err, "invalid two-factor code")
+	})
+
+	t.Run("auth.ConfirmTwoFactor: success", func(t *testing.T) {
+		confirmStep = totp.Step(time.Now())
+		code, err := totp.Code(secret, confirmStep)
+		require.NoError(t, err)
+
+		res, err := c.ConfirmTwoFactor(contracts.NewAuthenticated(&contracts.ConfirmTwoFactorRequest{Code: code}, accessToken))
+		require.NoError(t, err)
+		require.Len(t, res.RecoveryCodes, cfg.Auth.TwoFactor.RecoveryCodes)
+		recoveryCodes = res.RecoveryCodes
+	})
+
+	t.Run("auth.EnrollTwoFactor: already enabled", func(t *testing.T) {
+		_, err := c.EnrollTwoFactor(accessToken)
+		requireBadRequestError(t, err, "two-factor authentication is already enabled")
+	})
+
+	t.Run("auth.LoginTwoFactor: replayed code", func(t *testing.T) {
+		code, err := totp.Code(secret, confirmStep)
+		require.NoError(t, err)
+
+		_, err = c.LoginTwoFactor(&contracts.LoginTwoFactorRequest{TwoFactorToken: loginWithTwoFactor(t), Code: code})
+		requireUnauthorizedError(t, err, "invalid two-factor code")
+	})
+
+	t.Run("auth.LoginTwoFactor: success", func(t *testing.T) {
+		twoFactorToken := loginWithTwoFactor(t)
+		code, err := totp.Code(secret, confirmStep+1)
+		require.NoError(t, err)
+
+		res, err := c.LoginTwoFactor(&contracts.LoginTwoFactorRequest{TwoFactorToken: twoFactorToken, Code: code})
+		require.NoError(t, err)
+		require.Equal(t, user.ID, res.User.ID)
+		require.NotEmpty(t, res.AccessToken)
+		require.NotEmpty(t, res.RefreshToken)
+
+		_, err = c.LoginTwoFactor(&contracts.LoginTwoFactorRequest{TwoFactorToken: twoFactorToken, Code: recoveryCodes[0]})
+		requireBadRequestError(t, err, "invalid or expired token")
+	})
+
+	t.Run("auth.LoginTwoFactor: recovery code", func(t *testing.T) {
+		res, err := c.LoginTwoFactor(&contracts.LoginTwoFactorRequest{TwoFactorToken: loginWithTwoFactor(t), Code: recoveryCodes[0]})
+		require.NoError(t, err)
+		require.NotEmpty(t, res.AccessToken)
+
+		_, err = c.LoginTwoFactor(&contracts.LoginTwoFactorRequest{TwoFactorToken: loginWithTwoFactor(t), Code: recoveryCodes[0]})
+		requireUnauthorizedError(t, err, "invalid two-factor code")
+	})
+
+	t.Run("auth.RegenerateRecoveryCodes: success", func(t *testing.T) {
+		req := &contracts.RegenerateRecoveryCodesRequest{Code: recoveryCodes[1]}
+		res, err := c.RegenerateRecoveryCodes(contracts.NewAuthenticated(req, accessToken))
+		require.NoError(t, err)
+		require.Len(t, res.RecoveryCodes, cfg.Auth.TwoFactor.RecoveryCodes)
+
+		req = &contracts.RegenerateRecoveryCodesRequest{Code: recoveryCodes[2]}
+		_, err = c.RegenerateRecoveryCodes(contracts.NewAuthenticated(req, accessToken))
+		requireUnauthorizedError(t, err, "invalid two-factor code")
+
+		recoveryCodes = res.RecoveryCodes
+	})
+
+	// The wrong recovery codes and the wrong password make three failures in a row, the threshold of the account
+	t.Run("auth.DisableTwoFactor: wrong password", func(t *testing.T) {
+		req := &contracts.DisableTwoFactorRequest{Password: standardPassword + "wrong", Code: recoveryCodes[0]}
+		err := c.DisableTwoFactor(contracts.NewAuthenticated(req, accessToken))
+		requireUnauthorizedError(t, err, "invalid credentials")
+	})
+
+	t.Run("auth.DisableTwoFactor: locked", func(t *testing.T) {
+		req := &contracts.DisableTwoFactorRequest{Password: standardPassword, Code: recoveryCodes[0]}
+		err := c.DisableTwoFactor(contracts.NewAuthenticated(req, accessToken))
+		requireTooManyRequestsError(t, err, "too many failed login attempts")
+
+		regenerate := &contracts.RegenerateRecoveryCodesRequest{Code: recoveryCodes[0]}
+		_, err = c.RegenerateRecoveryCodes(contracts.NewAuthenticated(regenerate, accessToken))
+		requireTooManyRequestsError(t, err, "too many failed login attempts")
+
+		adminToken := login(t, c, cfg.Admin.Email, cfg.Admin.Password)
+		unlock := &contracts.DeleteLockoutRequest{Scope: "user", Subject: strconv.Itoa(user.ID)}
+		require.NoError(t, c.DeleteLockout(contracts.NewAuthenticated(unlock, adminToken)))
+	})
+
+	t.Run("auth.DisableTwoFactor: wrong code", func(t *testing.T) {
+		req := &contracts.DisableTwoFactorRequest{Password: standardPassword, Code: "abcde-fghij"}
+		err := c.DisableTwoFactor(contracts.NewAuthenticated(req, accessToken))
+		requireUnauthorizedError(t, err, "invalid two-factor code")
+	})
+
+	t.Run("auth.DisableTwoFactor: success", func(t *testing.T) {
+		req := &contracts.DisableTwoFactorRequest{Password: standardPassword, Code: recoveryCodes[0]}
+		err := c.DisableTwoFactor(contracts.NewAuthenticated(req, accessToken))
+		require.NoError(t, err)
+
+		res, err := c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		require.NoError(t, err)
+		require.False(t, res.TwoFactorRequired)
+		require.NotEmpty(t, res.AccessToken)
+	})
+}
@@ -27,7 +27,6 @@ func usersAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 		req := &contracts.UpdateUserRequest{
 			UserID:   johnMoore.ID,
 			Username: ptr(fmt.Sprintf("%sTEST", johnMoore.Username)),
-			Password: ptr(fmt.Sprintf("%sTEST", johnMoorePass)),
 		}
 		user, err := c.UpdateUserData(contracts.NewAuthenticated(req, johnMooreToken))
 		require.NoError(t, err)
@@ -38,7 +37,6 @@ func usersAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 		req := &contracts.UpdateUserRequest{
 			UserID:   johnMoore.ID,
 			Username: ptr(johnMoore.Username),
-			Password: ptr(johnMoorePass),
 		}
 		_, err := c.UpdateUserData(contracts.NewAuthenticated(req, johnMooreToken))
 		require.NoError(t, err)
@@ -48,7 +46,6 @@ func usersAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 		req := &contracts.UpdateUserRequest{
 			UserID:   johnMoore.ID + 100,
 			Username: ptr(fmt.Sprintf("%sTEST", johnMoore.Username)),
-			Password: ptr(fmt.Sprintf("%sTEST", johnMoorePass)),
 		}
 		_, err := c.UpdateUserData(contracts.NewAuthenticated(req, johnMooreToken))
 		requireForbiddenError(t, err, "insufficient permissions")
@@ -58,7 +55,6 @@ func usersAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 		req := &contracts.UpdateUserRequest{
 			UserID:   johnMoore.ID + 1,
 			Username: ptr(fmt.Sprintf("%sTEST", johnMoore.Username)),
-			Password: ptr(fmt.Sprintf("%sTEST", johnMoorePass)),
 		}
 		_, err := c.UpdateUserData(contracts.NewAuthenticated(req, ""))
 		requireForbiddenError(t, err, "insufficient permissions")
@@ -88,6 +84,15 @@ func usersAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 		require.NoError(t, err)
 	})
 
+	t.Run("users.UpdateUserRoleById: old token revoked", func(t *testing.T) {
+		req := &contracts.UpdateUserRequest{
+			UserID:   johnMoore.ID,
+			Username: ptr(johnMoore.Username),
+		}
+		_, err := c.UpdateUserData(contracts.NewAuthenticated(req, johnMooreToken))
+		requireForbiddenError(t, err, "token has been revoked")
+	})
+
 	johnMooreToken = login(t, c, johnMoore.Email, johnMoorePass)
 	markTwainToken = login(t, c, markTwain.Email, markTwainPass)
 
@@ -130,10 +135,204 @@ func usersAPIChecks(t *testing.T, c *client.Client, cfg *config.Config) {
 		requireNotFoundError(t, err, "user", "id", johnMoore.ID+1000)
 	})
 
+	t.Run("auth.RevokeUserSessions: non-admin", func(t *testing.T) {
+		req := &contracts.RevokeUserSessionsRequest{UserID: markTwain.ID}
+		err := c.RevokeUserSessions(contracts.NewAuthenticated(req, johnMooreToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("auth.RevokeUserSessions: user not found", func(t *testing.T) {
+		req := &contracts.RevokeUserSessionsRequest{UserID: johnMoore.ID + 1000}
+		err := c.RevokeUserSessions(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "user", "id", johnMoore.ID+1000)
+	})
+
+	t.Run("auth.RevokeUserSessions: success", func(t *testing.T) {
+		user := registerRandomUser(t, c, "sessions", "sessions")
+		res, err := c.LoginUser(&contracts.LoginUserRequest{
+			Email:    user.Email,
+			Password: standardPassword,
+		})
+		require.NoError(t, err)
+
+		req := &contracts.RevokeUserSessionsRequest{UserID: user.ID}
+		err = c.RevokeUserSessions(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, res.AccessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+
+		_, err = c.RefreshToken(&contracts.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+		requireUnauthorizedError(t, err, "invalid refresh token")
+	})
+
+	t.Run("auth.RevokeUserSessions: token reused right after revocation", func(t *testing.T) {
+		user := registerRandomUser(t, c, "sessions", "sessions")
+		loginReq := &contracts.LoginUserRequest{
+			Email:    user.Email,
+			Password: standardPassword,
+		}
+		res, err := c.LoginUser(loginReq)
+		require.NoError(t, err)
+
+		// The revocation usually falls within the same second as the login, the token must be revoked anyway
+		req := &contracts.RevokeUserSessionsRequest{UserID: user.ID}
+		err = c.RevokeUserSessions(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, res.AccessToken))
+		requireForbiddenError(t, err, "token has been revoked")
+
+		// while tokens issued right after the revocation stay valid
+		res, err = c.LoginUser(loginReq)
+		require.NoError(t, err)
+
+		err = c.Logout(contracts.NewAuthenticated(&contracts.LogoutRequest{}, res.AccessToken))
+		require.NoError(t, err)
+	})
+
+	var deletedUser *contracts.User
+
 	t.Run("users.DeleteUserById: success", func(t *testing.T) {
-		user := register(t, c, "userForDelete", "2lG8G@example.com", standardPassword)
-		req := &contracts.DeleteUserRequest{UserID: user.ID}
+		deletedUser = register(t, c, "userForDelete", "2lG8G@example.com", standardPassword)
+		req := &contracts.DeleteUserRequest{UserID: deletedUser.ID}
 		err := c.DeleteUserByID(contracts.NewAuthenticated(req, adminToken))
 		require.NoError(t, err)
 	})
+
+	t.Run("users.GetUsers: insufficient permissions", func(t *testing.T) {
+		_, err := c.GetUsers(contracts.NewAuthenticated(&contracts.GetUsersRequest{}, johnMooreToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("users.GetUsers: invalid sort field", func(t *testing.T) {
+		req := &contracts.GetUsersRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{Sort: "pass_hash"}}
+		_, err := c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		requireBadRequestError(t, err, "invalid sort field")
+	})
+
+	t.Run("users.GetUsers: success", func(t *testing.T) {
+		res, err := c.GetUsers(contracts.NewAuthenticated(&contracts.GetUsersRequest{}, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, "createdAt", res.Sort)
+		require.Equal(t, "desc", res.Order)
+		require.Equal(t, deletedUser.ID, res.Items[0].ID)
+		require.Greater(t, res.Total, len(res.Items))
+	})
+
+	t.Run("users.GetUsers: search by prefix", func(t *testing.T) {
+		req := &contracts.GetUsersRequest{Search: ptr("USERFORDEL")}
+		res, err := c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Total)
+		require.Equal(t, deletedUser.ID, res.Items[0].ID)
+		require.NotNil(t, res.Items[0].DeletedAt)
+
+		req = &contracts.GetUsersRequest{Search: ptr("ForDelete")}
+		res, err = c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Total)
+	})
+
+	t.Run("users.GetUsers: filters", func(t *testing.T) {
+		req := &contracts.GetUsersRequest{Role: ptr(contracts.AdminRole), Deleted: ptr(false)}
+		res, err := c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Total)
+		require.Equal(t, cfg.Admin.Username, res.Items[0].Username)
+
+		req = &contracts.GetUsersRequest{Deleted: ptr(true), CreatedAfter: &deletedUser.CreatedAt}
+		res, err = c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Total)
+		require.Equal(t, deletedUser.ID, res.Items[0].ID)
+
+		req = &contracts.GetUsersRequest{CreatedBefore: &deletedUser.CreatedAt, Search: ptr("userForDelete")}
+		res, err = c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Total)
+	})
+
+	t.Run("users.GetUsers: sort by reviews count", func(t *testing.T) {
+		req := &contracts.GetUsersRequest{PaginatedRequestOrdered: contracts.PaginatedRequestOrdered{Sort: "reviewsCount", Order: "desc"}}
+		res, err := c.GetUsers(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		for i := 1; i < len(res.Items); i++ {
+			require.GreaterOrEqual(t, res.Items[i-1].ReviewsCount, res.Items[i].ReviewsCount)
+		}
+	})
+
+	t.Run("users.RestoreUserById: insufficient permissions", func(t *testing.T) {
+		req := &contracts.RestoreUserRequest{UserID: deletedUser.ID}
+		_, err := c.RestoreUser(contracts.NewAuthenticated(req, johnMooreToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("users.RestoreUserById: user not deleted", func(t *testing.T) {
+		req := &contracts.RestoreUserRequest{UserID: johnMoore.ID}
+		_, err := c.RestoreUser(contracts.NewAuthenticated(req, adminToken))
+		requireNotFoundError(t, err, "deleted user", "id", johnMoore.ID)
+	})
+
+	t.Run("users.RestoreUserById: email taken after deletion", func(t *testing.T) {
+		user := register(t, c, "userTakingEmail", deletedUser.Email, standardPassword)
+
+		req := &contracts.RestoreUserRequest{UserID: deletedUser.ID}
+		_, err := c.RestoreUser(contracts.NewAuthenticated(req, adminToken))
+		requireAlreadyExistsError(t, err, "user", "email", deletedUser.Email)
+
+		err = c.DeleteUserByID(contracts.NewAuthenticated(&contracts.DeleteUserRequest{UserID: user.ID}, adminToken))
+		require.NoError(t, err)
+	})
+
+	t.Run("users.RestoreUserById: success", func(t *testing.T) {
+		req := &contracts.RestoreUserRequest{UserID: deletedUser.ID}
+		user, err := c.RestoreUser(contracts.NewAuthenticated(req, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, deletedUser.ID, user.ID)
+		require.Nil(t, user.DeletedAt)
+
+		login(t, c, deletedUser.Email, standardPassword)
+	})
+
+	t.Run("users.AnonymizeUser: another user", func(t *testing.T) {
+		req := &contracts.AnonymizeUserRequest{UserID: deletedUser.ID}
+		err := c.AnonymizeUser(contracts.NewAuthenticated(req, johnMooreToken))
+		requireForbiddenError(t, err, "insufficient permissions")
+	})
+
+	t.Run("users.AnonymizeUser: success", func(t *testing.T) {
+		user := registerRandomUser(t, c, "userForAnonymize", "userForAnonymize")
+		token := login(t, c, user.Email, standardPassword)
+
+		req := &contracts.AnonymizeUserRequest{UserID: user.ID}
+		err := c.AnonymizeUser(contracts.NewAuthenticated(req, token))
+		requireBadRequestError(t, err, "current password is required")
+
+		req.CurrentPassword = "wRong!123"
+		err = c.AnonymizeUser(contracts.NewAuthenticated(req, token))
+		requireUnauthorizedError(t, err, "invalid credentials")
+
+		login(t, c, user.Email, standardPassword)
+
+		req.CurrentPassword = standardPassword
+		err = c.AnonymizeUser(contracts.NewAuthenticated(req, token))
+		require.NoError(t, err)
+
+		_, err = c.LoginUser(&contracts.LoginUserRequest{Email: user.Email, Password: standardPassword})
+		requireUnauthorizedError(t, err, "invalid credentials")
+
+		res, err := c.GetUsers(contracts.NewAuthenticated(&contracts.GetUsersRequest{Search: ptr("deleted_")}, adminToken))
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Total)
+		require.Equal(t, user.ID, res.Items[0].ID)
+		require.NotEqual(t, user.Email, res.Items[0].Email)
+		require.Nil(t, res.Items[0].Bio)
+
+		_, err = c.RestoreUser(contracts.NewAuthenticated(&contracts.RestoreUserRequest{UserID: user.ID}, adminToken))
+		requireNotFoundError(t, err, "deleted user", "id", user.ID)
+
+		err = c.AnonymizeUser(contracts.NewAuthenticated(&contracts.AnonymizeUserRequest{UserID: user.ID}, adminToken))
+		requireNotFoundError(t, err, "user", "id", user.ID)
+	})
 }
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v11"
@@ -22,15 +24,139 @@ type Config struct {
 	DBUrl      string           `env:"DB_URL"`
 	Port       int              `env:"PORT" envDefault:"8000"`
 	Local      bool             `env:"LOCAL" envDefault:"true"`
+	TrustProxy bool             `env:"TRUST_PROXY" envDefault:"false"`
+	CORS       CORSConfig       `envPrefix:"CORS_"`
 	JWT        JWTConfig        `envPrefix:"JWT_"`
 	Admin      AdminConfig      `envPrefix:"ADMIN_"`
+	Auth       AuthConfig       `envPrefix:"AUTH_"`
+	Mail       MailConfig       `envPrefix:"MAIL_"`
 	Logger     LoggerConfig     `envPrefix:"LOG_"`
 	Pagination PaginationConfig `envPrefix:"PAGINATION_"`
+	Exports    ExportsConfig    `envPrefix:"EXPORTS_"`
+	Search     SearchConfig     `envPrefix:"SEARCH_"`
+}
+
+// CORSConfig controls which origins may call the API from browsers. Credentials (cookies) can't be allowed
+// for any origin, the origins have to be listed.
+type CORSConfig struct {
+	AllowOrigins     []string `env:"ALLOW_ORIGINS" envDefault:"*"`
+	AllowCredentials bool     `env:"ALLOW_CREDENTIALS" envDefault:"false"`
 }
 
 type JWTConfig struct {
-	Secret           string        `env:"SECRET"`
-	AccessExpiration time.Duration `env:"ACCESS_EXPIRATION" envDefault:"5m"`
+	Algorithm         string            `env:"ALGORITHM" envDefault:"HS256"`
+	Secret            string            `env:"SECRET"`
+	SigningKeyID      string            `env:"SIGNING_KEY_ID"`
+	PrivateKeys       map[string]string `env:"PRIVATE_KEYS"`
+	PublicKeys        map[string]string `env:"PUBLIC_KEYS"`
+	AccessExpiration  time.Duration     `env:"ACCESS_EXPIRATION" envDefault:"5m"`
+	RefreshExpiration time.Duration     `env:"REFRESH_EXPIRATION" envDefault:"720h"`
+	Denylist          DenylistConfig    `envPrefix:"DENYLIST_"`
+}
+
+type DenylistConfig struct {
+	Store           string        `env:"STORE" envDefault:"postgres"`
+	CleanupInterval time.Duration `env:"CLEANUP_INTERVAL" envDefault:"10m"`
+}
+
+type AuthConfig struct {
+	PasswordResetExpiration     time.Duration      `env:"PASSWORD_RESET_EXPIRATION" envDefault:"1h"`
+	PasswordResetURL            string             `env:"PASSWORD_RESET_URL"`
+	EmailVerificationExpiration time.Duration      `env:"EMAIL_VERIFICATION_EXPIRATION" envDefault:"24h"`
+	EmailVerificationURL        string             `env:"EMAIL_VERIFICATION_URL"`
+	EmailChangeURL              string             `env:"EMAIL_CHANGE_URL"`
+	AllowUnverifiedLogin        bool               `env:"ALLOW_UNVERIFIED_LOGIN" envDefault:"true"`
+	InviteOnly                  bool               `env:"INVITE_ONLY" envDefault:"false"`
+	ImpersonationExpiration     time.Duration      `env:"IMPERSONATION_EXPIRATION" envDefault:"15m"`
+	PasswordHash                PasswordHashConfig `envPrefix:"PASSWORD_HASH_"`
+	Lockout                     LockoutConfig      `envPrefix:"LOCKOUT_"`
+	TwoFactor                   TwoFactorConfig    `envPrefix:"TWO_FACTOR_"`
+	OIDC                        OIDCConfig         `envPrefix:"OIDC_"`
+	Cookie                      CookieConfig       `envPrefix:"COOKIE_"`
+}
+
+// CookieConfig controls the cookie session mode for browser clients. Once enabled, clients can ask to get
+// the tokens in HttpOnly cookies on login instead of the response body.
+type CookieConfig struct {
+	Enabled  bool     `env:"ENABLED" envDefault:"false"`
+	Domain   string   `env:"DOMAIN"`
+	Secure   bool     `env:"SECURE" envDefault:"true"`
+	SameSite SameSite `env:"SAME_SITE" envDefault:"strict"`
+}
+
+// SameSite is the SameSite attribute of cookies: lax, strict or none.
+type SameSite http.SameSite
+
+func (s *SameSite) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "lax":
+		*s = SameSite(http.SameSiteLaxMode)
+	case "strict":
+		*s = SameSite(http.SameSiteStrictMode)
+	case "none":
+		*s = SameSite(http.SameSiteNoneMode)
+	default:
+		return fmt.Errorf("unknown SameSite mode: %q", text)
+	}
+
+	return nil
+}
+
+// PasswordHashConfig holds the argon2id parameters, Memory is in KiB. Hashes with weaker parameters
+// are replaced on the next successful login.
+type PasswordHashConfig struct {
+	Memory      uint32 `env:"MEMORY" envDefault:"19456"`
+	Iterations  uint32 `env:"ITERATIONS" envDefault:"2"`
+	Parallelism uint8  `env:"PARALLELISM" envDefault:"1"`
+}
+
+// LockoutConfig controls login throttling. Once an account or an IP reaches its threshold of failed attempts
+// it gets locked for BaseDuration, each next failure doubles the lock up to MaxDuration.
+// Failures older than FailureWindow are forgotten.
+type LockoutConfig struct {
+	UserThreshold int           `env:"USER_THRESHOLD" envDefault:"5"`
+	IPThreshold   int           `env:"IP_THRESHOLD" envDefault:"20"`
+	BaseDuration  time.Duration `env:"BASE_DURATION" envDefault:"1m"`
+	MaxDuration   time.Duration `env:"MAX_DURATION" envDefault:"1h"`
+	FailureWindow time.Duration `env:"FAILURE_WINDOW" envDefault:"1h"`
+}
+
+// TwoFactorConfig controls TOTP two-factor authentication. Users with one of RequiredRoles can't act
+// with their role until they enable it.
+type TwoFactorConfig struct {
+	Issuer              string        `env:"ISSUER" envDefault:"Movies-Reviews"`
+	RequiredRoles       []string      `env:"REQUIRED_ROLES"`
+	ChallengeExpiration time.Duration `env:"CHALLENGE_EXPIRATION" envDefault:"5m"`
+	RecoveryCodes       int           `env:"RECOVERY_CODES" envDefault:"10"`
+}
+
+type OIDCConfig struct {
+	StateExpiration time.Duration        `env:"STATE_EXPIRATION" envDefault:"10m"`
+	Providers       []OIDCProviderConfig `envPrefix:"PROVIDERS"`
+}
+
+// OIDCProviderConfig is an OpenID Connect identity provider, set as AUTH_OIDC_PROVIDERS_<index>_<field>.
+type OIDCProviderConfig struct {
+	Name         string   `env:"NAME"`
+	IssuerURL    string   `env:"ISSUER_URL"`
+	ClientID     string   `env:"CLIENT_ID"`
+	ClientSecret string   `env:"CLIENT_SECRET"`
+	RedirectURL  string   `env:"REDIRECT_URL"`
+	Scopes       []string `env:"SCOPES" envDefault:"openid,email,profile"`
+}
+
+type MailConfig struct {
+	Driver string     `env:"DRIVER" envDefault:"log"`
+	From   string     `env:"FROM" envDefault:"no-reply@movies-reviews.local"`
+	Dir    string     `env:"DIR" envDefault:"./mails"`
+	SMTP   SMTPConfig `envPrefix:"SMTP_"`
+}
+
+type SMTPConfig struct {
+	Host     string `env:"HOST"`
+	Port     int    `env:"PORT" envDefault:"587"`
+	Username string `env:"USERNAME"`
+	Password string `env:"PASSWORD"`
 }
 
 type LoggerConfig struct {
@@ -47,3 +173,17 @@ type PaginationConfig struct {
 	DefaultSize int `env:"DEFAULT_SIZE" envDefault:"10"`
 	MaxSize     int `env:"MAX_SIZE" envDefault:"20"`
 }
+
+// ExportsConfig controls personal data exports. Queued exports are picked up every PollInterval, 0 leaves
+// them to other instances. The archives can be downloaded for Expiration.
+type ExportsConfig struct {
+	PollInterval time.Duration `env:"POLL_INTERVAL" envDefault:"5s"`
+	Expiration   time.Duration `env:"EXPIRATION" envDefault:"24h"`
+}
+
+// SearchConfig limits the number of search suggestions of each type.
+type SearchConfig struct {
+	SuggestMovies int `env:"SUGGEST_MOVIES" envDefault:"5"`
+	SuggestStars  int `env:"SUGGEST_STARS" envDefault:"5"`
+	SuggestGenres int `env:"SUGGEST_GENRES" envDefault:"3"`
+}
@@ -18,6 +18,15 @@ func IsUniqueViolation(err error, name string) bool {
 	return false
 }
 
+func IsForeignKeyViolation(err error, name string) bool {
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		return pgError.Code == pgerrcode.ForeignKeyViolation && strings.Contains(pgError.ConstraintName, name)
+	}
+
+	return false
+}
+
 func IsNoRows(err error) bool {
 	if err != nil && err.Error() == "no rows in result set" {
 		return true
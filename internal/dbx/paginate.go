@@ -0,0 +1,61 @@
+package dbx
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
+)
+
+// Paginate orders the query by the keys of the page and selects its rows, plus one to tell whether there is a next
+// page. Rows before a cursor are selected in reverse order, pagination.Response trims and restores them.
+func Paginate[T any](query squirrel.SelectBuilder, page *pagination.Page[T]) squirrel.SelectBuilder {
+	backward := page.Backward()
+
+	for _, key := range page.Keys {
+		query = query.OrderByClause(key.Expr+" "+direction(key.Desc != backward), key.Args...)
+	}
+
+	query = query.Limit(uint64(page.Limit) + 1)
+	if page.Cursor == nil {
+		return query.Offset(uint64(page.Offset))
+	}
+
+	return query.Where(keysetCondition(page.Keys, page.Cursor.Values, backward))
+}
+
+// keysetCondition matches the rows after the values in the order of the keys:
+// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND c > ?)...
+func keysetCondition[T any](keys []pagination.SortKey[T], values []string, backward bool) squirrel.Or {
+	cond := make(squirrel.Or, 0, len(keys))
+	for i, key := range keys {
+		and := make(squirrel.And, 0, i+1)
+		for j := range i {
+			and = append(and, keyCondition(keys[j], "=", values[j]))
+		}
+
+		op := ">"
+		if key.Desc != backward {
+			op = "<"
+		}
+		and = append(and, keyCondition(key, op, values[i]))
+
+		cond = append(cond, and)
+	}
+
+	return cond
+}
+
+func keyCondition[T any](key pagination.SortKey[T], op, value string) squirrel.Sqlizer {
+	args := append(slices.Clip(key.Args), value)
+	return squirrel.Expr(fmt.Sprintf("%s %s CAST(? AS %s)", key.Expr, op, key.Type), args...)
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
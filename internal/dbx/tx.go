@@ -24,7 +24,7 @@ func FromContext(ctx context.Context, def Queryable) Queryable {
 	return def
 }
 
-func InTransaction(ctx context.Context, db *pgxpool.Pool, fn func(ctx2 context.Context, tx pgx.Tx) error) error {
+func InTransaction(ctx context.Context, db *pgxpool.Pool, fn func(ctx2 context.Context, tx pgx.Tx) error) (err error) {
 	tx, err := db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
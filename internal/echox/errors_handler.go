@@ -2,7 +2,9 @@ package echox
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/DavidMovas/Movies-Reviews/contracts"
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
@@ -12,6 +14,7 @@ import (
 
 type HTTPError struct {
 	Message    string `json:"message"`
+	Code       string `json:"code,omitempty"`
 	IncidentID string `json:"incident_id,omitempty"`
 }
 
@@ -28,6 +31,7 @@ func ErrorHandler(err error, c echo.Context) {
 
 	httpError := contracts.HTTPError{
 		Message:    appError.SafeError(),
+		Code:       toErrorCode(appError.Code),
 		IncidentID: appError.IncidentID,
 	}
 
@@ -45,6 +49,10 @@ func ErrorHandler(err error, c echo.Context) {
 		logger.Warn("client error", "message", err.Error())
 	}
 
+	if appError.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appError.RetryAfter.Seconds()))))
+	}
+
 	if err := c.JSON(toHTTPStatus(appError.Code), httpError); err != nil {
 		c.Logger().Error(err)
 	}
@@ -60,11 +68,23 @@ func toHTTPStatus(code apperrors.Code) int {
 		return http.StatusNotFound
 	case apperrors.UnauthorizedCode:
 		return http.StatusUnauthorized
-	case apperrors.ForbiddenCode:
+	case apperrors.ForbiddenCode, apperrors.SuspendedCode:
 		return http.StatusForbidden
 	case apperrors.AlreadyExistsCode, apperrors.VersionMismatchCode:
 		return http.StatusConflict
+	case apperrors.TooManyRequestsCode:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
 }
+
+// toErrorCode tells apart errors which share the HTTP status with others, so clients can react to them.
+func toErrorCode(code apperrors.Code) string {
+	switch code {
+	case apperrors.SuspendedCode:
+		return contracts.AccountSuspendedErrorCode
+	default:
+		return ""
+	}
+}
@@ -9,9 +9,11 @@ import (
 )
 
 type LoginRequest struct {
-	Email    *string `json:"email"`
-	Username *string `json:"username"`
-	Password string  `json:"password"`
+	Email      *string `json:"email"`
+	Username   *string `json:"username"`
+	Password   string  `json:"password"`
+	Device     *string `json:"device"`
+	UseCookies bool    `json:"use_cookies"`
 }
 
 type EmailField struct {
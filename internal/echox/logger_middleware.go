@@ -17,12 +17,20 @@ func Logger(next echo.HandlerFunc) echo.HandlerFunc {
 		attrs := []any{requestGroup}
 
 		if claims := jwt.GetClaims(c); claims != nil {
-			requesterGroup := slog.Group("requester",
+			requesterAttrs := []any{
 				slog.Int("id", claims.UserID),
 				slog.String("role", claims.Role),
-			)
+			}
 
-			attrs = append(attrs, requesterGroup)
+			// Impersonated requests are logged with both the impersonated user and the one acting as them
+			if claims.IsImpersonated() {
+				requesterAttrs = append(requesterAttrs, slog.Group("act",
+					slog.Int("id", claims.Act.UserID),
+					slog.String("role", claims.Act.Role),
+				))
+			}
+
+			attrs = append(attrs, slog.Group("requester", requesterAttrs...))
 		}
 
 		logger := slog.Default().With(attrs...)
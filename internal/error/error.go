@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -18,6 +19,8 @@ const (
 	UnauthorizedCode
 	ForbiddenCode
 	VersionMismatchCode
+	TooManyRequestsCode
+	SuspendedCode
 )
 
 var _ error = (*Error)(nil)
@@ -26,6 +29,7 @@ type Error struct {
 	Code       Code
 	StackTrace string
 	IncidentID string
+	RetryAfter time.Duration
 
 	innerError error
 	hiderError bool
@@ -98,7 +102,16 @@ func NotFound(subject, key string, value any) *Error {
 }
 
 func AlreadyExists(subject, key string, value any) *Error {
-	return newError(AlreadyExistsCode, fmt.Sprintf("%s %s: %v already extists", subject, key, value))
+	return newError(AlreadyExistsCode, alreadyExistsMessage(subject, key, value))
+}
+
+// AlreadyExistsWrapped is AlreadyExists keeping the cause, so callers can tell which value is taken with errors.Is.
+func AlreadyExistsWrapped(err error, subject, key string, value any) *Error {
+	return newHiddenError(err, AlreadyExistsCode, alreadyExistsMessage(subject, key, value))
+}
+
+func alreadyExistsMessage(subject, key string, value any) string {
+	return fmt.Sprintf("%s %s: %v already extists", subject, key, value)
 }
 
 func Unauthorized(message string) *Error {
@@ -117,6 +130,21 @@ func VersionMismatch(subject, key string, value any, version int) *Error {
 	return newError(VersionMismatchCode, fmt.Sprintf("stale version %d for %s %s: %v", version, subject, key, value))
 }
 
+func TooManyRequests(message string, retryAfter time.Duration) *Error {
+	appErr := newError(TooManyRequestsCode, message)
+	appErr.RetryAfter = retryAfter
+	return appErr
+}
+
+// Suspended rejects a suspended user, until is nil for permanent suspensions.
+func Suspended(reason string, until *time.Time) *Error {
+	if until == nil {
+		return newError(SuspendedCode, fmt.Sprintf("account suspended: %s", reason))
+	}
+
+	return newError(SuspendedCode, fmt.Sprintf("account suspended until %s: %s", until.Format(time.RFC3339), reason))
+}
+
 func newError(code Code, message string) *Error {
 	return &Error{
 		Code:    code,
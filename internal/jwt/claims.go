@@ -1,11 +1,76 @@
 package jwt
 
 import (
+	"slices"
+	"time"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// TimePrecision is the precision of the times in issued tokens. Sub-second issue times let the denylist
+// tell apart tokens issued right before and right after all tokens of a user were revoked.
+const TimePrecision = time.Microsecond
+
+// SetupTimePrecision makes the jwt library encode and decode token times with TimePrecision.
+// It has to be called before any token is generated or parsed.
+func SetupTimePrecision() {
+	jwt.TimePrecision = TimePrecision
+}
+
 type AccessClaims struct {
 	jwt.RegisteredClaims
-	UserID int    `json:"user_id"`
-	Role   string `json:"role"`
+	UserID        int      `json:"user_id"`
+	Role          string   `json:"role"`
+	EmailVerified bool     `json:"email_verified"`
+	Scopes        []string `json:"-"`
+
+	// Permissions are granted by the role at the time the token is issued.
+	Permissions []string `json:"permissions,omitempty"`
+
+	// TwoFactorSetupRequired is set when the role of the user requires two-factor authentication,
+	// but the user hasn't enabled it yet. Such tokens can't be used for the role's privileges.
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+
+	// Act is set on impersonation tokens: the token acts as UserID on behalf of the user in Act.
+	Act *ActClaim `json:"act,omitempty"`
+
+	// PersonalAccessToken is set when the request is authenticated with a personal access token instead of a JWT.
+	// Such requests are limited to the Scopes.
+	PersonalAccessToken bool `json:"-"`
+}
+
+// ActClaim identifies the user acting on behalf of the token subject (RFC 8693).
+type ActClaim struct {
+	Subject string `json:"sub"`
+	UserID  int    `json:"user_id"`
+	Role    string `json:"role"`
+}
+
+// IsImpersonated reports whether the token was issued to another user acting as the token user.
+func (c *AccessClaims) IsImpersonated() bool {
+	return c.Act != nil
+}
+
+// HasScope reports whether the token grants the scope. JWTs grant every scope.
+func (c *AccessClaims) HasScope(scope string) bool {
+	return !c.PersonalAccessToken || slices.Contains(c.Scopes, scope)
+}
+
+// HasPermission reports whether the role of the user grants the permission.
+func (c *AccessClaims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// TokenUser is the user an access token is issued for.
+type TokenUser struct {
+	ID            int
+	Role          string
+	EmailVerified bool
+	Permissions   []string
+	// TwoFactorSetupRequired marks users whose role requires two-factor authentication they haven't enabled yet.
+	TwoFactorSetupRequired bool
+	// Act is the impersonating user, if any.
+	Act *ActClaim
+	// Expiration overrides the configured access token lifetime if set.
+	Expiration time.Duration
 }
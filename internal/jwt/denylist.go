@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	MemoryDenylistStore   = "memory"
+	PostgresDenylistStore = "postgres"
+)
+
+// Denylist keeps revoked access tokens until they would have expired anyway.
+// Tokens can be revoked one by one (by jti) or all at once for a user (by issue time). Revoking the tokens
+// of a user revokes the impersonation tokens the user acts with too.
+type Denylist interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, claims *AccessClaims) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+func NewDenylist(store string, db *pgxpool.Pool) (Denylist, error) {
+	switch store {
+	case MemoryDenylistStore:
+		return NewMemoryDenylist(), nil
+	case PostgresDenylistStore:
+		return NewPostgresDenylist(db), nil
+	default:
+		return nil, fmt.Errorf("unknown denylist store: %q", store)
+	}
+}
+
+// actorID returns the id of the impersonating user, zero if the token is not an impersonation one.
+func actorID(claims *AccessClaims) int {
+	if claims.Act == nil {
+		return 0
+	}
+
+	return claims.Act.UserID
+}
+
+// issuedBefore reports whether the token was issued before the user revocation.
+// Issue times are truncated to TimePrecision, so a token issued within the same microsecond as the revocation
+// counts as revoked.
+func issuedBefore(claims *AccessClaims, revokedBefore time.Time) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+
+	return !claims.IssuedAt.Time.After(revokedBefore.Truncate(TimePrecision))
+}
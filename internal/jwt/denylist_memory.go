@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Denylist = (*MemoryDenylist)(nil)
+
+type userRevocation struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// MemoryDenylist is a process local denylist, suitable for a single instance deployment and tests.
+type MemoryDenylist struct {
+	mx     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int]userRevocation
+}
+
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]userRevocation),
+	}
+}
+
+func (d *MemoryDenylist) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	d.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeUserTokens keeps the latest revocation and expiration of the user, like the Postgres store does,
+// so a call with older times can't make revoked tokens valid again.
+func (d *MemoryDenylist) RevokeUserTokens(_ context.Context, userID int, issuedBefore, expiresAt time.Time) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	revocation := d.users[userID]
+	d.users[userID] = userRevocation{
+		revokedBefore: latest(revocation.revokedBefore, issuedBefore),
+		expiresAt:     latest(revocation.expiresAt, expiresAt),
+	}
+	return nil
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func (d *MemoryDenylist) IsRevoked(_ context.Context, claims *AccessClaims) (bool, error) {
+	d.mx.RLock()
+	defer d.mx.RUnlock()
+
+	if _, ok := d.tokens[claims.ID]; ok {
+		return true, nil
+	}
+
+	for _, userID := range []int{claims.UserID, actorID(claims)} {
+		if revocation, ok := d.users[userID]; ok && issuedBefore(claims, revocation.revokedBefore) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (d *MemoryDenylist) DeleteExpired(_ context.Context) error {
+	now := time.Now()
+
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	for jti, expiresAt := range d.tokens {
+		if expiresAt.Before(now) {
+			delete(d.tokens, jti)
+		}
+	}
+
+	for userID, revocation := range d.users {
+		if revocation.expiresAt.Before(now) {
+			delete(d.users, userID)
+		}
+	}
+
+	return nil
+}
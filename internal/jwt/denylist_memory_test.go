@@ -0,0 +1,59 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDenylist_RevokeUserTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	claims := func(issuedAt time.Time) *AccessClaims {
+		return &AccessClaims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti", IssuedAt: jwt.NewNumericDate(issuedAt)},
+			UserID:           1,
+		}
+	}
+
+	d := NewMemoryDenylist()
+	require.NoError(t, d.RevokeUserTokens(ctx, 1, now, now.Add(time.Hour)))
+
+	t.Run("issued before", func(t *testing.T) {
+		revoked, err := d.IsRevoked(ctx, claims(now.Add(-time.Second)))
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+
+	t.Run("issued after", func(t *testing.T) {
+		revoked, err := d.IsRevoked(ctx, claims(now.Add(time.Second)))
+		require.NoError(t, err)
+		require.False(t, revoked)
+	})
+
+	t.Run("older revocation keeps the latest one", func(t *testing.T) {
+		require.NoError(t, d.RevokeUserTokens(ctx, 1, now.Add(-time.Minute), now.Add(time.Minute)))
+
+		revoked, err := d.IsRevoked(ctx, claims(now.Add(-time.Second)))
+		require.NoError(t, err)
+		require.True(t, revoked)
+
+		require.NoError(t, d.DeleteExpired(ctx))
+		revoked, err = d.IsRevoked(ctx, claims(now.Add(-time.Second)))
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		other := claims(now.Add(-time.Second))
+		other.UserID = 2
+
+		revoked, err := d.IsRevoked(ctx, other)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	})
+}
@@ -0,0 +1,82 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ Denylist = (*PostgresDenylist)(nil)
+
+// PostgresDenylist shares revoked tokens between all instances of the service.
+// All times are stored in UTC.
+type PostgresDenylist struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresDenylist(db *pgxpool.Pool) *PostgresDenylist {
+	return &PostgresDenylist{
+		db: db,
+	}
+}
+
+func (d *PostgresDenylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query, args, err := dbx.StatementBuilder.Insert("revoked_tokens").
+		Columns("jti", "expires_at").
+		Values(jti, expiresAt.UTC()).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(ctx, query, args...)
+	return err
+}
+
+func (d *PostgresDenylist) RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error {
+	query, args, err := dbx.StatementBuilder.Insert("revoked_users").
+		Columns("user_id", "revoked_before", "expires_at").
+		Values(userID, issuedBefore.UTC(), expiresAt.UTC()).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET revoked_before = GREATEST(revoked_users.revoked_before, EXCLUDED.revoked_before), expires_at = GREATEST(revoked_users.expires_at, EXCLUDED.expires_at)").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(ctx, query, args...)
+	return err
+}
+
+func (d *PostgresDenylist) IsRevoked(ctx context.Context, claims *AccessClaims) (bool, error) {
+	var tokenRevoked bool
+	var revokedBefore *time.Time
+
+	// Impersonation tokens are revoked with the tokens of the impersonating user too, the latest revocation counts
+	err := d.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1), (SELECT MAX(revoked_before) FROM revoked_users WHERE user_id IN ($2, $3))`,
+		claims.ID, claims.UserID, actorID(claims),
+	).Scan(&tokenRevoked, &revokedBefore)
+	if err != nil {
+		return false, err
+	}
+
+	if tokenRevoked {
+		return true, nil
+	}
+
+	return revokedBefore != nil && issuedBefore(claims, *revokedBefore), nil
+}
+
+func (d *PostgresDenylist) DeleteExpired(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	if _, err := d.db.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < $1`, now); err != nil {
+		return err
+	}
+
+	_, err := d.db.Exec(ctx, `DELETE FROM revoked_users WHERE expires_at < $1`, now)
+	return err
+}
@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// KeySet holds the key used to sign new tokens and all keys tokens are still accepted with.
+// With HS256 the shared secret is used for both. With RS256 or EdDSA every token carries
+// the id of its signing key in the kid header, so a new signing key can be introduced
+// while the public keys of the previous ones keep verifying not yet expired tokens.
+type KeySet struct {
+	method     jwt.SigningMethod
+	signingKID string
+	signingKey crypto.PrivateKey
+	secret     []byte
+	keys       map[string]verificationKey
+}
+
+func NewKeySet(cfg config.JWTConfig) (*KeySet, error) {
+	switch cfg.Algorithm {
+	case "", AlgorithmHS256:
+		if cfg.Secret == "" {
+			return nil, errors.New("jwt secret is required for HS256")
+		}
+
+		return &KeySet{
+			method: jwt.SigningMethodHS256,
+			secret: []byte(cfg.Secret),
+		}, nil
+	case AlgorithmRS256, AlgorithmEdDSA:
+		return newAsymmetricKeySet(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %q", cfg.Algorithm)
+	}
+}
+
+func newAsymmetricKeySet(cfg config.JWTConfig) (*KeySet, error) {
+	ks := &KeySet{
+		signingKID: cfg.SigningKeyID,
+		keys:       make(map[string]verificationKey),
+	}
+
+	for kid, path := range cfg.PrivateKeys {
+		privateKey, err := readPrivateKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("read private key %q: %w", kid, err)
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("private key %q can not sign", kid)
+		}
+
+		method, err := methodForKey(signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("private key %q: %w", kid, err)
+		}
+
+		ks.keys[kid] = verificationKey{method: method, key: signer.Public()}
+		if kid == cfg.SigningKeyID {
+			ks.method = method
+			ks.signingKey = privateKey
+		}
+	}
+
+	for kid, path := range cfg.PublicKeys {
+		if _, ok := ks.keys[kid]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", kid)
+		}
+
+		publicKey, err := readPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("read public key %q: %w", kid, err)
+		}
+
+		method, err := methodForKey(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("public key %q: %w", kid, err)
+		}
+
+		ks.keys[kid] = verificationKey{method: method, key: publicKey}
+	}
+
+	if ks.signingKey == nil {
+		return nil, fmt.Errorf("signing key %q not found among private keys", cfg.SigningKeyID)
+	}
+
+	if ks.method.Alg() != cfg.Algorithm {
+		return nil, fmt.Errorf("signing key %q does not match algorithm %s", cfg.SigningKeyID, cfg.Algorithm)
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	if ks.secret != nil {
+		return token.SignedString(ks.secret)
+	}
+
+	token.Header["kid"] = ks.signingKID
+	return token.SignedString(ks.signingKey)
+}
+
+// keyFunc picks the verification key by the kid header and makes sure the token
+// is signed with the algorithm of that key, so a public key can never be used as an HMAC secret.
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if ks.secret != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return ks.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.key, nil
+}
+
+// JWKS returns the public verification keys, sorted by key id. It's empty for HS256.
+func (ks *KeySet) JWKS() *JWKS {
+	jwks := &JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for kid, key := range ks.keys {
+		jwks.Keys = append(jwks.Keys, newJWK(kid, key.method.Alg(), key.key))
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+
+	return jwks
+}
+
+func methodForKey(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+func readPrivateKey(path string) (crypto.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	return block, nil
+}
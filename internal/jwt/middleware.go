@@ -1,34 +1,82 @@
 package jwt
 
 import (
+	"context"
 	"strings"
 
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
-	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 )
 
 type contextKey string
 
-const tokenContextKey contextKey = "token"
+const claimsContextKey contextKey = "claims"
 
-func NewAuthMiddleware(secret string) echo.MiddlewareFunc {
+// AccessTokenCookie holds the access token of browser clients using the cookie session mode.
+// The Authorization header takes precedence over it.
+const AccessTokenCookie = "access_token"
+
+// TokenAuthenticator authenticates bearer tokens other than JWTs, e.g. personal access tokens.
+// It's used for every token starting with its prefix.
+type TokenAuthenticator interface {
+	TokenPrefix() string
+	Authenticate(ctx context.Context, token string) (*AccessClaims, error)
+}
+
+// SuspensionChecker rejects users who are suspended, whatever token they use.
+type SuspensionChecker interface {
+	CheckSuspension(ctx context.Context, userID int) error
+}
+
+func NewAuthMiddleware(service *Service, suspensions SuspensionChecker, authenticators ...TokenAuthenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			tokenStr := c.Request().Header.Get("Authorization")
-			token, err := jwt.ParseWithClaims(clearToken(tokenStr), &AccessClaims{}, func(_ *jwt.Token) (interface{}, error) {
-				return []byte(secret), nil
-			})
+			if tokenStr == "" {
+				cookie, err := c.Cookie(AccessTokenCookie)
+				if err != nil || cookie.Value == "" {
+					return next(c)
+				}
+				tokenStr = cookie.Value
+			}
+
+			tokenStr = clearToken(tokenStr)
+			for _, authenticator := range authenticators {
+				if strings.HasPrefix(tokenStr, authenticator.TokenPrefix()) {
+					claims, err := authenticator.Authenticate(c.Request().Context(), tokenStr)
+					if err != nil {
+						return err
+					}
+
+					if err = suspensions.CheckSuspension(c.Request().Context(), claims.UserID); err != nil {
+						return err
+					}
 
-			if err != nil && tokenStr == "" {
-				return next(c)
+					c.Set(string(claimsContextKey), claims)
+					return next(c)
+				}
 			}
 
+			token, err := service.ParseToken(tokenStr)
 			if err != nil || !token.Valid {
 				return apperrors.Forbidden("invalid token")
 			}
 
-			c.Set(string(tokenContextKey), token)
+			claims := token.Claims.(*AccessClaims)
+			revoked, err := service.IsRevoked(c.Request().Context(), claims)
+			if err != nil {
+				return apperrors.Internal(err)
+			}
+
+			if revoked {
+				return apperrors.Forbidden("token has been revoked")
+			}
+
+			if err = suspensions.CheckSuspension(c.Request().Context(), claims.UserID); err != nil {
+				return err
+			}
+
+			c.Set(string(claimsContextKey), claims)
 
 			return next(c)
 		}
@@ -36,13 +84,12 @@ func NewAuthMiddleware(secret string) echo.MiddlewareFunc {
 }
 
 func GetClaims(c echo.Context) *AccessClaims {
-	token := c.Get(string(tokenContextKey))
-
-	if token == nil {
+	claims, ok := c.Get(string(claimsContextKey)).(*AccessClaims)
+	if !ok {
 		return nil
 	}
 
-	return token.(*jwt.Token).Claims.(*AccessClaims)
+	return claims
 }
 
 func clearToken(tokenStr string) string {
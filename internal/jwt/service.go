@@ -1,42 +1,112 @@
 package jwt
 
 import (
+	"context"
+	"log/slog"
 	"strconv"
 	"time"
 
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 )
 
 type Service struct {
-	secret           string
-	accessExpiration time.Duration
+	keys              *KeySet
+	accessExpiration  time.Duration
+	refreshExpiration time.Duration
+	denylist          Denylist
 }
 
-func NewService(secret string, accessExpiration time.Duration) *Service {
-	return &Service{
-		secret:           secret,
-		accessExpiration: accessExpiration,
+func NewService(cfg config.JWTConfig, denylist Denylist) (*Service, error) {
+	keys, err := NewKeySet(cfg)
+	if err != nil {
+		return nil, err
 	}
+
+	return &Service{
+		keys:              keys,
+		accessExpiration:  cfg.AccessExpiration,
+		refreshExpiration: cfg.RefreshExpiration,
+		denylist:          denylist,
+	}, nil
 }
 
-func (s *Service) GenerateToken(userID int, role string) (string, error) {
+func (s *Service) GenerateToken(user TokenUser) (string, error) {
+	expiration := s.accessExpiration
+	if user.Expiration > 0 {
+		expiration = user.Expiration
+	}
+
 	now := time.Now()
 	claims := &AccessClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(),
-			Subject:   strconv.Itoa(userID),
+			Subject:   strconv.Itoa(user.ID),
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessExpiration)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
 		},
-		UserID: userID,
-		Role:   role,
+		UserID:                 user.ID,
+		Role:                   user.Role,
+		EmailVerified:          user.EmailVerified,
+		Permissions:            user.Permissions,
+		TwoFactorSetupRequired: user.TwoFactorSetupRequired,
+		Act:                    user.Act,
+	}
+
+	return s.keys.sign(claims)
+}
+
+func (s *Service) ParseToken(tokenStr string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenStr, &AccessClaims{}, s.keys.keyFunc)
+}
+
+func (s *Service) JWKS() *JWKS {
+	return s.keys.JWKS()
+}
+
+// RevokeToken puts the token into the denylist until it expires.
+func (s *Service) RevokeToken(ctx context.Context, claims *AccessClaims) error {
+	expiresAt := time.Now().Add(s.accessExpiration)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+	return s.denylist.RevokeToken(ctx, claims.ID, expiresAt)
+}
+
+// RevokeUserTokens revokes every access token issued to the user so far, including the tokens the user got
+// to impersonate others.
+func (s *Service) RevokeUserTokens(ctx context.Context, userID int) error {
+	now := time.Now()
+	return s.denylist.RevokeUserTokens(ctx, userID, now, now.Add(s.accessExpiration))
+}
+
+func (s *Service) IsRevoked(ctx context.Context, claims *AccessClaims) (bool, error) {
+	return s.denylist.IsRevoked(ctx, claims)
+}
+
+// RunDenylistCleanup periodically removes expired entries from the denylist until ctx is done.
+func (s *Service) RunDenylistCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.denylist.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("failed to clean up token denylist", "error", err)
+			}
+		}
+	}
 }
 
 func (s *Service) GetAccessExpiration() time.Duration {
 	return s.accessExpiration
 }
+
+func (s *Service) GetRefreshExpiration() time.Duration {
+	return s.refreshExpiration
+}
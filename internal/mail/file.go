@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ Mailer = (*FileMailer)(nil)
+
+// FileMailer writes every message into its own <timestamp>_<recipient>.eml file in dir.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail dir: %w", err)
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (m *FileMailer) Send(_ context.Context, msg *Message) error {
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), msg.To)
+	if err := os.WriteFile(filepath.Join(m.dir, name), compose(m.from, msg), 0o600); err != nil {
+		return fmt.Errorf("write mail: %w", err)
+	}
+
+	return nil
+}
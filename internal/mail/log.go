@@ -0,0 +1,25 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+)
+
+var _ Mailer = (*LogMailer)(nil)
+
+// LogMailer only logs messages. Never use it in production: the logs will contain secret tokens.
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{
+		from: from,
+	}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	log.FromContext(ctx).Info("mail sent", "from", m.from, "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+)
+
+const (
+	SMTPDriver = "smtp"
+	FileDriver = "file"
+	LogDriver  = "log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers plain text emails. SMTP is meant for production,
+// the file and log mailers for local development and integration tests.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+func NewMailer(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case SMTPDriver:
+		return NewSMTPMailer(cfg.SMTP, cfg.From), nil
+	case FileDriver:
+		return NewFileMailer(cfg.Dir, cfg.From)
+	case LogDriver:
+		return NewLogMailer(cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,91 @@
+package accesstokens
+
+import (
+	"net/http"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	*Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		Service: service,
+	}
+}
+
+// CreateAccessToken @Summary Create personal access token
+// @Description Create personal access token limited to the given scopes. The token is returned only once
+// @ID create-access-token
+// @Tags access tokens
+// @Param userId path int true "User ID"
+// @Param token body CreateAccessTokenRequest true "Access token"
+// @Produce json
+// @Success 201 {object} AccessTokenWithSecret "Access token with the secret"
+// @Failure 400 {object} apperrors.Error "Invalid parameter, unknown scope or missing parameter"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/tokens [post]
+func (h *Handler) CreateAccessToken(c echo.Context) error {
+	req, err := echox.BindAndValidate[CreateAccessTokenRequest](c)
+	if err != nil {
+		return err
+	}
+
+	token, err := h.Service.CreateAccessToken(c.Request().Context(), policy.ActorFromContext(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, token)
+}
+
+// GetAccessTokens @Summary Get personal access tokens
+// @Description Get personal access tokens of the user, without secrets
+// @ID get-access-tokens
+// @Tags access tokens
+// @Param userId path int true "User ID"
+// @Produce json
+// @Success 200 {array} AccessToken "Access tokens"
+// @Failure 400 {object} apperrors.Error "Invalid user id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/tokens [get]
+func (h *Handler) GetAccessTokens(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetAccessTokensRequest](c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.Service.GetAccessTokens(c.Request().Context(), policy.ActorFromContext(c), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// DeleteAccessToken @Summary Delete personal access token
+// @Description Delete personal access token (softly deleting)
+// @ID delete-access-token
+// @Tags access tokens
+// @Param userId path int true "User ID"
+// @Param tokenId path int true "Token ID"
+// @Success 200 "Access token deleted"
+// @Failure 400 {object} apperrors.Error "Invalid user id or token id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Access token not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/tokens/{tokenId} [delete]
+func (h *Handler) DeleteAccessToken(c echo.Context) error {
+	req, err := echox.BindAndValidate[DeleteAccessTokenRequest](c)
+	if err != nil {
+		return err
+	}
+
+	return h.Service.DeleteAccessToken(c.Request().Context(), policy.ActorFromContext(c), req.UserID, req.TokenID)
+}
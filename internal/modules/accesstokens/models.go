@@ -0,0 +1,59 @@
+package accesstokens
+
+import "time"
+
+// TokenPrefix starts every personal access token, so they're easy to tell from JWTs and to spot in leaked secrets.
+const TokenPrefix = "mrp_"
+
+const (
+	GenresWriteScope  = "genres:write"
+	StarsWriteScope   = "stars:write"
+	MoviesWriteScope  = "movies:write"
+	ReviewsWriteScope = "reviews:write"
+	UsersWriteScope   = "users:write"
+)
+
+var Scopes = []string{GenresWriteScope, StarsWriteScope, MoviesWriteScope, ReviewsWriteScope, UsersWriteScope}
+
+type AccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// AccessTokenWithSecret is returned only once, when the token is created. Only the token hash is stored.
+type AccessTokenWithSecret struct {
+	*AccessToken
+	Token string `json:"token"`
+}
+
+// TokenOwner is the user a personal access token authenticates.
+type TokenOwner struct {
+	TokenID          int
+	UserID           int
+	Role             string
+	EmailVerified    bool
+	TwoFactorEnabled bool
+	Permissions      []string
+	Scopes           []string
+}
+
+type CreateAccessTokenRequest struct {
+	UserID    int        `json:"-" param:"userId" validate:"nonzero"`
+	Name      string     `json:"name" validate:"min=1,max=64"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type GetAccessTokensRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type DeleteAccessTokenRequest struct {
+	UserID  int `json:"-" param:"userId" validate:"nonzero"`
+	TokenID int `json:"-" param:"tokenId" validate:"nonzero"`
+}
@@ -0,0 +1,24 @@
+package accesstokens
+
+import (
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Module struct {
+	Handler    *Handler
+	Service    *Service
+	Repository *Repository
+}
+
+func NewModule(db *pgxpool.Pool, twoFactor config.TwoFactorConfig) *Module {
+	repo := NewRepository(db)
+	service := NewService(repo, twoFactor)
+	handler := NewHandler(service)
+
+	return &Module{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
@@ -0,0 +1,125 @@
+package accesstokens
+
+import (
+	"context"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) CreateAccessToken(ctx context.Context, token *AccessToken, hash string) error {
+	query, args, err := dbx.StatementBuilder.Insert("personal_access_tokens").
+		Columns("user_id", "name", "token_hash", "scopes", "expires_at").
+		Values(token.UserID, token.Name, hash, token.Scopes, token.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&token.ID, &token.CreatedAt); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetAccessTokensByUserID(ctx context.Context, userID int) ([]*AccessToken, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, user_id, name, scopes, created_at, expires_at, last_used_at").
+		From("personal_access_tokens").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*AccessToken, 0)
+	for rows.Next() {
+		var token AccessToken
+		if err = rows.Scan(&token.ID, &token.UserID, &token.Name, &token.Scopes, &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return tokens, nil
+}
+
+func (r *Repository) DeleteAccessToken(ctx context.Context, userID, tokenID int) error {
+	query, args, err := dbx.StatementBuilder.Update("personal_access_tokens").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": tokenID}).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if n.RowsAffected() == 0 {
+		return apperrors.NotFound("access token", "id", tokenID)
+	}
+
+	return nil
+}
+
+// UseAccessToken looks up an active token of an existing user by its hash and records it was used.
+// The owner's permissions are those of the current role.
+func (r *Repository) UseAccessToken(ctx context.Context, hash string) (*TokenOwner, error) {
+	query, args, err := dbx.StatementBuilder.Update("personal_access_tokens t").
+		Set("last_used_at", squirrel.Expr("NOW()")).
+		From("users u").
+		Where("u.id = t.user_id").
+		Where(squirrel.Eq{"t.token_hash": hash}).
+		Where(squirrel.Eq{"t.deleted_at": nil}).
+		Where("(t.expires_at IS NULL OR t.expires_at > NOW())").
+		Where(squirrel.Eq{"u.deleted_at": nil}).
+		Suffix("RETURNING t.id, t.user_id, t.scopes, u.role, u.email_verified_at IS NOT NULL, " +
+			"EXISTS (SELECT 1 FROM user_two_factor f WHERE f.user_id = u.id AND f.enabled_at IS NOT NULL), " +
+			"ARRAY(SELECT permission FROM role_permissions WHERE role = u.role)").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var owner TokenOwner
+	err = r.db.QueryRow(ctx, query, args...).Scan(&owner.TokenID, &owner.UserID, &owner.Scopes, &owner.Role, &owner.EmailVerified, &owner.TwoFactorEnabled, &owner.Permissions)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, errInvalidToken
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &owner, nil
+}
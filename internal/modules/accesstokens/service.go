@@ -0,0 +1,119 @@
+package accesstokens
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/opaquetoken"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
+)
+
+var errInvalidToken = apperrors.Forbidden("invalid token")
+
+type Service struct {
+	Repository *Repository
+	twoFactor  config.TwoFactorConfig
+}
+
+func NewService(repo *Repository, twoFactor config.TwoFactorConfig) *Service {
+	return &Service{
+		Repository: repo,
+		twoFactor:  twoFactor,
+	}
+}
+
+func (s *Service) CreateAccessToken(ctx context.Context, actor *policy.Actor, req *CreateAccessTokenRequest) (*AccessTokenWithSecret, error) {
+	if err := policy.ManageAccessTokens(actor, req.UserID); err != nil {
+		return nil, err
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, apperrors.BadRequest(fmt.Errorf("at least one scope is required"))
+	}
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return nil, apperrors.BadRequest(fmt.Errorf("unknown scope %q", scope))
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.BadRequest(fmt.Errorf("expiration time must be in the future"))
+	}
+
+	raw, hash, err := opaquetoken.Generate(TokenPrefix)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	token := &AccessToken{
+		UserID:    req.UserID,
+		Name:      req.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err = s.Repository.CreateAccessToken(ctx, token, hash); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("access token created", "user_id", token.UserID, "token_id", token.ID)
+	return &AccessTokenWithSecret{AccessToken: token, Token: raw}, nil
+}
+
+func (s *Service) GetAccessTokens(ctx context.Context, actor *policy.Actor, userID int) ([]*AccessToken, error) {
+	if err := policy.ManageAccessTokens(actor, userID); err != nil {
+		return nil, err
+	}
+
+	return s.Repository.GetAccessTokensByUserID(ctx, userID)
+}
+
+// DeleteAccessToken deletes the token only if it belongs to the user.
+func (s *Service) DeleteAccessToken(ctx context.Context, actor *policy.Actor, userID, tokenID int) error {
+	if err := policy.ManageAccessTokens(actor, userID); err != nil {
+		return err
+	}
+
+	if err := s.Repository.DeleteAccessToken(ctx, userID, tokenID); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("access token deleted", "user_id", userID, "token_id", tokenID)
+	return nil
+}
+
+// TokenPrefix implements jwt.TokenAuthenticator.
+func (s *Service) TokenPrefix() string {
+	return TokenPrefix
+}
+
+// Authenticate implements jwt.TokenAuthenticator. The claims carry the owner's current role, its permissions and the token scopes.
+// Like JWTs, they can't be used for the role's privileges if the role requires two-factor authentication the owner
+// hasn't enabled.
+func (s *Service) Authenticate(ctx context.Context, token string) (*jwt.AccessClaims, error) {
+	owner, err := s.Repository.UseAccessToken(ctx, opaquetoken.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &jwt.AccessClaims{
+		UserID:                 owner.UserID,
+		Role:                   owner.Role,
+		EmailVerified:          owner.EmailVerified,
+		Permissions:            owner.Permissions,
+		TwoFactorSetupRequired: slices.Contains(s.twoFactor.RequiredRoles, owner.Role) && !owner.TwoFactorEnabled,
+		Scopes:                 owner.Scopes,
+		PersonalAccessToken:    true,
+	}
+	claims.Subject = strconv.Itoa(owner.UserID)
+
+	return claims, nil
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// RefreshTokenCookie is sent only to the auth routes.
+	RefreshTokenCookie = "refresh_token"
+	// CSRFTokenCookie is readable by scripts, they send it back in CSRFTokenHeader (double-submit).
+	CSRFTokenCookie = "csrf_token"
+	CSRFTokenHeader = "X-CSRF-Token"
+
+	accessTokenCookiePath  = "/api"
+	refreshTokenCookiePath = "/api/auth"
+	csrfTokenLength        = 32
+)
+
+var (
+	errCookiesDisabled      = apperrors.BadRequest(errors.New("cookie sessions are disabled"))
+	errRefreshTokenRequired = apperrors.BadRequest(errors.New("refresh token is required"))
+	errInvalidCSRFToken     = apperrors.Forbidden("invalid CSRF token")
+)
+
+// SessionCookies writes the session cookies of browser clients.
+type SessionCookies struct {
+	cfg               config.CookieConfig
+	accessExpiration  time.Duration
+	refreshExpiration time.Duration
+}
+
+func NewSessionCookies(cfg config.CookieConfig, jwtService *jwt.Service) *SessionCookies {
+	return &SessionCookies{
+		cfg:               cfg,
+		accessExpiration:  jwtService.GetAccessExpiration(),
+		refreshExpiration: jwtService.GetRefreshExpiration(),
+	}
+}
+
+func (sc *SessionCookies) Enabled() bool {
+	return sc.cfg.Enabled
+}
+
+// SetSession puts the tokens into HttpOnly cookies along with a new CSRF token, which is returned
+// for clients that can't read cookies of the API domain.
+func (sc *SessionCookies) SetSession(c echo.Context, tokens *Tokens) (string, error) {
+	if !sc.cfg.Enabled {
+		return "", errCookiesDisabled
+	}
+
+	csrfToken, err := generateCSRFToken()
+	if err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	c.SetCookie(sc.newCookie(jwt.AccessTokenCookie, tokens.AccessToken, accessTokenCookiePath, sc.accessExpiration, true))
+	c.SetCookie(sc.newCookie(RefreshTokenCookie, tokens.RefreshToken, refreshTokenCookiePath, sc.refreshExpiration, true))
+	c.SetCookie(sc.newCookie(CSRFTokenCookie, csrfToken, "/", sc.refreshExpiration, false))
+
+	return csrfToken, nil
+}
+
+// ClearSession removes the session cookies.
+func (sc *SessionCookies) ClearSession(c echo.Context) {
+	c.SetCookie(sc.newCookie(jwt.AccessTokenCookie, "", accessTokenCookiePath, -1, true))
+	c.SetCookie(sc.newCookie(RefreshTokenCookie, "", refreshTokenCookiePath, -1, true))
+	c.SetCookie(sc.newCookie(CSRFTokenCookie, "", "/", -1, false))
+}
+
+func (sc *SessionCookies) newCookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   sc.cfg.Domain,
+		Secure:   sc.cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSite(sc.cfg.SameSite),
+		MaxAge:   int(maxAge.Seconds()),
+	}
+
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	}
+
+	return cookie
+}
+
+// CSRF checks the double-submitted CSRF token on mutating requests of cookie sessions: the CSRFTokenHeader
+// must match the CSRFTokenCookie. Requests with an Authorization header don't send credentials automatically,
+// so they are not checked.
+func CSRF(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(c)
+		}
+
+		if !isCookieSession(c) {
+			return next(c)
+		}
+
+		cookie, err := c.Cookie(CSRFTokenCookie)
+		header := c.Request().Header.Get(CSRFTokenHeader)
+		if err != nil || header == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			return errInvalidCSRFToken
+		}
+
+		return next(c)
+	}
+}
+
+// isCookieSession reports whether the request is authenticated with the session cookies.
+func isCookieSession(c echo.Context) bool {
+	if c.Request().Header.Get("Authorization") != "" {
+		return false
+	}
+
+	return hasCookie(c, jwt.AccessTokenCookie) || hasCookie(c, RefreshTokenCookie)
+}
+
+func hasCookie(c echo.Context, name string) bool {
+	cookie, err := c.Cookie(name)
+	return err == nil && cookie.Value != ""
+}
+
+func generateCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
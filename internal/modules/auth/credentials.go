@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+	"github.com/DavidMovas/Movies-Reviews/internal/opaquetoken"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
+	"github.com/google/uuid"
+)
+
+const emailChangePurpose = "email_change"
+
+var (
+	errSameEmail               = apperrors.BadRequest(errors.New("new email is the same as the current one"))
+	errCurrentPasswordRequired = apperrors.BadRequest(errors.New("current password is required"))
+)
+
+// ChangePassword sets a new password after checking the current one. All sessions and personal access tokens
+// of the user are revoked, the caller gets a new pair of tokens for the device.
+func (s *Service) ChangePassword(ctx context.Context, userID int, attempt *CredentialsChange, newPassword string) (*Tokens, error) {
+	user, err := s.checkCurrentPassword(ctx, userID, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	passHash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	if err = s.repo.ChangePassword(ctx, userID, passHash); err != nil {
+		return nil, err
+	}
+
+	if err = s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	tokens, err := s.issueTokens(ctx, user.User, uuid.New().String(), attempt.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("password changed", "user_id", userID)
+	return tokens, nil
+}
+
+// RequestEmailChange checks the current password and emails a confirmation token to the new address.
+// The email changes only once ConfirmEmailChange is called with the token.
+func (s *Service) RequestEmailChange(ctx context.Context, userID int, attempt *CredentialsChange, newEmail string) error {
+	user, err := s.checkCurrentPassword(ctx, userID, attempt)
+	if err != nil {
+		return err
+	}
+
+	if newEmail == user.Email {
+		return errSameEmail
+	}
+
+	_, err = s.usersService.GetExistingUserByEmail(ctx, newEmail)
+	switch {
+	case err == nil:
+		return apperrors.AlreadyExists("user", "email", newEmail)
+	case !apperrors.Is(err, apperrors.NotFoundCode):
+		return err
+	}
+
+	raw, hash, err := opaquetoken.Generate("")
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	token := &OneTimeToken{
+		UserID:    userID,
+		Purpose:   emailChangePurpose,
+		TokenHash: hash,
+		Email:     &newEmail,
+	}
+	if err = s.repo.CreateOneTimeToken(ctx, token, s.cfg.EmailVerificationExpiration); err != nil {
+		return err
+	}
+
+	msg := emailChangeMessage(user.User, newEmail, raw, s.cfg.EmailVerificationExpiration, s.cfg.EmailChangeURL)
+	if err = s.mailer.Send(ctx, msg); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	log.FromContext(ctx).Info("email change requested", "user_id", userID)
+	return nil
+}
+
+// ConfirmEmailChange sets the new email, verified by the emailed token, and signs the user out everywhere.
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) error {
+	userID, err := s.repo.ChangeEmail(ctx, opaquetoken.Hash(token))
+	if err != nil {
+		return err
+	}
+
+	if err = s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	log.FromContext(ctx).Info("user email changed", "user_id", userID)
+	return nil
+}
+
+// AnonymizeUser deletes the user for good. Users deleting their own account have to give the current password,
+// so a stolen session alone can't destroy it. User managers deleting other accounts don't.
+func (s *Service) AnonymizeUser(ctx context.Context, actor *policy.Actor, userID int, attempt *CredentialsChange) error {
+	if actor != nil && actor.UserID == userID {
+		if attempt.Password == "" {
+			return errCurrentPasswordRequired
+		}
+
+		if _, err := s.checkCurrentPassword(ctx, userID, attempt); err != nil {
+			return err
+		}
+	}
+
+	return s.usersService.AnonymizeUser(ctx, actor, userID)
+}
+
+// checkCurrentPassword returns the user if the password is correct. Wrong passwords count as failed logins,
+// so a stolen session can't be used to guess the password.
+func (s *Service) checkCurrentPassword(ctx context.Context, userID int, attempt *CredentialsChange) (*users.UserWithPassword, error) {
+	if err := s.checkLoginLockouts(ctx, userID, attempt.IP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.usersService.GetExistingUserWithPasswordByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, _, err := s.hasher.Verify(attempt.Password, user.PasswordHash)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	if !ok {
+		return nil, s.loginFailed(ctx, user.User, attempt.IP)
+	}
+
+	if _, err = s.repo.ClearLoginFailures(ctx, userLockoutScope, strconv.Itoa(userID)); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// checkLoginLockouts rejects the attempt if the IP or the account is locked after failed logins.
+func (s *Service) checkLoginLockouts(ctx context.Context, userID int, ip string) error {
+	if err := s.checkLoginLockout(ctx, ipLockoutScope, ip); err != nil {
+		return err
+	}
+
+	return s.checkLoginLockout(ctx, userLockoutScope, strconv.Itoa(userID))
+}
@@ -1,35 +1,42 @@
 package auth
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
 	"github.com/labstack/echo/v4"
 )
 
 type Handler struct {
 	authService *Service
 	userService *users.Service
+	cookies     *SessionCookies
 }
 
-func NewHandler(authService *Service, userService *users.Service) *Handler {
+func NewHandler(authService *Service, userService *users.Service, cookies *SessionCookies) *Handler {
 	return &Handler{
 		authService: authService,
 		userService: userService,
+		cookies:     cookies,
 	}
 }
 
 // Register @Summary Register a new user
-// @Description Register a new user
+// @Description Register a new user. With an invitation code the user gets the role of the invitation
 // @ID register
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body RegisterUserRequest true "User"
-// @Success 201 {object} contracts.User "User"
-// @Failure 400 {object} apperrors.Error "Invalid email or password"
+// @Success 201 {object} contracts.User "User, a verification token is emailed to them"
+// @Failure 400 {object} apperrors.Error "Invalid email or password, invalid or expired invitation"
+// @Failure 403 {object} apperrors.Error "Registration is invite-only"
 // @Failure 500 {object} apperrors.Error "Internal server error"
 // @Router /auth/register [post]
 func (h *Handler) Register(c echo.Context) error {
@@ -45,7 +52,7 @@ func (h *Handler) Register(c echo.Context) error {
 		AvatarURL: users.DefaultAvatarURL,
 	}
 
-	if err = h.authService.Register(c.Request().Context(), user, req.Password); err != nil {
+	if err = h.authService.Register(c.Request().Context(), user, req.Password, req.InvitationCode); err != nil {
 		return err
 	}
 
@@ -53,15 +60,18 @@ func (h *Handler) Register(c echo.Context) error {
 }
 
 // Login @Summary Login a user
-// @Description Login a user and return an access token
+// @Description Login a user and return an access token and a refresh token bound to the device.
+// @Description With use_cookies the tokens are set in HttpOnly cookies and a CSRF token is returned instead
 // @ID login
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body LoginUserRequest true "User"
-// @Success 200 {object} LoginUserResponse "Access token"
+// @Success 200 {object} LoginUserResponse "Access and refresh tokens"
 // @Failure 400 {object} apperrors.Error "Invalid email or password"
-// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 401 {object} apperrors.Error "Invalid credentials"
+// @Failure 403 {object} apperrors.Error "Email is not verified (if unverified users can not log in)"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
 // @Failure 500 {object} apperrors.Error "Internal server error"
 // @Router /auth/login [post]
 func (h *Handler) Login(c echo.Context) error {
@@ -70,10 +80,765 @@ func (h *Handler) Login(c echo.Context) error {
 		return err
 	}
 
-	user, token, err := h.authService.Login(c.Request().Context(), req.Email, req.Username, req.Password)
+	if req.UseCookies && !h.cookies.Enabled() {
+		return errCookiesDisabled
+	}
+
+	attempt := &LoginAttempt{
+		Email:    req.Email,
+		Username: req.Username,
+		Password: req.Password,
+		Device:   c.Request().UserAgent(),
+		IP:       c.RealIP(),
+	}
+	if req.Device != nil {
+		attempt.Device = *req.Device
+	}
+
+	res, err := h.authService.Login(c.Request().Context(), attempt)
+	if err != nil {
+		return err
+	}
+
+	if res.Tokens == nil {
+		return c.JSON(http.StatusOK, newLoginUserResponse(res))
+	}
+
+	tokens, err := h.newTokensResponse(c, res.Tokens, req.UseCookies)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, LoginUserResponse{User: *res.User, AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken, CSRFToken: tokens.CSRFToken})
+}
+
+// StartOIDCLogin @Summary Login with an identity provider
+// @Description Redirect to the login page of an OpenID Connect identity provider (authorization code flow with PKCE)
+// @ID start-oidc-login
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} apperrors.Error "Identity provider not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *Handler) StartOIDCLogin(c echo.Context) error {
+	req, err := echox.BindAndValidate[OIDCLoginRequest](c)
+	if err != nil {
+		return err
+	}
+
+	url, err := h.authService.StartOIDCLogin(c.Request().Context(), req.Provider)
+	if err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, url)
+}
+
+// FinishOIDCLogin @Summary Finish a login with an identity provider
+// @Description Exchange the authorization code the identity provider redirected back with for access and refresh tokens.
+// @Description The external account is linked to the user with the same verified email, or a new user is created
+// @ID finish-oidc-login
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} LoginUserResponse "Access and refresh tokens, or a two-factor token"
+// @Failure 400 {object} apperrors.Error "Invalid or expired state, or the identity provider returned an error"
+// @Failure 401 {object} apperrors.Error "Identity provider login failed"
+// @Failure 403 {object} apperrors.Error "Email missing or not verified by the identity provider"
+// @Failure 404 {object} apperrors.Error "Identity provider not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *Handler) FinishOIDCLogin(c echo.Context) error {
+	req, err := echox.BindAndValidate[OIDCCallbackRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if req.Error != "" {
+		return apperrors.BadRequest(fmt.Errorf("identity provider error: %s %s", req.Error, req.ErrorDescription))
+	}
+
+	device := c.Request().UserAgent()
+	if req.Device != nil {
+		device = *req.Device
+	}
+
+	res, err := h.authService.FinishOIDCLogin(c.Request().Context(), req.Provider, req.Code, req.State, device)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, newLoginUserResponse(res))
+}
+
+// LoginTwoFactor @Summary Finish a login with a two-factor code
+// @Description Exchange the two-factor token returned by the login and a code from the authenticator app
+// @Description (or a recovery code) for an access token and a refresh token
+// @ID login-two-factor
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginTwoFactorRequest true "Two-factor token and code"
+// @Success 200 {object} LoginUserResponse "Access and refresh tokens"
+// @Failure 400 {object} apperrors.Error "Invalid or expired two-factor token"
+// @Failure 401 {object} apperrors.Error "Invalid two-factor code"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/login/2fa [post]
+func (h *Handler) LoginTwoFactor(c echo.Context) error {
+	req, err := echox.BindAndValidate[LoginTwoFactorRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if req.UseCookies && !h.cookies.Enabled() {
+		return errCookiesDisabled
+	}
+
+	device := c.Request().UserAgent()
+	if req.Device != nil {
+		device = *req.Device
+	}
+
+	user, tokens, err := h.authService.LoginTwoFactor(c.Request().Context(), req.TwoFactorToken, req.Code, device, c.RealIP())
+	if err != nil {
+		return err
+	}
+
+	res, err := h.newTokensResponse(c, tokens, req.UseCookies)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, LoginUserResponse{User: *user, AccessToken: res.AccessToken, RefreshToken: res.RefreshToken, CSRFToken: res.CSRFToken})
+}
+
+// Refresh @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token (rotation).
+// @Description Reusing an already exchanged refresh token revokes all tokens issued from the same login.
+// @Description Without a refresh token in the body the session cookie is used and the new tokens are set in cookies
+// @ID refresh
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} RefreshTokenResponse "Access and refresh tokens"
+// @Failure 400 {object} apperrors.Error "Invalid request"
+// @Failure 401 {object} apperrors.Error "Invalid, expired or reused refresh token"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/refresh [post]
+func (h *Handler) Refresh(c echo.Context) error {
+	req, err := echox.BindAndValidate[RefreshTokenRequest](c)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, useCookies := req.RefreshToken, false
+	if refreshToken == "" {
+		cookie, cookieErr := c.Cookie(RefreshTokenCookie)
+		if cookieErr != nil || cookie.Value == "" {
+			return errRefreshTokenRequired
+		}
+		refreshToken, useCookies = cookie.Value, true
+	}
+
+	tokens, err := h.authService.Refresh(c.Request().Context(), refreshToken)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.newTokensResponse(c, tokens, useCookies)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// Logout @Summary Logout
+// @Description Revoke the access token of the request and, if given, the refresh token of the same session.
+// @Description Cookie sessions revoke the refresh token of the cookie and clear the cookies
+// @ID logout
+// @Tags auth
+// @Accept json
+// @Param request body LogoutRequest false "Refresh token"
+// @Success 200 "Logged out"
+// @Failure 401 {object} apperrors.Error "Invalid refresh token"
+// @Failure 403 {object} apperrors.Error "Not authenticated"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/logout [post]
+func (h *Handler) Logout(c echo.Context) error {
+	req, err := echox.BindAndValidate[LogoutRequest](c)
+	if err != nil {
+		return err
+	}
+
+	cookieSession := isCookieSession(c)
+	if req.RefreshToken == nil && cookieSession {
+		if cookie, cookieErr := c.Cookie(RefreshTokenCookie); cookieErr == nil && cookie.Value != "" {
+			req.RefreshToken = &cookie.Value
+		}
+	}
+
+	if err = h.authService.Logout(c.Request().Context(), jwt.GetClaims(c), req.RefreshToken); err != nil {
+		return err
+	}
+
+	if cookieSession {
+		h.cookies.ClearSession(c)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// RevokeUserSessions @Summary Revoke all sessions of a user
+// @Description Revoke all refresh tokens and all access tokens issued to the user
+// @ID revoke-user-sessions
+// @Tags auth
+// @Param userId path int true "User ID"
+// @Success 200 "Sessions revoked"
+// @Failure 400 {object} apperrors.Error "Invalid user id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/sessions [delete]
+func (h *Handler) RevokeUserSessions(c echo.Context) error {
+	req, err := echox.BindAndValidate[RevokeUserSessionsRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.RevokeUserSessions(c.Request().Context(), req.UserID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// AnonymizeUser @Summary Anonymize user by id
+// @Description Delete the user for good: the email, username, bio and avatar are scrubbed and all credentials are deleted.
+// @Description The reviews stay, attributed to an anonymous placeholder, and stop counting in movie ratings.
+// @Description Users deleting their own account have to give the current password
+// @ID anonymize-user
+// @Tags users
+// @Accept json
+// @Param userId path int true "User ID"
+// @Param request body AnonymizeUserRequest false "Current password, required to delete your own account"
+// @Success 200 "User anonymized"
+// @Failure 400 {object} apperrors.Error "Invalid user id or missing current password"
+// @Failure 401 {object} apperrors.Error "Invalid credentials"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/anonymize [post]
+func (h *Handler) AnonymizeUser(c echo.Context) error {
+	req, err := echox.BindAndValidate[AnonymizeUserRequest](c)
+	if err != nil {
+		return err
+	}
+
+	attempt := &CredentialsChange{
+		Password: req.CurrentPassword,
+		IP:       c.RealIP(),
+	}
+
+	if err = h.authService.AnonymizeUser(c.Request().Context(), policy.ActorFromContext(c), req.UserID, attempt); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ImpersonateUser @Summary Impersonate a user
+// @Description Issue a short-lived access token of the user with the caller in the act claim, e.g. to see what the user sees.
+// @Description The token can't be refreshed nor used to change credentials. The user must not have permissions the caller lacks
+// @ID impersonate-user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Param request body ImpersonateUserRequest true "Reason"
+// @Success 200 {object} ImpersonateUserResponse "Access token of the user"
+// @Failure 400 {object} apperrors.Error "Invalid reason or impersonating yourself"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/impersonate [post]
+func (h *Handler) ImpersonateUser(c echo.Context) error {
+	req, err := echox.BindAndValidate[ImpersonateUserRequest](c)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, LoginUserResponse{AccessToken: token, User: *user.User})
+	res, err := h.authService.Impersonate(c.Request().Context(), jwt.GetClaims(c), req.UserID, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ImpersonateUserResponse{
+		User:        *res.User,
+		AccessToken: res.AccessToken,
+		ExpiresAt:   res.Impersonation.ExpiresAt,
+	})
+}
+
+// GetImpersonations @Summary Get impersonations of a user
+// @Description Get who impersonated the user, when and why, the latest first
+// @ID get-impersonations
+// @Tags auth
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {array} Impersonation "Impersonations"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/impersonations [get]
+func (h *Handler) GetImpersonations(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetImpersonationsRequest](c)
+	if err != nil {
+		return err
+	}
+
+	impersonations, err := h.authService.GetImpersonations(c.Request().Context(), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, impersonations)
+}
+
+// CreateInvitation @Summary Create an invitation
+// @Description Create an invitation code for a role (user by default), usable max_uses times (1 by default)
+// @Description until expires_at (no expiry by default). The code is returned only once
+// @ID create-invitation
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body CreateInvitationRequest true "Invitation"
+// @Success 201 {object} CreateInvitationResponse "Invitation with the code"
+// @Failure 400 {object} apperrors.Error "Invalid request"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions, also for roles with permissions the creator lacks"
+// @Failure 404 {object} apperrors.Error "Role not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /invitations [post]
+func (h *Handler) CreateInvitation(c echo.Context) error {
+	req, err := echox.BindAndValidate[CreateInvitationRequest](c)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := h.authService.CreateInvitation(c.Request().Context(), jwt.GetClaims(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, invitation)
+}
+
+// GetInvitations @Summary Get invitations
+// @Description Get all invitations, the latest first
+// @ID get-invitations
+// @Tags auth
+// @Produce json
+// @Success 200 {array} Invitation "Invitations"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /invitations [get]
+func (h *Handler) GetInvitations(c echo.Context) error {
+	invitations, err := h.authService.GetInvitations(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation @Summary Revoke an invitation
+// @Description Make the invitation code unusable
+// @ID revoke-invitation
+// @Tags auth
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 "Invitation revoked"
+// @Failure 400 {object} apperrors.Error "Invalid invitation id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Invitation not found or already revoked"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /invitations/{invitationId} [delete]
+func (h *Handler) RevokeInvitation(c echo.Context) error {
+	req, err := echox.BindAndValidate[RevokeInvitationRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.RevokeInvitation(c.Request().Context(), req.InvitationID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ForgotPassword @Summary Request a password reset
+// @Description Email a single-use password reset token to the user.
+// @Description Responds with success whether or not the email is registered
+// @ID forgot-password
+// @Tags auth
+// @Accept json
+// @Param request body ForgotPasswordRequest true "Email"
+// @Success 200 "Reset token sent if the email is registered"
+// @Failure 400 {object} apperrors.Error "Invalid email"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	req, err := echox.BindAndValidate[ForgotPasswordRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.ForgotPassword(c.Request().Context(), req.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ResetPassword @Summary Reset password
+// @Description Set a new password with a reset token. All sessions and personal access tokens of the user are revoked
+// @ID reset-password
+// @Tags auth
+// @Accept json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 "Password reset"
+// @Failure 400 {object} apperrors.Error "Invalid password, invalid or expired token"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *Handler) ResetPassword(c echo.Context) error {
+	req, err := echox.BindAndValidate[ResetPasswordRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.ResetPassword(c.Request().Context(), req.Token, req.Password); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ChangePassword @Summary Change password
+// @Description Set a new password, requires the current one. All sessions and personal access tokens of the user
+// @Description are revoked, new tokens are issued for the device (in cookies for cookie sessions)
+// @ID change-password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} RefreshTokenResponse "Access and refresh tokens"
+// @Failure 400 {object} apperrors.Error "Invalid password"
+// @Failure 401 {object} apperrors.Error "Invalid credentials"
+// @Failure 403 {object} apperrors.Error "Not authenticated or personal access token used"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/password/change [post]
+func (h *Handler) ChangePassword(c echo.Context) error {
+	req, err := echox.BindAndValidate[ChangePasswordRequest](c)
+	if err != nil {
+		return err
+	}
+
+	attempt := &CredentialsChange{
+		Password: req.CurrentPassword,
+		Device:   c.Request().UserAgent(),
+		IP:       c.RealIP(),
+	}
+	if req.Device != nil {
+		attempt.Device = *req.Device
+	}
+
+	tokens, err := h.authService.ChangePassword(c.Request().Context(), jwt.GetClaims(c).UserID, attempt, req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.newTokensResponse(c, tokens, isCookieSession(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// ChangeEmail @Summary Change email
+// @Description Email a confirmation token to the new address, requires the current password.
+// @Description The email changes once the token is confirmed
+// @ID change-email
+// @Tags auth
+// @Accept json
+// @Param request body ChangeEmailRequest true "Current password and new email"
+// @Success 200 "Confirmation token sent to the new email"
+// @Failure 400 {object} apperrors.Error "Invalid email or the same as the current one"
+// @Failure 401 {object} apperrors.Error "Invalid credentials"
+// @Failure 403 {object} apperrors.Error "Not authenticated or personal access token used"
+// @Failure 409 {object} apperrors.Error "Email already in use"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/email/change [post]
+func (h *Handler) ChangeEmail(c echo.Context) error {
+	req, err := echox.BindAndValidate[ChangeEmailRequest](c)
+	if err != nil {
+		return err
+	}
+
+	attempt := &CredentialsChange{
+		Password: req.CurrentPassword,
+		IP:       c.RealIP(),
+	}
+
+	if err = h.authService.RequestEmailChange(c.Request().Context(), jwt.GetClaims(c).UserID, attempt, req.NewEmail); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ConfirmEmailChange @Summary Confirm email change
+// @Description Set the new email with the token emailed to it. All sessions and personal access tokens of the user are revoked
+// @ID confirm-email-change
+// @Tags auth
+// @Accept json
+// @Param request body ConfirmEmailChangeRequest true "Confirmation token"
+// @Success 200 "Email changed"
+// @Failure 400 {object} apperrors.Error "Invalid or expired token"
+// @Failure 409 {object} apperrors.Error "Email already in use"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/email/confirm [post]
+func (h *Handler) ConfirmEmailChange(c echo.Context) error {
+	req, err := echox.BindAndValidate[ConfirmEmailChangeRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.ConfirmEmailChange(c.Request().Context(), req.Token); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// VerifyEmail @Summary Verify email
+// @Description Mark the email of the user as verified with an emailed verification token.
+// @Description Access tokens issued before have to be refreshed to pick up the change
+// @ID verify-email
+// @Tags auth
+// @Accept json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 "Email verified"
+// @Failure 400 {object} apperrors.Error "Invalid or expired token"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/verify [post]
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	req, err := echox.BindAndValidate[VerifyEmailRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.VerifyEmail(c.Request().Context(), req.Token); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ResendEmailVerification @Summary Resend email verification
+// @Description Email a new verification token. Responds with success whether or not the email is registered
+// @ID resend-email-verification
+// @Tags auth
+// @Accept json
+// @Param request body ResendEmailVerificationRequest true "Email"
+// @Success 200 "Verification token sent if the email is registered and not verified yet"
+// @Failure 400 {object} apperrors.Error "Invalid email"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/verify/resend [post]
+func (h *Handler) ResendEmailVerification(c echo.Context) error {
+	req, err := echox.BindAndValidate[ResendEmailVerificationRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.ResendEmailVerification(c.Request().Context(), req.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// GetLockouts @Summary Get login lockouts
+// @Description Get accounts (scope "user", subject is the user id) and IPs (scope "ip") with a locked login
+// @ID get-lockouts
+// @Tags auth
+// @Produce json
+// @Success 200 {array} Lockout "Lockouts"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/lockouts [get]
+func (h *Handler) GetLockouts(c echo.Context) error {
+	lockouts, err := h.authService.GetLockouts(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, lockouts)
+}
+
+// DeleteLockout @Summary Unlock login
+// @Description Unlock the login of an account or an IP and reset its failed attempts
+// @ID delete-lockout
+// @Tags auth
+// @Param scope path string true "Scope: user or ip"
+// @Param subject path string true "User id or IP"
+// @Success 200 "Login unlocked"
+// @Failure 400 {object} apperrors.Error "Invalid scope"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Lockout not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/lockouts/{scope}/{subject} [delete]
+func (h *Handler) DeleteLockout(c echo.Context) error {
+	req, err := echox.BindAndValidate[DeleteLockoutRequest](c)
+	if err != nil {
+		return err
+	}
+
+	if err = h.authService.DeleteLockout(c.Request().Context(), req.Scope, req.Subject); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// EnrollTwoFactor @Summary Start two-factor enrolment
+// @Description Generate a TOTP secret and its otpauth:// URI to show as a QR code. Enrolling again before
+// @Description the confirmation replaces the secret
+// @ID enroll-two-factor
+// @Tags auth
+// @Produce json
+// @Success 200 {object} EnrollTwoFactorResponse "Secret and provisioning URI"
+// @Failure 400 {object} apperrors.Error "Two-factor authentication is already enabled"
+// @Failure 403 {object} apperrors.Error "Not authenticated"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(c echo.Context) error {
+	res, err := h.authService.EnrollTwoFactor(c.Request().Context(), jwt.GetClaims(c).UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// ConfirmTwoFactor @Summary Confirm two-factor enrolment
+// @Description Enable two-factor authentication with a code from the authenticator app. Returns recovery codes,
+// @Description they are shown only once
+// @ID confirm-two-factor
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ConfirmTwoFactorRequest true "Code"
+// @Success 200 {object} RecoveryCodesResponse "Recovery codes"
+// @Failure 400 {object} apperrors.Error "Enrolment not started or already confirmed"
+// @Failure 401 {object} apperrors.Error "Invalid two-factor code"
+// @Failure 403 {object} apperrors.Error "Not authenticated"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(c echo.Context) error {
+	req, err := echox.BindAndValidate[ConfirmTwoFactorRequest](c)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.authService.ConfirmTwoFactor(c.Request().Context(), jwt.GetClaims(c).UserID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication with the password and a code (or a recovery code)
+// @ID disable-two-factor
+// @Tags auth
+// @Accept json
+// @Param request body DisableTwoFactorRequest true "Password and code"
+// @Success 200 "Two-factor authentication disabled"
+// @Failure 400 {object} apperrors.Error "Two-factor authentication is not enabled"
+// @Failure 401 {object} apperrors.Error "Invalid credentials or two-factor code"
+// @Failure 403 {object} apperrors.Error "Not authenticated or two-factor authentication is required for the role"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	req, err := echox.BindAndValidate[DisableTwoFactorRequest](c)
+	if err != nil {
+		return err
+	}
+
+	attempt := &CredentialsChange{
+		Password: req.Password,
+		IP:       c.RealIP(),
+	}
+
+	return h.authService.DisableTwoFactor(c.Request().Context(), jwt.GetClaims(c).UserID, attempt, req.Code)
+}
+
+// RegenerateRecoveryCodes @Summary Regenerate recovery codes
+// @Description Replace all recovery codes, requires a code (or a recovery code)
+// @ID regenerate-recovery-codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RegenerateRecoveryCodesRequest true "Code"
+// @Success 200 {object} RecoveryCodesResponse "Recovery codes"
+// @Failure 400 {object} apperrors.Error "Two-factor authentication is not enabled"
+// @Failure 401 {object} apperrors.Error "Invalid two-factor code"
+// @Failure 403 {object} apperrors.Error "Not authenticated"
+// @Failure 429 {object} apperrors.Error "Too many failed login attempts, see Retry-After header"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /auth/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c echo.Context) error {
+	req, err := echox.BindAndValidate[RegenerateRecoveryCodesRequest](c)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request().Context(), jwt.GetClaims(c).UserID, req.Code, c.RealIP())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// newTokensResponse returns the tokens, or sets them in the session cookies and returns the CSRF token.
+func (h *Handler) newTokensResponse(c echo.Context, tokens *Tokens, useCookies bool) (RefreshTokenResponse, error) {
+	if !useCookies {
+		return RefreshTokenResponse{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+	}
+
+	csrfToken, err := h.cookies.SetSession(c, tokens)
+	if err != nil {
+		return RefreshTokenResponse{}, err
+	}
+
+	return RefreshTokenResponse{CSRFToken: csrfToken}, nil
+}
+
+func newLoginUserResponse(res *LoginResult) LoginUserResponse {
+	if res.Tokens == nil {
+		return LoginUserResponse{User: *res.User, TwoFactorRequired: true, TwoFactorToken: res.TwoFactorToken}
+	}
+
+	return LoginUserResponse{AccessToken: res.Tokens.AccessToken, RefreshToken: res.Tokens.RefreshToken, User: *res.User}
 }
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+)
+
+var errImpersonateSelf = apperrors.BadRequest(errors.New("can't impersonate yourself"))
+
+// Impersonate issues a short-lived access token of the user to the actor, e.g. for support staff to see what
+// the user sees. The token carries the actor in the act claim and can't be refreshed. The user must not have
+// permissions the actor lacks. Every impersonation is recorded.
+func (s *Service) Impersonate(ctx context.Context, actor *jwt.AccessClaims, userID int, reason string) (*ImpersonationResult, error) {
+	if userID == actor.UserID {
+		return nil, errImpersonateSelf
+	}
+
+	user, err := s.usersService.GetExistingUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenUser, err := s.newTokenUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range tokenUser.Permissions {
+		if !slices.Contains(actor.Permissions, permission) {
+			return nil, errForbidden
+		}
+	}
+
+	tokenUser.Act = &jwt.ActClaim{
+		Subject: strconv.Itoa(actor.UserID),
+		UserID:  actor.UserID,
+		Role:    actor.Role,
+	}
+	tokenUser.Expiration = s.cfg.ImpersonationExpiration
+
+	accessToken, err := s.jwtService.GenerateToken(*tokenUser)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	impersonation := &Impersonation{
+		ActorID: actor.UserID,
+		UserID:  user.ID,
+		Reason:  reason,
+	}
+	if err = s.repo.CreateImpersonation(ctx, impersonation, s.cfg.ImpersonationExpiration); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Warn("user impersonation started", "user_id", user.ID, "actor_id", actor.UserID, "reason", reason)
+	return &ImpersonationResult{
+		User:          user,
+		AccessToken:   accessToken,
+		Impersonation: impersonation,
+	}, nil
+}
+
+// GetImpersonations returns the impersonations of the user, the latest first.
+func (s *Service) GetImpersonations(ctx context.Context, userID int) ([]*Impersonation, error) {
+	if _, err := s.usersService.GetExistingUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetImpersonationsByUserID(ctx, userID)
+}
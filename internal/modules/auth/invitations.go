@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+	"github.com/DavidMovas/Movies-Reviews/internal/opaquetoken"
+)
+
+const defaultInvitationMaxUses = 1
+
+var (
+	errInvitationRequired  = apperrors.Forbidden("registration requires an invitation")
+	errInvalidInvitation   = apperrors.BadRequest(errors.New("invalid or expired invitation"))
+	errInvitationExpiresAt = apperrors.BadRequest(errors.New("invitation must expire in the future"))
+)
+
+// CreateInvitation creates an invitation code for the role. The creator must have every permission of the role,
+// so nobody can invite users more privileged than themselves.
+func (s *Service) CreateInvitation(ctx context.Context, creator *jwt.AccessClaims, req *CreateInvitationRequest) (*CreateInvitationResponse, error) {
+	invitation := &Invitation{
+		Role:      req.Role,
+		MaxUses:   req.MaxUses,
+		CreatedBy: creator.UserID,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if invitation.Role == "" {
+		invitation.Role = users.UserRole
+	}
+	if invitation.MaxUses == 0 {
+		invitation.MaxUses = defaultInvitationMaxUses
+	}
+
+	if invitation.ExpiresAt != nil && !invitation.ExpiresAt.After(time.Now()) {
+		return nil, errInvitationExpiresAt
+	}
+
+	role, err := s.rolesService.GetRoleByName(ctx, invitation.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range role.Permissions {
+		if !slices.Contains(creator.Permissions, permission) {
+			return nil, errForbidden
+		}
+	}
+
+	code, hash, err := opaquetoken.Generate("")
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	invitation.CodeHash = hash
+
+	if err = s.repo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("invitation created", "invitation_id", invitation.ID, "role", invitation.Role)
+	return &CreateInvitationResponse{Invitation: invitation, Code: code}, nil
+}
+
+// GetInvitations returns all invitations, the latest first.
+func (s *Service) GetInvitations(ctx context.Context) ([]*Invitation, error) {
+	return s.repo.GetInvitations(ctx)
+}
+
+func (s *Service) RevokeInvitation(ctx context.Context, invitationID int) error {
+	if err := s.repo.RevokeInvitation(ctx, invitationID); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("invitation revoked", "invitation_id", invitationID)
+	return nil
+}
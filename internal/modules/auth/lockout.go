@@ -0,0 +1,24 @@
+package auth
+
+import "time"
+
+const (
+	userLockoutScope = "user"
+	ipLockoutScope   = "ip"
+)
+
+// lockoutDuration returns for how long a login has to be locked after the given number of consecutive failures.
+// The first lock happens on reaching the threshold and every next failure doubles it, up to maxDuration.
+// A non-positive threshold disables locking.
+func lockoutDuration(failures, threshold int, baseDuration, maxDuration time.Duration) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+
+	duration := baseDuration
+	for i := threshold; i < failures && duration < maxDuration; i++ {
+		duration *= 2
+	}
+
+	return min(duration, maxDuration)
+}
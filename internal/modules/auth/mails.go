@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/mail"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+)
+
+func passwordResetMessage(user *users.User, token string, ttl time.Duration, resetURL string) *mail.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello, %s!\n\n", user.Username)
+	fmt.Fprintf(&b, "Somebody requested a password reset for your account. The token expires in %s.\n\n", ttl)
+	if resetURL != "" {
+		fmt.Fprintf(&b, "Reset your password: %s?token=%s\n\n", resetURL, url.QueryEscape(token))
+	}
+	fmt.Fprintf(&b, "Reset token: %s\n\n", token)
+	b.WriteString("If it wasn't you, just ignore this email, your password stays the same.\n")
+
+	return &mail.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body:    b.String(),
+	}
+}
+
+func emailVerificationMessage(user *users.User, token string, ttl time.Duration, verificationURL string) *mail.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello, %s!\n\n", user.Username)
+	fmt.Fprintf(&b, "Please confirm this is your email address. The token expires in %s.\n\n", ttl)
+	if verificationURL != "" {
+		fmt.Fprintf(&b, "Verify your email: %s?token=%s\n\n", verificationURL, url.QueryEscape(token))
+	}
+	fmt.Fprintf(&b, "Verification token: %s\n\n", token)
+	b.WriteString("If you didn't register, just ignore this email.\n")
+
+	return &mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body:    b.String(),
+	}
+}
+
+func emailChangeMessage(user *users.User, newEmail, token string, ttl time.Duration, changeURL string) *mail.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hello, %s!\n\n", user.Username)
+	fmt.Fprintf(&b, "Please confirm you want to use this email address for your account. The token expires in %s.\n\n", ttl)
+	if changeURL != "" {
+		fmt.Fprintf(&b, "Confirm your new email: %s?token=%s\n\n", changeURL, url.QueryEscape(token))
+	}
+	fmt.Fprintf(&b, "Confirmation token: %s\n\n", token)
+	b.WriteString("If you didn't ask for it, just ignore this email, the account keeps its current address.\n")
+
+	return &mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Body:    b.String(),
+	}
+}
@@ -3,30 +3,65 @@ package auth
 import (
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
 	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
-	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
 	"github.com/labstack/echo/v4"
 )
 
-var errForbidden = apperrors.Forbidden("insufficient permissions")
+var (
+	errForbidden         = apperrors.Forbidden("insufficient permissions")
+	errInsufficientScope = apperrors.Forbidden("insufficient token scope")
+	errSessionRequired   = apperrors.Forbidden("not allowed with a personal access token")
+	errTwoFactorSetup    = apperrors.Forbidden("two-factor authentication must be enabled first")
+	errImpersonated      = apperrors.Forbidden("not allowed while impersonating a user")
+)
+
+func Authenticated(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if jwt.GetClaims(c) == nil {
+			return errForbidden
+		}
+
+		return next(c)
+	}
+}
 
-func Self(next echo.HandlerFunc) echo.HandlerFunc {
+// Verified allows only users with a verified email. Place it after a middleware checking the claims are present.
+func Verified(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		userID := c.Param("userId")
 		claims := jwt.GetClaims(c)
 
 		if claims == nil {
 			return errForbidden
 		}
 
-		if claims.Role == users.AdminRole || claims.Subject == userID {
-			return next(c)
+		if !claims.EmailVerified {
+			return errEmailNotVerified
 		}
 
-		return errForbidden
+		return next(c)
+	}
+}
+
+// Scope allows personal access tokens only when they were granted the scope. JWTs always pass.
+func Scope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := jwt.GetClaims(c)
+
+			if claims == nil {
+				return errForbidden
+			}
+
+			if !claims.HasScope(scope) {
+				return errInsufficientScope
+			}
+
+			return next(c)
+		}
 	}
 }
 
-func Editor(next echo.HandlerFunc) echo.HandlerFunc {
+// Session rejects personal access tokens, e.g. so that a token can't be used to create more tokens.
+func Session(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		claims := jwt.GetClaims(c)
 
@@ -34,16 +69,82 @@ func Editor(next echo.HandlerFunc) echo.HandlerFunc {
 			return errForbidden
 		}
 
-		switch claims.Role {
-		case users.AdminRole, users.EditorRole:
+		if claims.PersonalAccessToken {
+			return errSessionRequired
+		}
+
+		return next(c)
+	}
+}
+
+// Require allows only users whose role grants the permission.
+func Require(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims := jwt.GetClaims(c)
+
+			if claims == nil || !claims.HasPermission(permission) {
+				return errForbidden
+			}
+
+			if claims.TwoFactorSetupRequired {
+				return errTwoFactorSetup
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// SelfOr allows users to access their own resources (the userId path parameter),
+// and users whose role grants the permission to access resources of others.
+func SelfOr(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID := c.Param("userId")
+			claims := jwt.GetClaims(c)
+
+			if claims == nil {
+				return errForbidden
+			}
+
+			if claims.Subject == userID {
+				return next(c)
+			}
+
+			if !claims.HasPermission(permission) {
+				return errForbidden
+			}
+
+			if claims.TwoFactorSetupRequired {
+				return errTwoFactorSetup
+			}
+
 			return next(c)
-		default:
+		}
+	}
+}
+
+// TwoFactorSatisfied rejects users whose role requires two-factor authentication they haven't enabled yet.
+// Require and SelfOr check it on their own.
+func TwoFactorSatisfied(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := jwt.GetClaims(c)
+
+		if claims == nil {
 			return errForbidden
 		}
+
+		if claims.TwoFactorSetupRequired {
+			return errTwoFactorSetup
+		}
+
+		return next(c)
 	}
 }
 
-func Admin(next echo.HandlerFunc) echo.HandlerFunc {
+// NotImpersonated rejects impersonation tokens, e.g. so that nobody can change the credentials of the user they act as.
+func NotImpersonated(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		claims := jwt.GetClaims(c)
 
@@ -51,10 +152,10 @@ func Admin(next echo.HandlerFunc) echo.HandlerFunc {
 			return errForbidden
 		}
 
-		if claims.Role == users.AdminRole {
-			return next(c)
+		if claims.IsImpersonated() {
+			return errImpersonated
 		}
 
-		return errForbidden
+		return next(c)
 	}
 }
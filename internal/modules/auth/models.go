@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
 )
 
@@ -8,29 +10,297 @@ type RegisterUserRequest struct {
 	Username string `json:"username" validate:"min=3,max=24"`
 	Email    string `json:"email" validate:"email"`
 	Password string `json:"password" validate:"password"`
+	// InvitationCode is required if registration is invite-only, the user gets the role of the invitation.
+	InvitationCode string `json:"invitation_code,omitempty"`
 }
 
 type LoginUserRequest struct {
 	Username *string `json:"username,omitempty" validate:"min=3,max=24"`
 	Email    *string `json:"email,omitempty" validate:"email"`
 	Password string  `json:"password" validate:"password"`
+	Device   *string `json:"device,omitempty"`
+	// UseCookies asks for the tokens in session cookies instead of the response body.
+	UseCookies bool `json:"use_cookies,omitempty"`
 }
 
+// LoginUserResponse holds either the tokens or, if the user has two-factor authentication enabled,
+// a two-factor token to finish the login with a code. Cookie sessions get the CSRF token instead of the tokens.
 type LoginUserResponse struct {
-	User        users.User `json:"user"`
-	AccessToken string     `json:"access_token" nonzero:"true"`
+	User              users.User `json:"user"`
+	AccessToken       string     `json:"access_token,omitempty"`
+	RefreshToken      string     `json:"refresh_token,omitempty"`
+	CSRFToken         string     `json:"csrf_token,omitempty"`
+	TwoFactorRequired bool       `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string     `json:"two_factor_token,omitempty"`
+}
+
+type LoginTwoFactorRequest struct {
+	TwoFactorToken string  `json:"two_factor_token" validate:"nonzero"`
+	Code           string  `json:"code" validate:"nonzero"`
+	Device         *string `json:"device,omitempty"`
+	UseCookies     bool    `json:"use_cookies,omitempty"`
 }
 
+// RefreshTokenRequest takes the refresh token from the session cookie if it's not in the body.
 type RefreshTokenRequest struct {
-	UserID      int    `json:"-" param:"userId" validate:"nonzero"`
-	AccessToken string `json:"access-token" validate:"nonzero"`
+	RefreshToken string `json:"refresh_token"`
 }
 
+// RefreshTokenResponse holds the tokens, or the CSRF token for cookie sessions.
 type RefreshTokenResponse struct {
-	RefreshToken string `json:"access-token" nonzero:"true"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	CSRFToken    string `json:"csrf_token,omitempty"`
+}
+
+type LogoutRequest struct {
+	RefreshToken *string `json:"refresh_token,omitempty"`
+}
+
+type RevokeUserSessionsRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type AnonymizeUserRequest struct {
+	UserID          int    `json:"-" param:"userId" validate:"nonzero"`
+	CurrentPassword string `json:"current_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"nonzero"`
+	Password string `json:"password" validate:"password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"nonzero"`
+}
+
+type ResendEmailVerificationRequest struct {
+	Email string `json:"email" validate:"email"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string  `json:"current_password" validate:"nonzero"`
+	NewPassword     string  `json:"new_password" validate:"password"`
+	Device          *string `json:"device,omitempty"`
+}
+
+type ChangeEmailRequest struct {
+	CurrentPassword string `json:"current_password" validate:"nonzero"`
+	NewEmail        string `json:"new_email" validate:"email"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"nonzero"`
+}
+
+type ImpersonateUserRequest struct {
+	UserID int    `json:"-" param:"userId" validate:"nonzero"`
+	Reason string `json:"reason" validate:"min=3,max=255"`
+}
+
+type GetImpersonationsRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type ImpersonateUserResponse struct {
+	User        users.User `json:"user"`
+	AccessToken string     `json:"access_token"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// CreateInvitationRequest creates an invitation for the user role by default, usable once and without expiry.
+type CreateInvitationRequest struct {
+	Role      string     `json:"role,omitempty"`
+	MaxUses   int        `json:"max_uses,omitempty" validate:"min=0,max=10000"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateInvitationResponse holds the invitation code, it's shown only once.
+type CreateInvitationResponse struct {
+	*Invitation
+	Code string `json:"code"`
+}
+
+type RevokeInvitationRequest struct {
+	InvitationID int `json:"-" param:"invitationId" validate:"nonzero"`
+}
+
+type OIDCLoginRequest struct {
+	Provider string `json:"-" param:"provider" validate:"nonzero"`
+}
+
+// OIDCCallbackRequest is the redirect back from the identity provider.
+type OIDCCallbackRequest struct {
+	Provider         string  `json:"-" param:"provider" validate:"nonzero"`
+	Code             string  `json:"-" query:"code"`
+	State            string  `json:"-" query:"state" validate:"nonzero"`
+	Error            string  `json:"-" query:"error"`
+	ErrorDescription string  `json:"-" query:"error_description"`
+	Device           *string `json:"-" query:"device"`
+}
+
+type EnrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"nonzero"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"nonzero"`
+	Code     string `json:"code" validate:"nonzero"`
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	Code string `json:"code" validate:"nonzero"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DeleteLockoutRequest struct {
+	Scope   string `json:"-" param:"scope" validate:"nonzero"`
+	Subject string `json:"-" param:"subject" validate:"nonzero"`
 }
 
 type AuthenticatedRequest[T any] struct {
 	AccessToken string
 	Request     T
 }
+
+// LoginAttempt is a login request along with the address it comes from.
+type LoginAttempt struct {
+	Email    *string
+	Username *string
+	Password string
+	Device   string
+	IP       string
+}
+
+// CredentialsChange is the current password given to change the password or the email, or to delete the account,
+// along with the device and the address the request comes from.
+type CredentialsChange struct {
+	Password string
+	Device   string
+	IP       string
+}
+
+// LoginResult is the outcome of a correct password: the tokens, or a two-factor token if a code is required too.
+type LoginResult struct {
+	User           *users.User
+	Tokens         *Tokens
+	TwoFactorToken string
+}
+
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	Device    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	Expired   bool
+}
+
+type OneTimeToken struct {
+	ID        int
+	UserID    int
+	Purpose   string
+	TokenHash string
+	// Email is the new address of an email change.
+	Email     *string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// Lockout is a temporarily locked login of an account (scope "user", subject is the user id) or of an IP (scope "ip").
+type Lockout struct {
+	Scope         string    `json:"scope"`
+	Subject       string    `json:"subject"`
+	Username      *string   `json:"username,omitempty"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+	LockedUntil   time.Time `json:"lockedUntil"`
+}
+
+// Impersonation records that the actor got an access token to act as the user.
+type Impersonation struct {
+	ID        int       `json:"id"`
+	ActorID   int       `json:"actorId"`
+	UserID    int       `json:"userId"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Invitation lets its holder register, up to MaxUses times, with the role of the invitation.
+type Invitation struct {
+	ID        int        `json:"id"`
+	Role      string     `json:"role"`
+	MaxUses   int        `json:"maxUses"`
+	Uses      int        `json:"uses"`
+	CreatedBy int        `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CodeHash  string     `json:"-"`
+}
+
+// ImpersonationResult is the access token issued for an impersonation.
+type ImpersonationResult struct {
+	User          *users.User
+	AccessToken   string
+	Impersonation *Impersonation
+}
+
+// TwoFactor is the TOTP secret of a user. Two-factor authentication is enabled once the first code is confirmed.
+type TwoFactor struct {
+	UserID       int
+	Secret       string
+	CreatedAt    time.Time
+	EnabledAt    *time.Time
+	LastUsedStep *int64
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// OIDCLoginState is a login started with an identity provider, the state parameter ties the callback to it.
+type OIDCLoginState struct {
+	ID           int
+	Provider     string
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+}
+
+// UserIdentity links an account of an identity provider to a user.
+type UserIdentity struct {
+	ID        int
+	UserID    int
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
@@ -1,8 +1,14 @@
 package auth
 
 import (
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
 	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/mail"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+	"github.com/DavidMovas/Movies-Reviews/internal/oidc"
+	"github.com/DavidMovas/Movies-Reviews/internal/password"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Module struct {
@@ -11,10 +17,10 @@ type Module struct {
 	Repository *Repository
 }
 
-func NewModule(jwtService *jwt.Service, userService *users.Service) *Module {
-	repo := NewRepository()
-	service := NewService(userService, jwtService)
-	handler := NewHandler(service, userService)
+func NewModule(db *pgxpool.Pool, jwtService *jwt.Service, userService *users.Service, rolesService *roles.Service, hasher *password.Hasher, mailer mail.Mailer, oidcProviders map[string]*oidc.Provider, cfg config.AuthConfig) *Module {
+	repo := NewRepository(db)
+	service := NewService(repo, userService, rolesService, jwtService, hasher, mailer, oidcProviders, cfg)
+	handler := NewHandler(service, userService, NewSessionCookies(cfg.Cookie, jwtService))
 
 	return &Module{
 		Handler:    handler,
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+	"github.com/DavidMovas/Movies-Reviews/internal/oidc"
+	"github.com/DavidMovas/Movies-Reviews/internal/opaquetoken"
+)
+
+const (
+	maxUsernameLength      = 24
+	minUsernameLength      = 3
+	usernameSuffixMax      = 10000
+	usernameCreationTrials = 5
+)
+
+var (
+	errInvalidOIDCState  = apperrors.BadRequest(errors.New("invalid or expired state"))
+	errOIDCLoginFailed   = apperrors.Unauthorized("identity provider login failed")
+	errOIDCNoEmail       = apperrors.Forbidden("identity provider did not return an email")
+	errOIDCEmailNotValid = apperrors.Forbidden("email is not verified by the identity provider")
+
+	usernameDisallowedChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+// StartOIDCLogin returns the URL of the provider's login page. The state, the nonce and the PKCE verifier
+// are stored until the provider redirects the user back.
+func (s *Service) StartOIDCLogin(ctx context.Context, providerName string) (string, error) {
+	provider, err := s.oidcProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, stateHash, err := opaquetoken.Generate("")
+	if err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	nonce, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	loginState := &OIDCLoginState{
+		Provider:     providerName,
+		StateHash:    stateHash,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	}
+	if err = s.repo.CreateOIDCLoginState(ctx, loginState, s.cfg.OIDC.StateExpiration); err != nil {
+		return "", err
+	}
+
+	url, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.FromContext(ctx).Error("failed to build oidc authorization url", "provider", providerName, "error", err)
+		return "", apperrors.Internal(err)
+	}
+
+	return url, nil
+}
+
+// FinishOIDCLogin redeems the authorization code and logs in the user the external identity belongs to.
+// An identity seen for the first time is linked to the user with the same verified email,
+// or a new user is created for it.
+func (s *Service) FinishOIDCLogin(ctx context.Context, providerName, code, state, device string) (*LoginResult, error) {
+	provider, err := s.oidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState, err := s.repo.ConsumeOIDCLoginState(ctx, providerName, opaquetoken.Hash(state))
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.FromContext(ctx).Warn("oidc login failed", "provider", providerName, "error", err)
+		return nil, errOIDCLoginFailed
+	}
+
+	user, err := s.identityUser(ctx, providerName, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, user, device)
+}
+
+func (s *Service) identityUser(ctx context.Context, providerName string, identity *oidc.Identity) (*users.User, error) {
+	userID, err := s.repo.GetIdentityUserID(ctx, providerName, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != 0 {
+		var user *users.User
+		user, err = s.usersService.GetExistingUserByID(ctx, userID)
+		if apperrors.Is(err, apperrors.NotFoundCode) {
+			return nil, errInvalidCredentials
+		}
+		return user, err
+	}
+
+	if identity.Email == "" {
+		return nil, errOIDCNoEmail
+	}
+
+	// Linking by an unverified email would let anyone who controls the provider account take over the user
+	if !identity.EmailVerified {
+		return nil, errOIDCEmailNotValid
+	}
+
+	existing, err := s.usersService.GetExistingUserByEmail(ctx, identity.Email)
+	var user *users.User
+	switch {
+	case apperrors.Is(err, apperrors.NotFoundCode):
+		if user, err = s.createIdentityUser(ctx, identity); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		user = existing.User
+	}
+
+	link := &UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err = s.repo.LinkIdentity(ctx, link); err != nil {
+		return nil, err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &link.CreatedAt
+	}
+
+	log.FromContext(ctx).Info("external identity linked", "user_id", user.ID, "provider", providerName)
+	return user, nil
+}
+
+// createIdentityUser creates a user with the role "user" for the external identity. The user has a random password,
+// so they can sign in only through the provider until they reset it.
+func (s *Service) createIdentityUser(ctx context.Context, identity *oidc.Identity) (*users.User, error) {
+	password, _, err := opaquetoken.Generate("")
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	passHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	base := identityUsername(identity)
+	verifiedAt := time.Now()
+	for i := 0; i < usernameCreationTrials; i++ {
+		username := base
+		if i > 0 {
+			username, err = withRandomSuffix(base)
+			if err != nil {
+				return nil, apperrors.Internal(err)
+			}
+		}
+
+		user := &users.UserWithPassword{
+			User: &users.User{
+				Username:        username,
+				Email:           identity.Email,
+				Role:            users.UserRole,
+				AvatarURL:       users.DefaultAvatarURL,
+				EmailVerifiedAt: &verifiedAt,
+			},
+			PasswordHash: passHash,
+		}
+
+		err = s.usersService.Create(ctx, user)
+		if errors.Is(err, users.ErrUsernameTaken) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return user.User, nil
+	}
+
+	return nil, apperrors.Internal(fmt.Errorf("no free username for %q", base))
+}
+
+func (s *Service) oidcProvider(name string) (*oidc.Provider, error) {
+	provider, ok := s.oidcProviders[name]
+	if !ok {
+		return nil, apperrors.NotFound("identity provider", "name", name)
+	}
+
+	return provider, nil
+}
+
+// identityUsername derives a username from the preferred username or the email of the identity.
+func identityUsername(identity *oidc.Identity) string {
+	username := identity.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	username = usernameDisallowedChars.ReplaceAllString(username, "")
+	for len(username) < minUsernameLength {
+		username += "_"
+	}
+
+	// Leave room for a suffix
+	if len(username) > maxUsernameLength-5 {
+		username = username[:maxUsernameLength-5]
+	}
+
+	return username
+}
+
+func withRandomSuffix(username string) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(usernameSuffixMax))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s_%04d", username, n.Int64()), nil
+}
@@ -1,7 +1,944 @@
 package auth
 
-type Repository struct{}
+import (
+	"context"
+	"errors"
+	"time"
 
-func NewRepository() *Repository {
-	return &Repository{}
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errRefreshTokenConsumed = errors.New("refresh token already consumed")
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, token *RefreshToken, ttl time.Duration) error {
+	query, args, err := dbx.StatementBuilder.Insert("refresh_tokens").
+		Columns("user_id", "family_id", "token_hash", "device", "expires_at").
+		Values(token.UserID, token.FamilyID, token.TokenHash, token.Device, squirrel.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix("RETURNING id, created_at, expires_at").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	err = dbx.FromContext(ctx, r.db).QueryRow(ctx, query, args...).Scan(&token.ID, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, user_id, family_id::text, token_hash, device, created_at, expires_at, used_at, revoked_at, expires_at <= NOW()").
+		From("refresh_tokens").
+		Where(squirrel.Eq{"token_hash": hash}).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var token RefreshToken
+	err = r.db.QueryRow(ctx, query, args...).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.Device, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.Expired)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, errInvalidRefreshToken
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken marks the current token as used and stores its successor in one transaction.
+// It returns errRefreshTokenConsumed when the current token was used or revoked concurrently.
+func (r *Repository) RotateRefreshToken(ctx context.Context, currentID int, next *RefreshToken, ttl time.Duration) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("refresh_tokens").
+			Set("used_at", squirrel.Expr("NOW()")).
+			Where(squirrel.Eq{"id": currentID}).
+			Where(squirrel.Eq{"used_at": nil}).
+			Where(squirrel.Eq{"revoked_at": nil}).
+			Where("expires_at > NOW()").
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return errRefreshTokenConsumed
+		}
+
+		return r.CreateRefreshToken(ctx, next, ttl)
+	})
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	query, args, err := dbx.StatementBuilder.Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"family_id": familyID}).
+		Where(squirrel.Eq{"revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = dbx.FromContext(ctx, r.db).Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	query, args, err := dbx.StatementBuilder.Update("refresh_tokens").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = dbx.FromContext(ctx, r.db).Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// CreateOneTimeToken stores a new token and invalidates the unused tokens issued to the user for the same purpose,
+// so only the latest emailed token works.
+func (r *Repository) CreateOneTimeToken(ctx context.Context, token *OneTimeToken, ttl time.Duration) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("one_time_tokens").
+			Set("used_at", squirrel.Expr("NOW()")).
+			Where(squirrel.Eq{"user_id": token.UserID}).
+			Where(squirrel.Eq{"purpose": token.Purpose}).
+			Where(squirrel.Eq{"used_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return apperrors.Internal(err)
+		}
+
+		query, args, err = dbx.StatementBuilder.Insert("one_time_tokens").
+			Columns("user_id", "purpose", "token_hash", "email", "expires_at").
+			Values(token.UserID, token.Purpose, token.TokenHash, token.Email, squirrel.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds())).
+			Suffix("RETURNING id, created_at, expires_at").
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if err = tx.QueryRow(ctx, query, args...).Scan(&token.ID, &token.CreatedAt, &token.ExpiresAt); err != nil {
+			return apperrors.Internal(err)
+		}
+
+		return nil
+	})
+}
+
+// consumeOneTimeToken marks the token as used and returns it.
+// It returns errInvalidOneTimeToken if the token does not exist, expired or was already used.
+func (r *Repository) consumeOneTimeToken(ctx context.Context, hash, purpose string) (*OneTimeToken, error) {
+	query, args, err := dbx.StatementBuilder.Update("one_time_tokens").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"token_hash": hash}).
+		Where(squirrel.Eq{"purpose": purpose}).
+		Where(squirrel.Eq{"used_at": nil}).
+		Where("expires_at > NOW()").
+		Suffix("RETURNING id, user_id, email, created_at, expires_at, used_at").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	token := &OneTimeToken{Purpose: purpose, TokenHash: hash}
+	err = dbx.FromContext(ctx, r.db).QueryRow(ctx, query, args...).
+		Scan(&token.ID, &token.UserID, &token.Email, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, errInvalidOneTimeToken
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return token, nil
+}
+
+// ResetPassword consumes the reset token, sets the new password hash and revokes all refresh tokens and personal
+// access tokens of the user in one transaction. It returns the id of the user.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash, passHash string) (int, error) {
+	var userID int
+	err := dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		token, err := r.consumeOneTimeToken(ctx, tokenHash, passwordResetPurpose)
+		if err != nil {
+			return err
+		}
+		userID = token.UserID
+
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("pass_hash", passHash).
+			Where(squirrel.Eq{"id": userID}).
+			Where(squirrel.Eq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return errInvalidOneTimeToken
+		}
+
+		return r.revokeUserCredentials(ctx, userID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// VerifyEmail consumes the verification token and marks the email of its user as verified.
+// It returns the id of the user.
+func (r *Repository) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+	err := dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		token, err := r.consumeOneTimeToken(ctx, tokenHash, emailVerificationPurpose)
+		if err != nil {
+			return err
+		}
+		userID = token.UserID
+
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("email_verified_at", squirrel.Expr("COALESCE(email_verified_at, NOW())")).
+			Where(squirrel.Eq{"id": userID}).
+			Where(squirrel.Eq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return errInvalidOneTimeToken
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ChangePassword sets the new password hash and revokes all refresh tokens and personal access tokens of the user
+// in one transaction.
+func (r *Repository) ChangePassword(ctx context.Context, userID int, passHash string) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("pass_hash", passHash).
+			Where(squirrel.Eq{"id": userID}).
+			Where(squirrel.Eq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return apperrors.NotFound("user", "id", userID)
+		}
+
+		return r.revokeUserCredentials(ctx, userID)
+	})
+}
+
+// ChangeEmail consumes the email change token, sets the new email as verified and revokes all refresh tokens
+// and personal access tokens of the user in one transaction. It returns the id of the user.
+func (r *Repository) ChangeEmail(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+	err := dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		token, err := r.consumeOneTimeToken(ctx, tokenHash, emailChangePurpose)
+		if err != nil {
+			return err
+		}
+		userID = token.UserID
+
+		if token.Email == nil {
+			return errInvalidOneTimeToken
+		}
+
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("email", *token.Email).
+			Set("email_verified_at", squirrel.Expr("NOW()")).
+			Where(squirrel.Eq{"id": userID}).
+			Where(squirrel.Eq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		switch {
+		case dbx.IsUniqueViolation(err, "email"):
+			return apperrors.AlreadyExists("user", "email", *token.Email)
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return errInvalidOneTimeToken
+		}
+
+		return r.revokeUserCredentials(ctx, userID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// revokeUserCredentials revokes all refresh tokens and personal access tokens of the user.
+func (r *Repository) revokeUserCredentials(ctx context.Context, userID int) error {
+	if err := r.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	query, args, err := dbx.StatementBuilder.Update("personal_access_tokens").
+		Set("deleted_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = dbx.FromContext(ctx, r.db).Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// GetLoginLockout returns for how long the login is still locked, zero if it is not.
+func (r *Repository) GetLoginLockout(ctx context.Context, scope, subject string) (time.Duration, error) {
+	query, args, err := dbx.StatementBuilder.Select("EXTRACT(EPOCH FROM locked_until - NOW())::float8").
+		From("login_failures").
+		Where(squirrel.Eq{"scope": scope}).
+		Where(squirrel.Eq{"subject": subject}).
+		Where("locked_until > NOW()").
+		ToSql()
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	var seconds float64
+	err = r.db.QueryRow(ctx, query, args...).Scan(&seconds)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return 0, nil
+	case err != nil:
+		return 0, apperrors.Internal(err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// RecordLoginFailure counts a failed login and returns the number of consecutive failures.
+// The count starts over if the previous failure happened longer than window ago.
+func (r *Repository) RecordLoginFailure(ctx context.Context, scope, subject string, window time.Duration) (int, error) {
+	query, args, err := dbx.StatementBuilder.Insert("login_failures").
+		Columns("scope", "subject", "failures").
+		Values(scope, subject, 1).
+		Suffix(`ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE WHEN login_failures.last_failure_at < NOW() - make_interval(secs => ?) THEN 1 ELSE login_failures.failures + 1 END,
+			last_failure_at = NOW()
+			RETURNING failures`, window.Seconds()).
+		ToSql()
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	var failures int
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&failures); err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	return failures, nil
+}
+
+func (r *Repository) LockLogin(ctx context.Context, scope, subject string, duration time.Duration) error {
+	query, args, err := dbx.StatementBuilder.Update("login_failures").
+		Set("locked_until", squirrel.Expr("NOW() + make_interval(secs => ?)", duration.Seconds())).
+		Where(squirrel.Eq{"scope": scope}).
+		Where(squirrel.Eq{"subject": subject}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// ClearLoginFailures forgets the failures and the lock. It reports whether there was anything to clear.
+func (r *Repository) ClearLoginFailures(ctx context.Context, scope, subject string) (bool, error) {
+	query, args, err := dbx.StatementBuilder.Delete("login_failures").
+		Where(squirrel.Eq{"scope": scope}).
+		Where(squirrel.Eq{"subject": subject}).
+		ToSql()
+	if err != nil {
+		return false, apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, apperrors.Internal(err)
+	}
+
+	return n.RowsAffected() > 0, nil
+}
+
+func (r *Repository) GetLockouts(ctx context.Context) ([]*Lockout, error) {
+	query, args, err := dbx.StatementBuilder.Select("lf.scope, lf.subject, u.username, lf.failures, lf.last_failure_at, lf.locked_until").
+		From("login_failures lf").
+		LeftJoin("users u ON lf.scope = ? AND u.id::text = lf.subject", userLockoutScope).
+		Where("lf.locked_until > NOW()").
+		OrderBy("lf.locked_until DESC").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	lockouts := make([]*Lockout, 0)
+	for rows.Next() {
+		var lockout Lockout
+		if err = rows.Scan(&lockout.Scope, &lockout.Subject, &lockout.Username, &lockout.Failures, &lockout.LastFailureAt, &lockout.LockedUntil); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		lockouts = append(lockouts, &lockout)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return lockouts, nil
+}
+
+func (r *Repository) CreateImpersonation(ctx context.Context, impersonation *Impersonation, ttl time.Duration) error {
+	query, args, err := dbx.StatementBuilder.Insert("impersonations").
+		Columns("actor_id", "user_id", "reason", "expires_at").
+		Values(impersonation.ActorID, impersonation.UserID, impersonation.Reason, squirrel.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix("RETURNING id, created_at, expires_at").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	err = r.db.QueryRow(ctx, query, args...).Scan(&impersonation.ID, &impersonation.CreatedAt, &impersonation.ExpiresAt)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetImpersonationsByUserID(ctx context.Context, userID int) ([]*Impersonation, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, actor_id, user_id, reason, created_at, expires_at").
+		From("impersonations").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	impersonations := make([]*Impersonation, 0)
+	for rows.Next() {
+		var impersonation Impersonation
+		if err = rows.Scan(&impersonation.ID, &impersonation.ActorID, &impersonation.UserID, &impersonation.Reason, &impersonation.CreatedAt, &impersonation.ExpiresAt); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		impersonations = append(impersonations, &impersonation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return impersonations, nil
+}
+
+const invitationColumns = "id, role, max_uses, uses, created_by, created_at, expires_at, revoked_at"
+
+func (r *Repository) CreateInvitation(ctx context.Context, invitation *Invitation) error {
+	query, args, err := dbx.StatementBuilder.Insert("invitations").
+		Columns("code_hash", "role", "max_uses", "created_by", "expires_at").
+		Values(invitation.CodeHash, invitation.Role, invitation.MaxUses, invitation.CreatedBy, invitation.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	err = r.db.QueryRow(ctx, query, args...).Scan(&invitation.ID, &invitation.CreatedAt)
+	switch {
+	case dbx.IsForeignKeyViolation(err, "invitations_role_fkey"):
+		return apperrors.NotFound("role", "name", invitation.Role)
+	case err != nil:
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetInvitations(ctx context.Context) ([]*Invitation, error) {
+	query, args, err := dbx.StatementBuilder.Select(invitationColumns).
+		From("invitations").
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	invitations := make([]*Invitation, 0)
+	for rows.Next() {
+		var invitation Invitation
+		if err = scanInvitation(rows, &invitation); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		invitations = append(invitations, &invitation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation makes the invitation unusable, used invitations are kept for the record.
+func (r *Repository) RevokeInvitation(ctx context.Context, invitationID int) error {
+	query, args, err := dbx.StatementBuilder.Update("invitations").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": invitationID}).
+		Where(squirrel.Eq{"revoked_at": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if n.RowsAffected() == 0 {
+		return apperrors.NotFound("invitation", "id", invitationID)
+	}
+
+	return nil
+}
+
+// RedeemInvitation counts a use of the invitation and calls register in the same transaction, so the use
+// is taken back if the registration fails. The row lock keeps concurrent registrations within the usage limit.
+func (r *Repository) RedeemInvitation(ctx context.Context, codeHash string, register func(ctx context.Context, invitation *Invitation) error) error {
+	query, args, err := dbx.StatementBuilder.Update("invitations").
+		Set("uses", squirrel.Expr("uses + 1")).
+		Where(squirrel.Eq{"code_hash": codeHash}).
+		Where(squirrel.Eq{"revoked_at": nil}).
+		Where("uses < max_uses").
+		Where(squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Expr("expires_at > NOW()")}).
+		Suffix("RETURNING " + invitationColumns).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		var invitation Invitation
+		err = scanInvitation(tx.QueryRow(ctx, query, args...), &invitation)
+		switch {
+		case dbx.IsNoRows(err):
+			return errInvalidInvitation
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		return register(ctx, &invitation)
+	})
+}
+
+func scanInvitation(row pgx.Row, invitation *Invitation) error {
+	return row.Scan(&invitation.ID, &invitation.Role, &invitation.MaxUses, &invitation.Uses, &invitation.CreatedBy,
+		&invitation.CreatedAt, &invitation.ExpiresAt, &invitation.RevokedAt)
+}
+
+// getOneTimeTokenUser returns the id of the user the token was issued to without consuming it.
+func (r *Repository) getOneTimeTokenUser(ctx context.Context, hash, purpose string) (int, error) {
+	query, args, err := dbx.StatementBuilder.Select("user_id").
+		From("one_time_tokens").
+		Where(squirrel.Eq{"token_hash": hash}).
+		Where(squirrel.Eq{"purpose": purpose}).
+		Where(squirrel.Eq{"used_at": nil}).
+		Where("expires_at > NOW()").
+		ToSql()
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	var userID int
+	err = r.db.QueryRow(ctx, query, args...).Scan(&userID)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return 0, errInvalidOneTimeToken
+	case err != nil:
+		return 0, apperrors.Internal(err)
+	}
+
+	return userID, nil
+}
+
+// GetTwoFactor returns the two-factor settings of the user, nil if the user never started the enrolment.
+func (r *Repository) GetTwoFactor(ctx context.Context, userID int) (*TwoFactor, error) {
+	query, args, err := dbx.StatementBuilder.Select("user_id, secret, created_at, enabled_at, last_used_step").
+		From("user_two_factor").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var twoFactor TwoFactor
+	err = r.db.QueryRow(ctx, query, args...).Scan(&twoFactor.UserID, &twoFactor.Secret, &twoFactor.CreatedAt, &twoFactor.EnabledAt, &twoFactor.LastUsedStep)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, nil
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &twoFactor, nil
+}
+
+// SaveTwoFactorSecret starts the enrolment or replaces the secret of an unconfirmed one.
+// It returns errTwoFactorEnabled if two-factor authentication is already enabled.
+func (r *Repository) SaveTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	query, args, err := dbx.StatementBuilder.Insert("user_two_factor").
+		Columns("user_id", "secret").
+		Values(userID, secret).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = NOW(), last_used_step = NULL
+			WHERE user_two_factor.enabled_at IS NULL`).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if n.RowsAffected() == 0 {
+		return errTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// EnableTwoFactor confirms the enrolment with the step of the first valid code and stores the recovery codes.
+func (r *Repository) EnableTwoFactor(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("user_two_factor").
+			Set("enabled_at", squirrel.Expr("NOW()")).
+			Set("last_used_step", step).
+			Where(squirrel.Eq{"user_id": userID}).
+			Where(squirrel.Eq{"enabled_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return errTwoFactorEnabled
+		}
+
+		return r.replaceRecoveryCodes(ctx, userID, recoveryCodeHashes)
+	})
+}
+
+// ReplaceRecoveryCodes deletes all recovery codes of the user and stores the new ones.
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, _ pgx.Tx) error {
+		return r.replaceRecoveryCodes(ctx, userID, hashes)
+	})
+}
+
+func (r *Repository) replaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	db := dbx.FromContext(ctx, r.db)
+
+	query, args, err := dbx.StatementBuilder.Delete("two_factor_recovery_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = db.Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	insert := dbx.StatementBuilder.Insert("two_factor_recovery_codes").Columns("user_id", "code_hash")
+	for _, hash := range hashes {
+		insert = insert.Values(userID, hash)
+	}
+
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = db.Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// UseTwoFactorStep records the step of a valid code. It reports false if the step, or a later one,
+// was already used, so every code works only once.
+func (r *Repository) UseTwoFactorStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query, args, err := dbx.StatementBuilder.Update("user_two_factor").
+		Set("last_used_step", step).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Or{squirrel.Eq{"last_used_step": nil}, squirrel.Lt{"last_used_step": step}}).
+		ToSql()
+	if err != nil {
+		return false, apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, apperrors.Internal(err)
+	}
+
+	return n.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode marks the recovery code as used. It reports false if the user has no such unused code.
+func (r *Repository) UseRecoveryCode(ctx context.Context, userID int, hash string) (bool, error) {
+	query, args, err := dbx.StatementBuilder.Update("two_factor_recovery_codes").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"code_hash": hash}).
+		Where(squirrel.Eq{"used_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, apperrors.Internal(err)
+	}
+
+	return n.RowsAffected() > 0, nil
+}
+
+// DisableTwoFactor deletes the two-factor settings and the recovery codes of the user.
+func (r *Repository) DisableTwoFactor(ctx context.Context, userID int) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		for _, table := range []string{"two_factor_recovery_codes", "user_two_factor"} {
+			query, args, err := dbx.StatementBuilder.Delete(table).
+				Where(squirrel.Eq{"user_id": userID}).
+				ToSql()
+			if err != nil {
+				return apperrors.Internal(err)
+			}
+
+			if _, err = tx.Exec(ctx, query, args...); err != nil {
+				return apperrors.Internal(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) CreateOIDCLoginState(ctx context.Context, state *OIDCLoginState, ttl time.Duration) error {
+	query, args, err := dbx.StatementBuilder.Insert("oidc_login_states").
+		Columns("provider", "state_hash", "nonce", "code_verifier", "expires_at").
+		Values(state.Provider, state.StateHash, state.Nonce, state.CodeVerifier, squirrel.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix("RETURNING id, created_at, expires_at").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if err = r.db.QueryRow(ctx, query, args...).Scan(&state.ID, &state.CreatedAt, &state.ExpiresAt); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// ConsumeOIDCLoginState marks the state of a login started with the provider as used and returns it.
+// It returns errInvalidOIDCState if the state does not exist, expired or was already used.
+func (r *Repository) ConsumeOIDCLoginState(ctx context.Context, provider, stateHash string) (*OIDCLoginState, error) {
+	query, args, err := dbx.StatementBuilder.Update("oidc_login_states").
+		Set("used_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"provider": provider}).
+		Where(squirrel.Eq{"state_hash": stateHash}).
+		Where(squirrel.Eq{"used_at": nil}).
+		Where("expires_at > NOW()").
+		Suffix("RETURNING id, provider, state_hash, nonce, code_verifier, created_at, expires_at, used_at").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var state OIDCLoginState
+	err = r.db.QueryRow(ctx, query, args...).Scan(&state.ID, &state.Provider, &state.StateHash, &state.Nonce, &state.CodeVerifier, &state.CreatedAt, &state.ExpiresAt, &state.UsedAt)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, errInvalidOIDCState
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &state, nil
+}
+
+// GetIdentityUserID returns the id of the user the external identity is linked to, zero if it isn't linked.
+func (r *Repository) GetIdentityUserID(ctx context.Context, provider, subject string) (int, error) {
+	query, args, err := dbx.StatementBuilder.Select("user_id").
+		From("user_identities").
+		Where(squirrel.Eq{"provider": provider}).
+		Where(squirrel.Eq{"subject": subject}).
+		ToSql()
+	if err != nil {
+		return 0, apperrors.Internal(err)
+	}
+
+	var userID int
+	err = r.db.QueryRow(ctx, query, args...).Scan(&userID)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return 0, nil
+	case err != nil:
+		return 0, apperrors.Internal(err)
+	}
+
+	return userID, nil
+}
+
+// LinkIdentity links the external identity to the user. The identity provider has verified the email,
+// so the email of the user is marked as verified too.
+func (r *Repository) LinkIdentity(ctx context.Context, identity *UserIdentity) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Insert("user_identities").
+			Columns("user_id", "provider", "subject", "email").
+			Values(identity.UserID, identity.Provider, identity.Subject, identity.Email).
+			Suffix("RETURNING id, created_at").
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		err = tx.QueryRow(ctx, query, args...).Scan(&identity.ID, &identity.CreatedAt)
+		switch {
+		case dbx.IsUniqueViolation(err, "user_identities_provider_subject_key"):
+			return apperrors.AlreadyExists("identity", "subject", identity.Subject)
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		query, args, err = dbx.StatementBuilder.Update("users").
+			Set("email_verified_at", squirrel.Expr("COALESCE(email_verified_at, NOW())")).
+			Where(squirrel.Eq{"id": identity.UserID}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return apperrors.Internal(err)
+		}
+
+		return nil
+	})
 }
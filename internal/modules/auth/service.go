@@ -3,57 +3,592 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
 	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/mail"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/DavidMovas/Movies-Reviews/internal/oidc"
+	"github.com/DavidMovas/Movies-Reviews/internal/opaquetoken"
+	"github.com/DavidMovas/Movies-Reviews/internal/password"
+)
+
+const (
+	maxDeviceLength = 255
+
+	passwordResetPurpose     = "password_reset"
+	emailVerificationPurpose = "email_verification"
+)
+
+var (
+	errInvalidRefreshToken = apperrors.Unauthorized("invalid refresh token")
+	errRefreshTokenReused  = apperrors.Unauthorized("refresh token reuse detected")
+	errInvalidOneTimeToken = apperrors.BadRequest(errors.New("invalid or expired token"))
+	errEmailNotVerified    = apperrors.Forbidden("email is not verified")
+	errInvalidCredentials  = apperrors.Unauthorized("invalid credentials")
 )
 
 type Service struct {
-	usersService *users.Service
-	jwtService   *jwt.Service
+	repo          *Repository
+	usersService  *users.Service
+	rolesService  *roles.Service
+	jwtService    *jwt.Service
+	hasher        *password.Hasher
+	mailer        mail.Mailer
+	oidcProviders map[string]*oidc.Provider
+	cfg           config.AuthConfig
+
+	// dummyPasswordHash is compared with passwords of unknown accounts.
+	dummyPasswordHash string
 }
 
-func NewService(service *users.Service, jwtService *jwt.Service) *Service {
+func NewService(repo *Repository, service *users.Service, rolesService *roles.Service, jwtService *jwt.Service, hasher *password.Hasher, mailer mail.Mailer, oidcProviders map[string]*oidc.Provider, cfg config.AuthConfig) *Service {
+	dummyPasswordHash, _ := hasher.Hash("dummy password")
+
 	return &Service{
-		usersService: service,
-		jwtService:   jwtService,
+		repo:              repo,
+		usersService:      service,
+		rolesService:      rolesService,
+		jwtService:        jwtService,
+		hasher:            hasher,
+		mailer:            mailer,
+		oidcProviders:     oidcProviders,
+		cfg:               cfg,
+		dummyPasswordHash: dummyPasswordHash,
 	}
 }
 
-func (s *Service) Register(ctx context.Context, user *users.User, password string) error {
-	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// Register creates a user through the public registration. With an invitation code the user gets the role
+// of the invitation, the code is redeemed in the same transaction. In invite-only mode the code is required.
+func (s *Service) Register(ctx context.Context, user *users.User, password, invitationCode string) error {
+	if invitationCode == "" && s.cfg.InviteOnly {
+		return errInvitationRequired
+	}
+
+	return s.createUser(ctx, user, password, invitationCode)
+}
+
+// CreateUser creates a user regardless of the registration mode, e.g. the initial admin.
+func (s *Service) CreateUser(ctx context.Context, user *users.User, password string) error {
+	return s.createUser(ctx, user, password, "")
+}
+
+func (s *Service) createUser(ctx context.Context, user *users.User, password, invitationCode string) error {
+	passHash, err := s.hasher.Hash(password)
 	if err != nil {
 		return apperrors.Internal(err)
 	}
 
 	userWithPassword := &users.UserWithPassword{
 		User:         user,
-		PasswordHash: string(passHash),
+		PasswordHash: passHash,
 	}
 
-	return s.usersService.Create(ctx, userWithPassword)
+	if invitationCode == "" {
+		err = s.usersService.Create(ctx, userWithPassword)
+	} else {
+		err = s.repo.RedeemInvitation(ctx, opaquetoken.Hash(invitationCode), func(ctx context.Context, invitation *Invitation) error {
+			user.Role = invitation.Role
+			if createErr := s.usersService.Create(ctx, userWithPassword); createErr != nil {
+				return createErr
+			}
+
+			log.FromContext(ctx).Info("invitation redeemed", "invitation_id", invitation.ID, "user_id", user.ID)
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	// The account is created anyway, the user can ask for another email
+	if !user.IsEmailVerified() {
+		if err = s.sendEmailVerification(ctx, user); err != nil {
+			log.FromContext(ctx).Error("failed to send email verification", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return nil
 }
 
-func (s *Service) Login(ctx context.Context, email, username *string, password string) (user *users.UserWithPassword, token string, err error) {
-	if email != nil {
-		user, err = s.usersService.GetExistingUserByEmail(ctx, *email)
+// Login checks the credentials and issues a new pair of tokens. Failed attempts are counted per account
+// and per IP, reaching a threshold locks the login for a while. Unknown accounts and wrong passwords
+// are indistinguishable for the caller. Users with two-factor authentication get a two-factor token instead
+// of the tokens and finish the login with LoginTwoFactor.
+func (s *Service) Login(ctx context.Context, attempt *LoginAttempt) (*LoginResult, error) {
+	user, err := s.checkCredentials(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, user.User, attempt.Device)
+}
+
+// checkCredentials returns the user if the password is correct.
+func (s *Service) checkCredentials(ctx context.Context, attempt *LoginAttempt) (user *users.UserWithPassword, err error) {
+	if err = s.checkLoginLockout(ctx, ipLockoutScope, attempt.IP); err != nil {
+		return nil, err
+	}
+
+	if attempt.Email != nil {
+		user, err = s.usersService.GetExistingUserByEmail(ctx, *attempt.Email)
 	} else {
-		user, err = s.usersService.GetExistingUserByUsername(ctx, *username)
+		user, err = s.usersService.GetExistingUserByUsername(ctx, *attempt.Username)
+	}
+
+	switch {
+	case apperrors.Is(err, apperrors.NotFoundCode):
+		// Take as long as for a wrong password, so the response time doesn't tell the account exists
+		_, _, _ = s.hasher.Verify(attempt.Password, s.dummyPasswordHash)
+		return nil, s.loginFailed(ctx, nil, attempt.IP)
+	case err != nil:
+		return nil, err
+	}
+
+	userSubject := strconv.Itoa(user.ID)
+	if err = s.checkLoginLockout(ctx, userLockoutScope, userSubject); err != nil {
+		return nil, err
+	}
+
+	ok, rehash, err := s.hasher.Verify(attempt.Password, user.PasswordHash)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	if !ok {
+		return nil, s.loginFailed(ctx, user.User, attempt.IP)
+	}
+
+	if _, err = s.repo.ClearLoginFailures(ctx, userLockoutScope, userSubject); err != nil {
+		return nil, err
+	}
+
+	// The login goes on if the upgrade fails, it's retried on the next one
+	if rehash {
+		if err = s.rehashPassword(ctx, user.ID, attempt.Password); err != nil {
+			log.FromContext(ctx).Error("failed to rehash password", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return user, nil
+}
+
+// rehashPassword replaces a bcrypt hash, or one with weaker parameters, with a hash made with the current ones.
+func (s *Service) rehashPassword(ctx context.Context, userID int, password string) error {
+	passHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err = s.usersService.UpdatePasswordHash(ctx, userID, passHash); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("password rehashed", "user_id", userID)
+	return nil
+}
+
+// completeLogin issues the tokens for an authenticated user, or a two-factor token if the user
+// has to give a two-factor code first.
+func (s *Service) completeLogin(ctx context.Context, user *users.User, device string) (*LoginResult, error) {
+	if !user.IsEmailVerified() && !s.cfg.AllowUnverifiedLogin {
+		return nil, errEmailNotVerified
+	}
+
+	if err := s.usersService.CheckSuspension(ctx, user.ID); err != nil {
+		return nil, err
 	}
 
+	twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	res := &LoginResult{User: user}
+	if twoFactor.IsEnabled() {
+		res.TwoFactorToken, err = s.issueOneTimeToken(ctx, user.ID, twoFactorLoginPurpose, s.cfg.TwoFactor.ChallengeExpiration)
+	} else {
+		res.Tokens, err = s.issueTokens(ctx, user, uuid.New().String(), device)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *Service) GetLockouts(ctx context.Context) ([]*Lockout, error) {
+	return s.repo.GetLockouts(ctx)
+}
+
+// DeleteLockout unlocks the login of an account or an IP and resets its failures.
+func (s *Service) DeleteLockout(ctx context.Context, scope, subject string) error {
+	if scope != userLockoutScope && scope != ipLockoutScope {
+		return apperrors.BadRequest(fmt.Errorf("invalid lockout scope: %s", scope))
+	}
+
+	deleted, err := s.repo.ClearLoginFailures(ctx, scope, subject)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return apperrors.NotFound("lockout", scope, subject)
+	}
+
+	log.FromContext(ctx).Info("login lockout deleted", "scope", scope, "subject", subject)
+	return nil
+}
+
+func (s *Service) checkLoginLockout(ctx context.Context, scope, subject string) error {
+	lockedFor, err := s.repo.GetLoginLockout(ctx, scope, subject)
+	if err != nil {
+		return err
+	}
+
+	if lockedFor > 0 {
+		return apperrors.TooManyRequests("too many failed login attempts, try again later", lockedFor)
+	}
+
+	return nil
+}
+
+// loginFailed records the failure for the IP and, if known, for the account and returns errInvalidCredentials.
+func (s *Service) loginFailed(ctx context.Context, user *users.User, ip string) error {
+	if err := s.recordLoginFailures(ctx, user, ip); err != nil {
+		return err
+	}
+
+	return errInvalidCredentials
+}
+
+func (s *Service) recordLoginFailures(ctx context.Context, user *users.User, ip string) error {
+	if err := s.recordLoginFailure(ctx, ipLockoutScope, ip, s.cfg.Lockout.IPThreshold); err != nil {
+		return err
+	}
+
+	if user != nil {
+		return s.recordLoginFailure(ctx, userLockoutScope, strconv.Itoa(user.ID), s.cfg.Lockout.UserThreshold)
+	}
+
+	return nil
+}
+
+func (s *Service) recordLoginFailure(ctx context.Context, scope, subject string, threshold int) error {
+	failures, err := s.repo.RecordLoginFailure(ctx, scope, subject, s.cfg.Lockout.FailureWindow)
+	if err != nil {
+		return err
+	}
+
+	duration := lockoutDuration(failures, threshold, s.cfg.Lockout.BaseDuration, s.cfg.Lockout.MaxDuration)
+	if duration == 0 {
+		return nil
+	}
+
+	if err = s.repo.LockLogin(ctx, scope, subject, duration); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Warn("login locked", "scope", scope, "subject", subject, "failures", failures, "duration", duration)
+	return nil
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens. Every refresh token can be used only once:
+// presenting an already used token means it was stolen, so the whole token family gets revoked.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	current, err := s.repo.GetRefreshTokenByHash(ctx, opaquetoken.Hash(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case current.RevokedAt != nil, current.Expired:
+		return nil, errInvalidRefreshToken
+	case current.UsedAt != nil:
+		return nil, s.revokeReusedFamily(ctx, current)
+	}
+
+	user, err := s.usersService.GetExistingUserByID(ctx, current.UserID)
+	switch {
+	case apperrors.Is(err, apperrors.NotFoundCode):
+		return nil, errInvalidRefreshToken
+	case err != nil:
+		return nil, err
+	}
+
+	if !user.IsEmailVerified() && !s.cfg.AllowUnverifiedLogin {
+		return nil, errEmailNotVerified
+	}
+
+	if err = s.usersService.CheckSuspension(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	raw, next, err := s.newRefreshToken(user.ID, current.FamilyID, current.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.RotateRefreshToken(ctx, current.ID, next, s.jwtService.GetRefreshExpiration())
+	switch {
+	case errors.Is(err, errRefreshTokenConsumed):
+		return nil, s.revokeReusedFamily(ctx, current)
+	case err != nil:
+		return nil, err
+	}
+
+	accessToken, err := s.generateAccessToken(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{AccessToken: accessToken, RefreshToken: raw}, nil
+}
+
+// Logout revokes the access token it was called with and, if given, the refresh token family of the session.
+func (s *Service) Logout(ctx context.Context, claims *jwt.AccessClaims, refreshToken *string) error {
+	if refreshToken != nil {
+		token, err := s.repo.GetRefreshTokenByHash(ctx, opaquetoken.Hash(*refreshToken))
+		if err != nil {
+			return err
+		}
+
+		if token.UserID != claims.UserID {
+			return errInvalidRefreshToken
+		}
+
+		if err = s.repo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.jwtService.RevokeToken(ctx, claims); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	log.FromContext(ctx).Info("user logged out", "user_id", claims.UserID)
+	return nil
+}
+
+// RevokeUserSessions revokes all refresh tokens and all access tokens of the user.
+func (s *Service) RevokeUserSessions(ctx context.Context, userID int) error {
+	if _, err := s.usersService.GetExistingUserByID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	log.FromContext(ctx).Info("user sessions revoked", "user_id", userID)
+	return nil
+}
+
+// ForgotPassword emails a password reset token to the user. It never tells whether the email is registered.
+func (s *Service) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.usersService.GetExistingUserByEmail(ctx, email)
+	switch {
+	case apperrors.Is(err, apperrors.NotFoundCode):
+		log.FromContext(ctx).Info("password reset requested for unknown email")
+		return nil
+	case err != nil:
+		return err
+	}
+
+	raw, err := s.issueOneTimeToken(ctx, user.ID, passwordResetPurpose, s.cfg.PasswordResetExpiration)
+	if err != nil {
+		return err
+	}
+
+	msg := passwordResetMessage(user.User, raw, s.cfg.PasswordResetExpiration, s.cfg.PasswordResetURL)
+	if err = s.mailer.Send(ctx, msg); err != nil {
+		log.FromContext(ctx).Error("failed to send password reset email", "user_id", user.ID, "error", err)
+		return nil
+	}
+
+	log.FromContext(ctx).Info("password reset requested", "user_id", user.ID)
+	return nil
+}
+
+// ResetPassword sets a new password using an emailed reset token and signs the user out everywhere.
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	passHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	userID, err := s.repo.ResetPassword(ctx, opaquetoken.Hash(token), passHash)
+	if err != nil {
+		return err
+	}
+
+	if err = s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	log.FromContext(ctx).Info("password reset", "user_id", userID)
+	return nil
+}
+
+// VerifyEmail marks the email of the user as verified using an emailed verification token.
+// Access tokens issued earlier still say the email is not verified, the client has to refresh them.
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.repo.VerifyEmail(ctx, opaquetoken.Hash(token))
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("user email verified", "user_id", userID)
+	return nil
+}
+
+// ResendEmailVerification emails a new verification token. Like ForgotPassword, it never tells
+// whether the email is registered or already verified.
+func (s *Service) ResendEmailVerification(ctx context.Context, email string) error {
+	user, err := s.usersService.GetExistingUserByEmail(ctx, email)
+	switch {
+	case apperrors.Is(err, apperrors.NotFoundCode):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	if err = s.sendEmailVerification(ctx, user.User); err != nil {
+		log.FromContext(ctx).Error("failed to send email verification", "user_id", user.ID, "error", err)
+	}
+
+	return nil
+}
+
+func (s *Service) sendEmailVerification(ctx context.Context, user *users.User) error {
+	raw, err := s.issueOneTimeToken(ctx, user.ID, emailVerificationPurpose, s.cfg.EmailVerificationExpiration)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, emailVerificationMessage(user, raw, s.cfg.EmailVerificationExpiration, s.cfg.EmailVerificationURL))
+}
+
+// issueOneTimeToken stores a new single-use token and returns it in the raw form to be emailed.
+func (s *Service) issueOneTimeToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	raw, hash, err := opaquetoken.Generate("")
+	if err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	token := &OneTimeToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+	}
+	if err = s.repo.CreateOneTimeToken(ctx, token, ttl); err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+func (s *Service) issueTokens(ctx context.Context, user *users.User, familyID, device string) (*Tokens, error) {
+	accessToken, err := s.generateAccessToken(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, refreshToken, err := s.newRefreshToken(user.ID, familyID, device)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.CreateRefreshToken(ctx, refreshToken, s.jwtService.GetRefreshExpiration()); err != nil {
+		return nil, err
+	}
+
+	return &Tokens{AccessToken: accessToken, RefreshToken: raw}, nil
+}
+
+func (s *Service) newRefreshToken(userID int, familyID, device string) (string, *RefreshToken, error) {
+	raw, hash, err := opaquetoken.Generate("")
+	if err != nil {
+		return "", nil, apperrors.Internal(err)
+	}
+
+	// The column holds up to maxDeviceLength characters of valid UTF-8, user agents are cut by runes
+	device = strings.ToValidUTF8(device, "")
+	if runes := []rune(device); len(runes) > maxDeviceLength {
+		device = string(runes[:maxDeviceLength])
+	}
+
+	return raw, &RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		Device:    device,
+	}, nil
+}
+
+func (s *Service) revokeReusedFamily(ctx context.Context, token *RefreshToken) error {
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Warn("refresh token reuse detected, token family revoked", "user_id", token.UserID, "family_id", token.FamilyID)
+	return errRefreshTokenReused
+}
+
+func (s *Service) generateAccessToken(ctx context.Context, user *users.User) (string, error) {
+	tokenUser, err := s.newTokenUser(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	accessToken, err := s.jwtService.GenerateToken(*tokenUser)
+	if err != nil {
+		return "", apperrors.Internal(err)
+	}
+
+	return accessToken, nil
+}
+
+// newTokenUser returns what the access tokens of the user carry: the permissions of the role
+// and whether the user still has to enable two-factor authentication.
+func (s *Service) newTokenUser(ctx context.Context, user *users.User) (*jwt.TokenUser, error) {
+	permissions, err := s.rolesService.GetRolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenUser := &jwt.TokenUser{
+		ID:            user.ID,
+		Role:          user.Role,
+		EmailVerified: user.IsEmailVerified(),
+		Permissions:   permissions,
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return nil, "", apperrors.Unauthorized("invalid password")
+	if s.twoFactorRequired(user.Role) {
+		var twoFactor *TwoFactor
+		if twoFactor, err = s.repo.GetTwoFactor(ctx, user.ID); err != nil {
+			return nil, err
 		}
-		return nil, "", apperrors.Internal(err)
+		tokenUser.TwoFactorSetupRequired = !twoFactor.IsEnabled()
 	}
 
-	token, err = s.jwtService.GenerateToken(user.ID, user.Role)
-	return user, token, err
+	return tokenUser, nil
 }
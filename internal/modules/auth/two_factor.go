@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+	"github.com/DavidMovas/Movies-Reviews/internal/opaquetoken"
+	"github.com/DavidMovas/Movies-Reviews/internal/totp"
+	"github.com/google/uuid"
+)
+
+const (
+	twoFactorLoginPurpose = "two_factor_login"
+
+	recoveryCodeLength = 10
+)
+
+var (
+	errTwoFactorEnabled     = apperrors.BadRequest(errors.New("two-factor authentication is already enabled"))
+	errTwoFactorNotEnabled  = apperrors.BadRequest(errors.New("two-factor authentication is not enabled"))
+	errTwoFactorNotEnrolled = apperrors.BadRequest(errors.New("two-factor enrolment is not started"))
+	errTwoFactorRequired    = apperrors.Forbidden("two-factor authentication is required for your role")
+	errInvalidTwoFactorCode = apperrors.Unauthorized("invalid two-factor code")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTwoFactor generates a new TOTP secret for the user. Two-factor authentication is not enabled until
+// a code generated from the secret is confirmed, enrolling again before that replaces the secret.
+func (s *Service) EnrollTwoFactor(ctx context.Context, userID int) (*EnrollTwoFactorResponse, error) {
+	user, err := s.usersService.GetExistingUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	if err = s.repo.SaveTwoFactorSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("two-factor enrolment started", "user_id", userID)
+	return &EnrollTwoFactorResponse{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(s.cfg.TwoFactor.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication with the first code from the authenticator app
+// and returns the recovery codes. They are shown only once.
+func (s *Service) ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	twoFactor, err := s.repo.GetTwoFactor(ctx, userID)
+	switch {
+	case err != nil:
+		return nil, err
+	case twoFactor == nil:
+		return nil, errTwoFactorNotEnrolled
+	case twoFactor.IsEnabled():
+		return nil, errTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, errInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.EnableTwoFactor(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("two-factor authentication enabled", "user_id", userID)
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off. It takes the password and a code (or a recovery code),
+// so a stolen session alone is not enough. Users whose role requires two-factor authentication can't disable it.
+func (s *Service) DisableTwoFactor(ctx context.Context, userID int, attempt *CredentialsChange, code string) error {
+	user, err := s.checkCurrentPassword(ctx, userID, attempt)
+	if err != nil {
+		return err
+	}
+
+	if s.twoFactorRequired(user.Role) {
+		return errTwoFactorRequired
+	}
+
+	if err = s.verifyTwoFactorCode(ctx, user.User, code, attempt.IP); err != nil {
+		return err
+	}
+
+	if err = s.repo.DisableTwoFactor(ctx, userID); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("two-factor authentication disabled", "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user, e.g. after most of them were used.
+// Wrong codes count as failed logins.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int, code, ip string) ([]string, error) {
+	if err := s.checkLoginLockouts(ctx, userID, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.usersService.GetExistingUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyTwoFactorCode(ctx, user, code, ip); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("two-factor recovery codes regenerated", "user_id", userID)
+	return codes, nil
+}
+
+// LoginTwoFactor finishes a login of a user with two-factor authentication. The two-factor token stays valid
+// until a correct code is given or it expires, wrong codes count as failed logins.
+func (s *Service) LoginTwoFactor(ctx context.Context, token, code, device, ip string) (*users.User, *Tokens, error) {
+	if err := s.checkLoginLockout(ctx, ipLockoutScope, ip); err != nil {
+		return nil, nil, err
+	}
+
+	hash := opaquetoken.Hash(token)
+	userID, err := s.repo.getOneTimeTokenUser(ctx, hash, twoFactorLoginPurpose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userSubject := strconv.Itoa(userID)
+	if err = s.checkLoginLockout(ctx, userLockoutScope, userSubject); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.usersService.GetExistingUserByID(ctx, userID)
+	switch {
+	case apperrors.Is(err, apperrors.NotFoundCode):
+		return nil, nil, errInvalidOneTimeToken
+	case err != nil:
+		return nil, nil, err
+	}
+
+	if err = s.verifyTwoFactorCode(ctx, user, code, ip); err != nil {
+		return nil, nil, err
+	}
+
+	if err = s.usersService.CheckSuspension(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	// Two requests with the same token and valid codes can't both sign in
+	if _, err = s.repo.consumeOneTimeToken(ctx, hash, twoFactorLoginPurpose); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err = s.repo.ClearLoginFailures(ctx, userLockoutScope, userSubject); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(ctx, user, uuid.New().String(), device)
+	return user, tokens, err
+}
+
+// verifyTwoFactorCode accepts a code from the authenticator app or an unused recovery code.
+// Every code can be used only once. Wrong codes count as failed logins, the caller checks the lockouts.
+func (s *Service) verifyTwoFactorCode(ctx context.Context, user *users.User, code, ip string) error {
+	twoFactor, err := s.repo.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !twoFactor.IsEnabled() {
+		return errTwoFactorNotEnabled
+	}
+
+	var used bool
+	step, ok := totp.Validate(twoFactor.Secret, code, time.Now())
+	if ok {
+		used, err = s.repo.UseTwoFactorStep(ctx, user.ID, step)
+	} else {
+		used, err = s.repo.UseRecoveryCode(ctx, user.ID, opaquetoken.Hash(normalizeRecoveryCode(code)))
+	}
+
+	switch {
+	case err != nil:
+		return err
+	case !used:
+		if err = s.recordLoginFailures(ctx, user, ip); err != nil {
+			return err
+		}
+		return errInvalidTwoFactorCode
+	case !ok:
+		log.FromContext(ctx).Info("two-factor recovery code used", "user_id", user.ID)
+	}
+
+	return nil
+}
+
+func (s *Service) twoFactorRequired(role string) bool {
+	return slices.Contains(s.cfg.TwoFactor.RequiredRoles, role)
+}
+
+// generateRecoveryCodes returns the codes formatted for the user, e.g. "ab3de-fg7hk", and their hashes.
+func (s *Service) generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < s.cfg.TwoFactor.RecoveryCodes; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, apperrors.Internal(err)
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:recoveryCodeLength]
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, opaquetoken.Hash(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,79 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// buildArchive writes the data as a zip file: export.json with everything, and the reviews and events as CSV
+// for spreadsheets.
+func buildArchive(data *Data) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create("export.json")
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(data); err != nil {
+		return nil, err
+	}
+
+	reviews := [][]string{{"id", "movie_id", "movie_title", "rating", "title", "description", "created_at", "updated_at", "deleted_at"}}
+	for _, review := range data.Reviews {
+		reviews = append(reviews, []string{
+			strconv.Itoa(review.ID),
+			strconv.Itoa(review.MovieID),
+			review.MovieTitle,
+			strconv.Itoa(review.Rating),
+			review.Title,
+			review.Content,
+			formatTime(&review.CreatedAt),
+			formatTime(review.UpdatedAt),
+			formatTime(review.DeletedAt),
+		})
+	}
+
+	if err = writeCSV(zw, "reviews.csv", reviews); err != nil {
+		return nil, err
+	}
+
+	events := [][]string{{"type", "occurred_at", "detail"}}
+	for _, event := range data.Events {
+		events = append(events, []string{event.Type, formatTime(&event.OccurredAt), event.Detail})
+	}
+
+	if err = writeCSV(zw, "events.csv", events); err != nil {
+		return nil, err
+	}
+
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeCSV(zw *zip.Writer, name string, records [][]string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
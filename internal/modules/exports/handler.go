@@ -0,0 +1,100 @@
+package exports
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	*Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		Service: service,
+	}
+}
+
+// StartExport @Summary Start personal data export
+// @Description Start building an archive with the profile, all reviews including deleted ones, and account events
+// @Description of the user. The export runs in the background, an export already in progress is returned instead
+// @ID start-export
+// @Tags exports
+// @Param userId path int true "User ID"
+// @Produce json
+// @Success 202 {object} Export "Export"
+// @Failure 400 {object} apperrors.Error "Invalid user id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/export [post]
+func (h *Handler) StartExport(c echo.Context) error {
+	req, err := echox.BindAndValidate[StartExportRequest](c)
+	if err != nil {
+		return err
+	}
+
+	export, err := h.Service.StartExport(c.Request().Context(), policy.ActorFromContext(c), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, export)
+}
+
+// GetExport @Summary Get personal data export
+// @Description Get the status of the latest personal data export of the user: pending, running, completed or failed
+// @ID get-export
+// @Tags exports
+// @Param userId path int true "User ID"
+// @Produce json
+// @Success 200 {object} Export "Export"
+// @Failure 400 {object} apperrors.Error "Invalid user id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Export not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/export [get]
+func (h *Handler) GetExport(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetExportRequest](c)
+	if err != nil {
+		return err
+	}
+
+	export, err := h.Service.GetExport(c.Request().Context(), policy.ActorFromContext(c), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, export)
+}
+
+// GetExportArchive @Summary Download personal data export
+// @Description Download the zip archive of the latest completed export: export.json, reviews.csv and events.csv
+// @ID get-export-archive
+// @Tags exports
+// @Param userId path int true "User ID"
+// @Produce application/zip
+// @Success 200 {file} file "Archive"
+// @Failure 400 {object} apperrors.Error "Invalid user id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "No completed export or the archive expired"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/export/archive [get]
+func (h *Handler) GetExportArchive(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetExportArchiveRequest](c)
+	if err != nil {
+		return err
+	}
+
+	archive, err := h.Service.GetArchive(c.Request().Context(), policy.ActorFromContext(c), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"export-%d.zip\"", archive.ExportID))
+	return c.Blob(http.StatusOK, "application/zip", archive.Content)
+}
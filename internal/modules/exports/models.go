@@ -0,0 +1,78 @@
+package exports
+
+import "time"
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Export is a job building the archive with the personal data of a user.
+type Export struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"userId"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Archive is the zip file of a completed export.
+type Archive struct {
+	ExportID int
+	Content  []byte
+}
+
+// Data is everything stored about a user, as written to the archive.
+type Data struct {
+	ExportedAt time.Time `json:"exportedAt"`
+	Profile    *Profile  `json:"profile"`
+	Reviews    []*Review `json:"reviews"`
+	Events     []*Event  `json:"events"`
+}
+
+type Profile struct {
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	AvatarURL       *string    `json:"avatarUrl,omitempty"`
+	Bio             *string    `json:"bio,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Review includes soft-deleted reviews, they're still stored.
+type Review struct {
+	ID         int        `json:"id"`
+	MovieID    int        `json:"movieId"`
+	MovieTitle string     `json:"movieTitle"`
+	Rating     int        `json:"rating"`
+	Title      string     `json:"title"`
+	Content    string     `json:"description"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+}
+
+// Event is something that happened to the account: a login, a password reset request, an impersonation...
+type Event struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Detail     string    `json:"detail,omitempty"`
+}
+
+type StartExportRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type GetExportRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type GetExportArchiveRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
@@ -0,0 +1,270 @@
+package exports
+
+import (
+	"context"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// staleExportTimeout is how long an export may run before it's considered abandoned, e.g. by a crashed
+// instance, and is picked up again.
+const staleExportTimeout = 15 * time.Minute
+
+const eventsQuery = `
+SELECT 'account_created', created_at, '' FROM users WHERE id = $1
+UNION ALL
+SELECT 'email_verified', email_verified_at, '' FROM users WHERE id = $1 AND email_verified_at IS NOT NULL
+UNION ALL
+SELECT 'account_deleted', deleted_at, '' FROM users WHERE id = $1 AND deleted_at IS NOT NULL
+UNION ALL
+SELECT 'login', MIN(created_at), MIN(device) FROM refresh_tokens WHERE user_id = $1 GROUP BY family_id
+UNION ALL
+SELECT purpose || '_requested', created_at, '' FROM one_time_tokens WHERE user_id = $1
+UNION ALL
+SELECT 'two_factor_enabled', enabled_at, '' FROM user_two_factor WHERE user_id = $1 AND enabled_at IS NOT NULL
+UNION ALL
+SELECT 'identity_linked', created_at, provider FROM user_identities WHERE user_id = $1
+UNION ALL
+SELECT 'access_token_created', created_at, name FROM personal_access_tokens WHERE user_id = $1
+UNION ALL
+SELECT 'access_token_deleted', deleted_at, name FROM personal_access_tokens WHERE user_id = $1 AND deleted_at IS NOT NULL
+UNION ALL
+SELECT 'impersonated', created_at, reason FROM impersonations WHERE user_id = $1
+ORDER BY 2`
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// CreateExport queues an export for the user, or returns the one already in progress.
+func (r *Repository) CreateExport(ctx context.Context, userID int) (*Export, error) {
+	query, args, err := dbx.StatementBuilder.Insert("data_exports").
+		Columns("user_id").
+		Values(userID).
+		Suffix("ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING").
+		Suffix("RETURNING id, user_id, status, created_at, completed_at, expires_at").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var export Export
+	err = r.db.QueryRow(ctx, query, args...).Scan(&export.ID, &export.UserID, &export.Status, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	switch {
+	case dbx.IsNoRows(err):
+		return r.GetLatestExport(ctx, userID)
+	case dbx.IsForeignKeyViolation(err, "user_id"):
+		return nil, apperrors.NotFound("user", "id", userID)
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &export, nil
+}
+
+func (r *Repository) GetLatestExport(ctx context.Context, userID int) (*Export, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, user_id, status, created_at, completed_at, expires_at").
+		From("data_exports").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("id DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var export Export
+	err = r.db.QueryRow(ctx, query, args...).Scan(&export.ID, &export.UserID, &export.Status, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, apperrors.NotFound("export", "user id", userID)
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &export, nil
+}
+
+// GetArchive returns the archive of the latest completed export which hasn't expired yet.
+func (r *Repository) GetArchive(ctx context.Context, userID int) (*Archive, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, archive").
+		From("data_exports").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"status": StatusCompleted}).
+		Where("expires_at > NOW()").
+		OrderBy("id DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var archive Archive
+	err = r.db.QueryRow(ctx, query, args...).Scan(&archive.ExportID, &archive.Content)
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, apperrors.NotFound("export archive", "user id", userID)
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &archive, nil
+}
+
+// ClaimExport marks the oldest pending export as running and returns it, nil if there is none.
+// Concurrent workers never claim the same export.
+func (r *Repository) ClaimExport(ctx context.Context) (*Export, error) {
+	query, args, err := dbx.StatementBuilder.Update("data_exports").
+		Set("status", StatusRunning).
+		Set("started_at", squirrel.Expr("NOW()")).
+		Where(`id = (
+			SELECT id FROM data_exports
+			WHERE status = ? OR (status = ? AND started_at < NOW() - make_interval(secs => ?))
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)`, StatusPending, StatusRunning, staleExportTimeout.Seconds()).
+		Suffix("RETURNING id, user_id, status, created_at, completed_at, expires_at").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var export Export
+	err = r.db.QueryRow(ctx, query, args...).Scan(&export.ID, &export.UserID, &export.Status, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, nil
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &export, nil
+}
+
+func (r *Repository) CompleteExport(ctx context.Context, id int, content []byte, expiration time.Duration) error {
+	query, args, err := dbx.StatementBuilder.Update("data_exports").
+		Set("status", StatusCompleted).
+		Set("archive", content).
+		Set("completed_at", squirrel.Expr("NOW()")).
+		Set("expires_at", squirrel.Expr("NOW() + make_interval(secs => ?)", expiration.Seconds())).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r *Repository) FailExport(ctx context.Context, id int) error {
+	query, args, err := dbx.StatementBuilder.Update("data_exports").
+		Set("status", StatusFailed).
+		Set("completed_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredArchives drops the archives of expired exports, the jobs stay as a record of the request.
+func (r *Repository) DeleteExpiredArchives(ctx context.Context) error {
+	query, args, err := dbx.StatementBuilder.Update("data_exports").
+		Set("archive", nil).
+		Where("expires_at < NOW()").
+		Where(squirrel.NotEq{"archive": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if _, err = r.db.Exec(ctx, query, args...); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+// GetData collects everything stored about the user.
+func (r *Repository) GetData(ctx context.Context, userID int) (*Data, error) {
+	profileQuery := dbx.StatementBuilder.Select("id, username, email, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
+		From("users").
+		Where(squirrel.Eq{"id": userID})
+
+	reviewsQuery := dbx.StatementBuilder.Select("r.id, r.movie_id, m.title, r.rating, r.title, r.content, r.created_at, r.updated_at, r.deleted_at").
+		From("reviews r").
+		Join("movies m ON m.id = r.movie_id").
+		Where(squirrel.Eq{"r.user_id": userID}).
+		OrderBy("r.id")
+
+	b := &pgx.Batch{}
+	if err := dbx.QueryBatchSelect(b, profileQuery); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	if err := dbx.QueryBatchSelect(b, reviewsQuery); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	b.Queue(eventsQuery, userID)
+
+	br := r.db.SendBatch(ctx, b)
+	defer func() {
+		_ = br.Close()
+	}()
+
+	var profile Profile
+	err := br.QueryRow().Scan(&profile.ID, &profile.Username, &profile.Email, &profile.Role, &profile.AvatarURL, &profile.Bio, &profile.CreatedAt, &profile.EmailVerifiedAt, &profile.DeletedAt)
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, apperrors.NotFound("user", "id", userID)
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	reviews, err := pgx.CollectRows[*Review](rows, pgx.RowToAddrOfStructByPos[Review])
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err = br.Query()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	events, err := pgx.CollectRows[*Event](rows, pgx.RowToAddrOfStructByPos[Event])
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return &Data{
+		Profile: &profile,
+		Reviews: reviews,
+		Events:  events,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package exports
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
+)
+
+type Service struct {
+	repo       *Repository
+	expiration time.Duration
+}
+
+func NewService(repo *Repository, expiration time.Duration) *Service {
+	return &Service{
+		repo:       repo,
+		expiration: expiration,
+	}
+}
+
+// StartExport queues an export of the personal data of the user. The archive is built in the background
+// by RunWorker.
+func (s *Service) StartExport(ctx context.Context, actor *policy.Actor, userID int) (*Export, error) {
+	if err := policy.ExportUserData(actor, userID); err != nil {
+		return nil, err
+	}
+
+	export, err := s.repo.CreateExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("data export requested", "user_id", userID, "export_id", export.ID)
+	return export, nil
+}
+
+func (s *Service) GetExport(ctx context.Context, actor *policy.Actor, userID int) (*Export, error) {
+	if err := policy.ExportUserData(actor, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetLatestExport(ctx, userID)
+}
+
+func (s *Service) GetArchive(ctx context.Context, actor *policy.Actor, userID int) (*Archive, error) {
+	if err := policy.ExportUserData(actor, userID); err != nil {
+		return nil, err
+	}
+
+	archive, err := s.repo.GetArchive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("data export downloaded", "user_id", userID, "export_id", archive.ExportID)
+	return archive, nil
+}
+
+// RunWorker builds the queued exports every interval until the context is canceled, and drops expired archives.
+func (s *Service) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.processExports(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("failed to process data exports", "error", err)
+			}
+
+			if err := s.repo.DeleteExpiredArchives(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("failed to delete expired data export archives", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) processExports(ctx context.Context) error {
+	for ctx.Err() == nil {
+		export, err := s.repo.ClaimExport(ctx)
+		if err != nil {
+			return err
+		}
+
+		if export == nil {
+			return nil
+		}
+
+		if err = s.processExport(ctx, export); err != nil {
+			slog.Error("data export failed", "export_id", export.ID, "user_id", export.UserID, "error", err)
+
+			if err = s.repo.FailExport(ctx, export.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) processExport(ctx context.Context, export *Export) error {
+	data, err := s.repo.GetData(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	data.ExportedAt = time.Now().UTC()
+
+	content, err := buildArchive(data)
+	if err != nil {
+		return apperrors.Internal(fmt.Errorf("build archive: %w", err))
+	}
+
+	if err = s.repo.CompleteExport(ctx, export.ID, content, s.expiration); err != nil {
+		return err
+	}
+
+	slog.Info("data export completed", "export_id", export.ID, "user_id", export.UserID, "size", len(content))
+	return nil
+}
@@ -2,13 +2,12 @@ package movies
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/golang/groupcache/singleflight"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/stars"
 
-	"github.com/DavidMovas/Movies-Reviews/contracts"
-
 	"github.com/DavidMovas/Movies-Reviews/internal/config"
 	"github.com/DavidMovas/Movies-Reviews/internal/echox"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/genres"
@@ -41,7 +40,7 @@ func NewHandler(service *Service, paginationConfig *config.PaginationConfig) *Ha
 // @ID           get-movies
 // @Tags         movies
 // @Produce      json
-// @Param        request body contracts.GetMoviesRequest false "Request, if request body empty, default values will be used, if searchTerm in not empty: searching by title or description matches"
+// @Param        request body contracts.GetMoviesRequest false "Request, if request body empty, default values will be used, if q is not empty: searching by title or description in web search syntax, sorted by relevance, other filters narrow by genres, star, release date, ratings and reviews"
 // @Success      200 {object} pagination.PaginatedResponseOrdered[contracts.Movie] "PaginatedResponse of Movies, total number of movies, or nil if none found"
 // @Failure      400 {object} apperrors.Error "Invalid request, invalid parameter or missing parameter"
 // @Failure      500 {object} apperrors.Error "Internal server error"
@@ -53,19 +52,32 @@ func (h *Handler) GetMovies(c echo.Context) error {
 			return nil, err
 		}
 
-		pagination.SetDefaultsOrdered(&req.PaginatedRequestOrdered, h.paginationConfig)
-		offset, limit := pagination.OffsetLimit(&req.PaginatedRequest)
+		if req.SearchTerm != nil && strings.TrimSpace(*req.SearchTerm) == "" {
+			req.SearchTerm = nil
+		}
+
+		if req.SearchTerm != nil {
+			pagination.SetDefaultsOrderedWith(&req.PaginatedRequestOrdered, h.paginationConfig, "relevance", "desc")
+		} else {
+			pagination.SetDefaultsOrdered(&req.PaginatedRequestOrdered, h.paginationConfig)
+		}
+
+		keys, err := pagination.ParseSort(moviesSortKeys(req.SearchTerm), req.Sort, req.Order)
+		if err != nil {
+			return nil, err
+		}
 
-		if err = contracts.ValidateSortRequest(req.Sort); err != nil {
-			req.Sort = "id"
+		page, err := pagination.NewPage(&req.PaginatedRequest, keys)
+		if err != nil {
+			return nil, err
 		}
 
-		movies, total, err := h.service.GetMovies(c.Request().Context(), offset, limit, req.Sort, req.Order, req.SearchTerm)
+		movies, total, err := h.service.GetMovies(c.Request().Context(), &req.MoviesFilter, page)
 		if err != nil {
 			return nil, err
 		}
 
-		return pagination.ResponseOrdered[*Movie](&req.PaginatedRequestOrdered, total, movies), nil
+		return pagination.ResponseOrdered(&req.PaginatedRequestOrdered, page, total, movies), nil
 	})
 	if err != nil {
 		return err
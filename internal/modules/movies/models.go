@@ -1,6 +1,8 @@
 package movies
 
 import (
+	"maps"
+	"strconv"
 	"time"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/stars"
@@ -11,13 +13,23 @@ import (
 )
 
 type Movie struct {
-	ID          int        `json:"id"`
-	Title       string     `json:"title"`
-	PosterURL   string     `json:"posterUrl"`
-	ReleaseDate time.Time  `json:"releaseDate"`
-	AvgRating   *float64   `json:"avgRating,omitempty"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	PosterURL   string      `json:"posterUrl"`
+	ReleaseDate time.Time   `json:"releaseDate"`
+	AvgRating   *float64    `json:"avgRating,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	DeletedAt   *time.Time  `json:"deletedAt,omitempty"`
+	Relevance   *float64    `json:"relevance,omitempty"`
+	Highlights  *Highlights `json:"highlights,omitempty"`
+}
+
+// Highlights are the title and the best matching fragments of the description of a searched movie, with the
+// matching words wrapped in <mark> tags. They are HTML: the text is escaped (&, <, > and "), so the <mark> tags
+// are the only markup and the highlights can be inserted into a page as they are.
+type Highlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
 type MovieDetails struct {
@@ -50,9 +62,60 @@ type GetMovieRequest struct {
 	MovieID int `json:"-" param:"movieId" validate:"nonzero"`
 }
 
+// catalogSortKeys are the fields the movie catalog can be sorted by, movies without reviews sort as rated 0.
+var catalogSortKeys = pagination.NewSortKeys(map[string]pagination.SortKey[*Movie]{
+	"id":          {Expr: "id", Type: "integer", Value: func(m *Movie) string { return strconv.Itoa(m.ID) }},
+	"title":       {Expr: "title", Type: "varchar", Value: func(m *Movie) string { return m.Title }},
+	"releaseDate": {Expr: "release_date", Type: "date", Value: func(m *Movie) string { return pagination.FormatTime(m.ReleaseDate) }},
+	"createdAt":   {Expr: "created_at", Type: "timestamp", Value: func(m *Movie) string { return pagination.FormatTime(m.CreatedAt) }},
+	"created_at":  {Expr: "created_at", Type: "timestamp", Value: func(m *Movie) string { return pagination.FormatTime(m.CreatedAt) }},
+	"avgRating":   {Expr: "COALESCE(avg_rating, 0)", Type: "real", Value: func(m *Movie) string { return pagination.FormatFloat(m.AvgRating) }},
+})
+
+// moviesSortKeys returns the catalog sort keys, searches can be sorted by relevance too.
+func moviesSortKeys(searchTerm *string) pagination.SortKeys[*Movie] {
+	if searchTerm == nil {
+		return catalogSortKeys
+	}
+
+	keys := maps.Clone(catalogSortKeys)
+	expr, args := relevanceExpr(*searchTerm)
+	keys["relevance"] = pagination.SortKey[*Movie]{Expr: expr, Args: args, Type: "real", Value: func(m *Movie) string { return pagination.FormatFloat(m.Relevance) }}
+
+	return keys
+}
+
+// movieRoles are the values of the movie_role type.
+var movieRoles = []string{"actor", "director", "producer", "writer", "composer", "voice actor"}
+
+const (
+	GenreMatchAny = "any"
+	GenreMatchAll = "all"
+)
+
 type GetMoviesRequest struct {
 	pagination.PaginatedRequestOrdered
-	SearchTerm *string `query:"q"`
+	MoviesFilter
+}
+
+// MoviesFilter narrows the movie catalog, all given filters have to match. GenreMatch tells whether a movie needs
+// any (default) or all of GenreIDs, StarRole limits StarID to one credit role. Ranges include their bounds, except
+// ReleasedBefore.
+type MoviesFilter struct {
+	SearchTerm     *string    `query:"q"`
+	GenreIDs       []int      `query:"genre_ids"`
+	GenreMatch     *string    `query:"genre_match"`
+	StarID         *int       `query:"star_id"`
+	StarRole       *string    `query:"star_role"`
+	ReleasedAfter  *time.Time `query:"released_after"`
+	ReleasedBefore *time.Time `query:"released_before"`
+	MinAvgRating   *float64   `query:"min_avg_rating"`
+	MaxAvgRating   *float64   `query:"max_avg_rating"`
+	MinIMDbRating  *float64   `query:"min_imdb_rating"`
+	MaxIMDbRating  *float64   `query:"max_imdb_rating"`
+	MinMetascore   *int       `query:"min_metascore"`
+	MaxMetascore   *int       `query:"max_metascore"`
+	HasReviews     *bool      `query:"has_reviews"`
 }
 
 type CreateMovieRequest struct {
@@ -2,12 +2,18 @@ package movies
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	stdslices "slices"
+	"strings"
+	"unicode"
 
 	"github.com/Masterminds/squirrel"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/stars"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/genres"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 	"github.com/DavidMovas/Movies-Reviews/internal/slices"
 
 	"github.com/jackc/pgx/v5"
@@ -19,6 +25,15 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// prefixWord is a word which can be matched as a prefix, it can't change the syntax of the query.
+var prefixWord = regexp.MustCompile(`^[\p{L}\p{N}]+$`)
+
+var (
+	errInvalidGenreMatch   = apperrors.BadRequest(errors.New("invalid genre match"))
+	errInvalidStarRole     = apperrors.BadRequest(errors.New("invalid star role"))
+	errStarRoleWithoutStar = apperrors.BadRequest(errors.New("star role requires star id"))
+)
+
 type Repository struct {
 	db         *pgxpool.Pool
 	genresRepo *genres.Repository
@@ -33,25 +48,37 @@ func NewRepository(db *pgxpool.Pool, genresRepo *genres.Repository, starsRepo *s
 	}
 }
 
-func (r *Repository) GetMovies(ctx context.Context, offset int, limit int, sort, order string, searchTerm *string) ([]*Movie, int, error) {
+func (r *Repository) GetMovies(ctx context.Context, filter *MoviesFilter, page *pagination.Page[*Movie]) ([]*Movie, int, error) {
 	selectQuery := dbx.StatementBuilder.Select("id, title, poster_url, release_date, avg_rating, created_at, deleted_at").
 		From("movies").
-		Where(squirrel.Eq{"deleted_at": nil}).
-		OrderBy(sort + " " + order).
-		Limit(uint64(limit)).
-		Offset(uint64(offset))
+		Where(squirrel.Eq{"deleted_at": nil})
 
 	countQuery := dbx.StatementBuilder.Select("COUNT(*)").
 		From("movies").
 		Where(squirrel.Eq{"deleted_at": nil})
 
-	if searchTerm != nil {
-		selectQuery = selectQuery.Where("search_vector @@ to_tsquery('english', ?)", *searchTerm).
-			OrderByClause("ts_rank_cd(search_vector, to_tsquery('english', ?)) DESC", *searchTerm)
+	if err := filter.validate(); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.SearchTerm != nil {
+		relevance, relevanceArgs := relevanceExpr(*filter.SearchTerm)
+		tsquery, tsqueryArgs := searchQuery(*filter.SearchTerm)
 
-		countQuery = countQuery.Where("search_vector @@ to_tsquery('english', ?)", *searchTerm)
+		selectQuery = selectQuery.Column(relevance, relevanceArgs...).
+			Column("ts_headline('english', "+escapeHTML("title")+", "+tsquery+", 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')", tsqueryArgs...).
+			Column("ts_headline('english', "+escapeHTML("description")+", "+tsquery+", 'MaxFragments=2, MaxWords=20, MinWords=5, StartSel=<mark>, StopSel=</mark>')", tsqueryArgs...).
+			Where(searchCondition(*filter.SearchTerm))
+		countQuery = countQuery.Where(searchCondition(*filter.SearchTerm))
 	}
 
+	for _, cond := range filter.conditions() {
+		selectQuery = selectQuery.Where(cond)
+		countQuery = countQuery.Where(cond)
+	}
+
+	selectQuery = dbx.Paginate(selectQuery, page)
+
 	b := &pgx.Batch{}
 
 	if err := dbx.QueryBatchSelect(b, selectQuery); err != nil {
@@ -75,7 +102,13 @@ func (r *Repository) GetMovies(ctx context.Context, offset int, limit int, sort,
 	var movies []*Movie
 	for rows.Next() {
 		var movie Movie
-		if err = rows.Scan(&movie.ID, &movie.Title, &movie.PosterURL, &movie.ReleaseDate, &movie.AvgRating, &movie.CreatedAt, &movie.DeletedAt); err != nil {
+		dest := []any{&movie.ID, &movie.Title, &movie.PosterURL, &movie.ReleaseDate, &movie.AvgRating, &movie.CreatedAt, &movie.DeletedAt}
+		if filter.SearchTerm != nil {
+			movie.Highlights = &Highlights{}
+			dest = append(dest, &movie.Relevance, &movie.Highlights.Title, &movie.Highlights.Description)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
 			return nil, 0, apperrors.Internal(err)
 		}
 		movies = append(movies, &movie)
@@ -93,6 +126,110 @@ func (r *Repository) GetMovies(ctx context.Context, offset int, limit int, sort,
 	return movies, total, nil
 }
 
+// searchQuery returns the full-text query of a search term in web search syntax: "quoted phrases", or, -excluded
+// words. While typing, the last word matches as a prefix: the last lexeme of the parsed query is marked so, the
+// simple configuration keeps the lexemes as they are.
+func searchQuery(term string) (string, []any) {
+	words := strings.Fields(term)
+	if len(words) == 0 || strings.TrimRightFunc(term, unicode.IsSpace) != term || !prefixWord.MatchString(words[len(words)-1]) {
+		return "websearch_to_tsquery('english', ?)", []any{term}
+	}
+
+	return `to_tsquery('simple', regexp_replace(websearch_to_tsquery('english', ?)::text, '''$', ''':*'))`, []any{term}
+}
+
+// searchCondition matches the movies found by the full-text query, or with a title similar to the term, which
+// tolerates typos.
+func searchCondition(term string) squirrel.Sqlizer {
+	tsquery, args := searchQuery(term)
+	return squirrel.Or{
+		squirrel.Expr("search_vector @@ "+tsquery, args...),
+		squirrel.Expr("? <% title", term),
+	}
+}
+
+// escapeHTML escapes the text of the column for HTML, so that the <mark> tags ts_headline adds are the only markup
+// in the highlights. Entities are separate tokens for the text search parser and don't change the matches.
+func escapeHTML(column string) string {
+	return "replace(replace(replace(replace(" + column + ", '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '\"', '&quot;')"
+}
+
+// relevanceExpr ranks the movies found by searchCondition.
+func relevanceExpr(term string) (string, []any) {
+	tsquery, args := searchQuery(term)
+	return "(ts_rank_cd(search_vector, " + tsquery + ") + word_similarity(?, title))", append(args, term)
+}
+
+func (f *MoviesFilter) validate() error {
+	if f.GenreMatch != nil && *f.GenreMatch != GenreMatchAny && *f.GenreMatch != GenreMatchAll {
+		return errInvalidGenreMatch
+	}
+	if f.StarRole != nil {
+		if f.StarID == nil {
+			return errStarRoleWithoutStar
+		}
+		if !stdslices.Contains(movieRoles, *f.StarRole) {
+			return errInvalidStarRole
+		}
+	}
+
+	return nil
+}
+
+func (f *MoviesFilter) conditions() []squirrel.Sqlizer {
+	var conds []squirrel.Sqlizer
+
+	if len(f.GenreIDs) > 0 {
+		genreIDs := stdslices.Compact(stdslices.Sorted(stdslices.Values(f.GenreIDs)))
+		if f.GenreMatch != nil && *f.GenreMatch == GenreMatchAll {
+			conds = append(conds, squirrel.Expr("(SELECT COUNT(*) FROM movie_genres WHERE movie_genres.movie_id = movies.id AND movie_genres.genre_id = ANY(?)) = ?", genreIDs, len(genreIDs)))
+		} else {
+			conds = append(conds, squirrel.Expr("EXISTS (SELECT 1 FROM movie_genres WHERE movie_genres.movie_id = movies.id AND movie_genres.genre_id = ANY(?))", genreIDs))
+		}
+	}
+	if f.StarID != nil {
+		credits := squirrel.And{squirrel.Expr("movie_stars.movie_id = movies.id"), squirrel.Eq{"movie_stars.star_id": *f.StarID}}
+		if f.StarRole != nil {
+			credits = append(credits, squirrel.Eq{"movie_stars.role": *f.StarRole})
+		}
+		conds = append(conds, squirrel.Expr("EXISTS (?)", dbx.StatementBuilder.Select("1").From("movie_stars").Where(credits)))
+	}
+	if f.ReleasedAfter != nil {
+		conds = append(conds, squirrel.GtOrEq{"release_date": *f.ReleasedAfter})
+	}
+	if f.ReleasedBefore != nil {
+		conds = append(conds, squirrel.Lt{"release_date": *f.ReleasedBefore})
+	}
+	if f.MinAvgRating != nil {
+		conds = append(conds, squirrel.GtOrEq{"avg_rating": *f.MinAvgRating})
+	}
+	if f.MaxAvgRating != nil {
+		conds = append(conds, squirrel.LtOrEq{"avg_rating": *f.MaxAvgRating})
+	}
+	if f.MinIMDbRating != nil {
+		conds = append(conds, squirrel.GtOrEq{"imdb_rating": *f.MinIMDbRating})
+	}
+	if f.MaxIMDbRating != nil {
+		conds = append(conds, squirrel.LtOrEq{"imdb_rating": *f.MaxIMDbRating})
+	}
+	if f.MinMetascore != nil {
+		conds = append(conds, squirrel.GtOrEq{"metascore": *f.MinMetascore})
+	}
+	if f.MaxMetascore != nil {
+		conds = append(conds, squirrel.LtOrEq{"metascore": *f.MaxMetascore})
+	}
+	if f.HasReviews != nil {
+		reviewed := "EXISTS (SELECT 1 FROM reviews WHERE reviews.movie_id = movies.id AND reviews.deleted_at IS NULL)"
+		if *f.HasReviews {
+			conds = append(conds, squirrel.Expr(reviewed))
+		} else {
+			conds = append(conds, squirrel.Expr("NOT "+reviewed))
+		}
+	}
+
+	return conds
+}
+
 func (r *Repository) GetMovieByID(ctx context.Context, movieID int) (*MovieDetails, error) {
 	query, args, err := squirrel.Select("id", "title", "poster_url", "description", "imdb_rating", "imdb_url", "metascore", "metascore_url", "release_date", "avg_rating", "created_at", "version").
 		From("movies").
@@ -8,6 +8,7 @@ import (
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/genres"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 )
 
 type Service struct {
@@ -24,8 +25,8 @@ func NewService(repo *Repository, genresRepo *genres.Repository, starsRepo *star
 	}
 }
 
-func (s *Service) GetMovies(ctx context.Context, offset int, limit int, sort, order string, searchTerm *string) ([]*Movie, int, error) {
-	return s.repo.GetMovies(ctx, offset, limit, sort, order, searchTerm)
+func (s *Service) GetMovies(ctx context.Context, filter *MoviesFilter, page *pagination.Page[*Movie]) ([]*Movie, int, error) {
+	return s.repo.GetMovies(ctx, filter, page)
 }
 
 func (s *Service) GetMovieByID(ctx context.Context, movieID int) (*MovieDetails, error) {
@@ -6,6 +6,7 @@ import (
 	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/config"
 	"github.com/labstack/echo/v4"
@@ -42,15 +43,19 @@ func (h *Handler) GetReviewsByMovieID(c echo.Context) error {
 		return err
 	}
 
-	pagination.SetDefaultsOrderedWith(&req.PaginatedRequestOrdered, h.paginationConfig, "created_at", "desc")
-	offset, limit := pagination.OffsetLimit(&req.PaginatedRequest)
+	pagination.SetDefaultsOrderedWith(&req.PaginatedRequestOrdered, h.paginationConfig, "createdAt", "desc")
 
-	reviews, total, err := h.service.GetReviewsByMovieID(c.Request().Context(), req.MovieID, offset, limit, req.Sort, req.Order)
+	page, err := h.page(&req.PaginatedRequestOrdered)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, pagination.ResponseOrdered[*Review](&req.PaginatedRequestOrdered, total, reviews))
+	reviews, total, err := h.service.GetReviewsByMovieID(c.Request().Context(), req.MovieID, page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, pagination.ResponseOrdered(&req.PaginatedRequestOrdered, page, total, reviews))
 }
 
 // GetReviewsByUserID godoc
@@ -72,15 +77,19 @@ func (h *Handler) GetReviewsByUserID(c echo.Context) error {
 		return err
 	}
 
-	pagination.SetDefaultsOrderedWith(&req.PaginatedRequestOrdered, h.paginationConfig, "created_at", "desc")
-	offset, limit := pagination.OffsetLimit(&req.PaginatedRequest)
+	pagination.SetDefaultsOrderedWith(&req.PaginatedRequestOrdered, h.paginationConfig, "createdAt", "desc")
 
-	reviews, total, err := h.service.GetReviewsByUserID(c.Request().Context(), req.UserID, offset, limit, req.Sort, req.Order)
+	page, err := h.page(&req.PaginatedRequestOrdered)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, pagination.ResponseOrdered[*Review](&req.PaginatedRequestOrdered, total, reviews))
+	reviews, total, err := h.service.GetReviewsByUserID(c.Request().Context(), req.UserID, page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, pagination.ResponseOrdered(&req.PaginatedRequestOrdered, page, total, reviews))
 }
 
 // GetReviewByID godoc
@@ -131,7 +140,7 @@ func (h *Handler) CreateReview(c echo.Context) error {
 		return err
 	}
 
-	review, err := h.service.CreateReview(c.Request().Context(), req)
+	review, err := h.service.CreateReview(c.Request().Context(), policy.ActorFromContext(c), req)
 	if err != nil {
 		return err
 	}
@@ -161,7 +170,7 @@ func (h *Handler) UpdateReviewByID(c echo.Context) error {
 		return err
 	}
 
-	review, err := h.service.UpdateReview(c.Request().Context(), req.ReviewID, req)
+	review, err := h.service.UpdateReview(c.Request().Context(), policy.ActorFromContext(c), req)
 	if err != nil {
 		return err
 	}
@@ -189,10 +198,19 @@ func (h *Handler) DeleteReviewByID(c echo.Context) error {
 		return err
 	}
 
-	err = h.service.DeleteReview(c.Request().Context(), req.ReviewID)
+	err = h.service.DeleteReview(c.Request().Context(), policy.ActorFromContext(c), req)
 	if err != nil {
 		return err
 	}
 
 	return c.NoContent(http.StatusOK)
 }
+
+func (h *Handler) page(req *pagination.PaginatedRequestOrdered) (*pagination.Page[*Review], error) {
+	keys, err := pagination.ParseSort(reviewsSortKeys, req.Sort, req.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(&req.PaginatedRequest, keys)
+}
@@ -1,6 +1,7 @@
 package reviews
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
@@ -18,6 +19,14 @@ type Review struct {
 	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
+// reviewsSortKeys are the fields review lists can be sorted by.
+var reviewsSortKeys = pagination.NewSortKeys(map[string]pagination.SortKey[*Review]{
+	"id":         {Expr: "id", Type: "integer", Value: func(r *Review) string { return strconv.Itoa(r.ID) }},
+	"rating":     {Expr: "rating", Type: "integer", Value: func(r *Review) string { return strconv.Itoa(r.Rating) }},
+	"createdAt":  {Expr: "created_at", Type: "timestamp", Value: func(r *Review) string { return pagination.FormatTime(r.CreatedAt) }},
+	"created_at": {Expr: "created_at", Type: "timestamp", Value: func(r *Review) string { return pagination.FormatTime(r.CreatedAt) }},
+})
+
 type GetReviewRequest struct {
 	ReviewID int `json:"-" param:"reviewId" validate:"nonzero"`
 }
@@ -43,7 +52,6 @@ type CreateReviewRequest struct {
 type UpdateReviewRequest struct {
 	UserID   int     `json:"-" param:"userId" validate:"nonzero"`
 	ReviewID int     `json:"-" param:"reviewId" validate:"nonzero"`
-	MovieID  int     `json:"movieId" validate:"nonzero"`
 	Title    *string `json:"title,omitempty" validate:"max=100"`
 	Content  *string `json:"description,omitempty" validate:"max=1000"`
 	Rating   *int    `json:"rating,omitempty" validate:"min=1,max=10"`
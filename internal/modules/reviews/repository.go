@@ -13,6 +13,7 @@ import (
 	"github.com/Masterminds/squirrel"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -29,19 +30,19 @@ func NewRepository(db *pgxpool.Pool, movieRepo *movies.Repository) *Repository {
 	}
 }
 
-func (r *Repository) GetReviewsByMovieID(ctx context.Context, movieID int, offset int, limit int, sort, order string) ([]*Review, int, error) {
+func (r *Repository) GetReviewsByMovieID(ctx context.Context, movieID int, page *pagination.Page[*Review]) ([]*Review, int, error) {
 	selectQuery := dbx.StatementBuilder.Select("id, movie_id, user_id, rating, title, content, created_at, updated_at, deleted_at").
 		From("reviews").
 		Where("movie_id = ?", movieID).
 		Where(squirrel.Eq{"deleted_at": nil}).
-		Offset(uint64(offset)).
-		Limit(uint64(limit)).
-		OrderBy(sort + " " + order)
+		Where("NOT user_suspended(user_id)")
+	selectQuery = dbx.Paginate(selectQuery, page)
 
 	countQuery := dbx.StatementBuilder.Select("COUNT(*)").
 		From("reviews").
 		Where("movie_id = ?", movieID).
-		Where(squirrel.Eq{"deleted_at": nil})
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where("NOT user_suspended(user_id)")
 
 	b := &pgx.Batch{}
 	if err := dbx.QueryBatchSelect(b, selectQuery); err != nil {
@@ -79,19 +80,19 @@ func (r *Repository) GetReviewsByMovieID(ctx context.Context, movieID int, offse
 	return reviews, total, nil
 }
 
-func (r *Repository) GetReviewsByUserID(ctx context.Context, userID int, offset int, limit int, sort, order string) ([]*Review, int, error) {
+func (r *Repository) GetReviewsByUserID(ctx context.Context, userID int, page *pagination.Page[*Review]) ([]*Review, int, error) {
 	selectQuery := dbx.StatementBuilder.Select("id, movie_id, user_id, rating, title, content, created_at, updated_at, deleted_at").
 		From("reviews").
 		Where("user_id = ?", userID).
 		Where(squirrel.Eq{"deleted_at": nil}).
-		Offset(uint64(offset)).
-		Limit(uint64(limit)).
-		OrderBy(sort + " " + order)
+		Where("NOT user_suspended(user_id)")
+	selectQuery = dbx.Paginate(selectQuery, page)
 
 	countQuery := dbx.StatementBuilder.Select("COUNT(*)").
 		From("reviews").
 		Where("user_id = ?", userID).
-		Where(squirrel.Eq{"deleted_at": nil})
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where("NOT user_suspended(user_id)")
 
 	b := &pgx.Batch{}
 	if err := dbx.QueryBatchSelect(b, selectQuery); err != nil {
@@ -130,19 +131,24 @@ func (r *Repository) GetReviewsByUserID(ctx context.Context, userID int, offset
 }
 
 func (r *Repository) GetReviewByID(ctx context.Context, reviewID int) (*Review, error) {
-	builder := dbx.StatementBuilder.Select("id, movie_id, user_id, rating, title, content, created_at, updated_at, deleted_at").
-		From("reviews").
-		Where(squirrel.Eq{"id": reviewID}).
-		Where(squirrel.Eq{"deleted_at": nil})
-
-	query, args, err := builder.ToSql()
+	query, args, err := selectReview(reviewID).ToSql()
 	if err != nil {
 		return nil, apperrors.Internal(err)
 	}
 
+	return scanReview(r.db.QueryRow(ctx, query, args...), reviewID)
+}
+
+func selectReview(reviewID int) squirrel.SelectBuilder {
+	return dbx.StatementBuilder.Select("id, movie_id, user_id, rating, title, content, created_at, updated_at, deleted_at").
+		From("reviews").
+		Where(squirrel.Eq{"id": reviewID}).
+		Where(squirrel.Eq{"deleted_at": nil})
+}
+
+func scanReview(row pgx.Row, reviewID int) (*Review, error) {
 	var review Review
-	err = r.db.QueryRow(ctx, query, args...).
-		Scan(&review.ID, &review.MovieID, &review.UserID, &review.Rating, &review.Title, &review.Content, &review.CreatedAt, &review.UpdatedAt, &review.DeletedAt)
+	err := row.Scan(&review.ID, &review.MovieID, &review.UserID, &review.Rating, &review.Title, &review.Content, &review.CreatedAt, &review.UpdatedAt, &review.DeletedAt)
 
 	switch {
 	case dbx.IsNoRows(err):
@@ -190,20 +196,28 @@ func (r *Repository) CreateReview(ctx context.Context, req *CreateReviewRequest)
 	return &review, nil
 }
 
-func (r *Repository) UpdateReview(ctx context.Context, reviewID int, req *UpdateReviewRequest) (*Review, error) {
-	if req.Rating == nil && req.Title == nil && req.Content == nil {
-		return nil, apperrors.BadRequest(fmt.Errorf("no fields to update"))
-	}
-
+// UpdateReview locks the review, calls check with it and updates it in the same transaction, so the review can't
+// change between the check and the write. The movie to recalculate the rating of is taken from the review, not from
+// the request.
+func (r *Repository) UpdateReview(ctx context.Context, reviewID int, req *UpdateReviewRequest, check func(current *Review) error) (*Review, error) {
 	var review Review
 	err := dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
-		if err := r.movieRepo.Lock(ctx, tx, req.MovieID); err != nil {
+		current, err := r.lockReview(ctx, tx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		if err = check(current); err != nil {
+			return err
+		}
+
+		if err = r.movieRepo.Lock(ctx, tx, current.MovieID); err != nil {
 			return err
 		}
 
 		builder := dbx.StatementBuilder.Update("reviews").
 			Set("updated_at", time.Now()).
-			Where("id = ?", reviewID).
+			Where("id = ?", current.ID).
 			Where(squirrel.Eq{"deleted_at": nil}).
 			Suffix("RETURNING id, movie_id, user_id, rating, title, content, created_at, updated_at, deleted_at")
 
@@ -227,13 +241,13 @@ func (r *Repository) UpdateReview(ctx context.Context, reviewID int, req *Update
 
 		switch {
 		case dbx.IsNoRows(err):
-			return apperrors.NotFound("review", "id", reviewID)
+			return apperrors.NotFound("review", "id", current.ID)
 		case err != nil:
 			return apperrors.Internal(err)
 		}
 
 		if req.Rating != nil {
-			return r.recalculateMovieAverageRating(ctx, req.MovieID)
+			return r.recalculateMovieAverageRating(ctx, review.MovieID)
 		}
 
 		return nil
@@ -245,48 +259,59 @@ func (r *Repository) UpdateReview(ctx context.Context, reviewID int, req *Update
 	return &review, nil
 }
 
-func (r *Repository) DeleteReview(ctx context.Context, reviewID int) error {
-	review, getErr := r.GetReviewByID(ctx, reviewID)
-	if getErr != nil {
-		return getErr
-	}
-
+// DeleteReview locks the review, calls check with it and softly deletes it in the same transaction.
+func (r *Repository) DeleteReview(ctx context.Context, reviewID int, check func(review *Review) error) (*Review, error) {
+	var review *Review
 	err := dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
-		if err := r.movieRepo.Lock(ctx, tx, review.MovieID); err != nil {
+		var err error
+		review, err = r.lockReview(ctx, tx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		if err = check(review); err != nil {
+			return err
+		}
+
+		if err = r.movieRepo.Lock(ctx, tx, review.MovieID); err != nil {
 			return err
 		}
 
 		builder := dbx.StatementBuilder.Update("reviews").
 			Set("deleted_at", time.Now()).
-			Where("id = ?", reviewID).
-			Where(squirrel.Eq{"deleted_at": nil})
+			Where("id = ?", review.ID)
 
 		query, args, err := builder.ToSql()
 		if err != nil {
 			return apperrors.Internal(err)
 		}
 
-		n, err := tx.Exec(ctx, query, args...)
-		if err != nil {
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
 			return apperrors.Internal(err)
 		}
 
-		if n.RowsAffected() == 0 {
-			return apperrors.NotFound("review", "id", reviewID)
-		}
-
 		return r.recalculateMovieAverageRating(ctx, review.MovieID)
 	})
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	return nil
+	return review, nil
+}
+
+// lockReview returns the review and locks it until the end of the transaction.
+func (r *Repository) lockReview(ctx context.Context, tx pgx.Tx, reviewID int) (*Review, error) {
+	query, args, err := selectReview(reviewID).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return scanReview(tx.QueryRow(ctx, query, args...), reviewID)
 }
 
 func (r *Repository) recalculateMovieAverageRating(ctx context.Context, movieID int) error {
 	q := dbx.FromContext(ctx, r.db)
-	n, err := q.Exec(ctx, `UPDATE movies SET avg_rating = (SELECT AVG(rating) FROM reviews WHERE deleted_at IS NULL AND movie_id = $1) WHERE id = $1`, movieID)
+	n, err := q.Exec(ctx, `UPDATE movies SET avg_rating = movie_avg_rating(id) WHERE id = $1`, movieID)
 	if err != nil {
 		return apperrors.Internal(err)
 	}
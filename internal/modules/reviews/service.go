@@ -2,10 +2,13 @@ package reviews
 
 import (
 	"context"
+	"fmt"
 
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 )
 
 type Service struct {
@@ -18,19 +21,23 @@ func NewService(repo *Repository) *Service {
 	}
 }
 
-func (s *Service) GetReviewsByMovieID(ctx context.Context, movieID int, offset int, limit int, sort, order string) ([]*Review, int, error) {
-	return s.repo.GetReviewsByMovieID(ctx, movieID, offset, limit, sort, order)
+func (s *Service) GetReviewsByMovieID(ctx context.Context, movieID int, page *pagination.Page[*Review]) ([]*Review, int, error) {
+	return s.repo.GetReviewsByMovieID(ctx, movieID, page)
 }
 
-func (s *Service) GetReviewsByUserID(ctx context.Context, userID int, offset int, limit int, sort, order string) ([]*Review, int, error) {
-	return s.repo.GetReviewsByUserID(ctx, userID, offset, limit, sort, order)
+func (s *Service) GetReviewsByUserID(ctx context.Context, userID int, page *pagination.Page[*Review]) ([]*Review, int, error) {
+	return s.repo.GetReviewsByUserID(ctx, userID, page)
 }
 
 func (s *Service) GetReviewByID(ctx context.Context, reviewID int) (*Review, error) {
 	return s.repo.GetReviewByID(ctx, reviewID)
 }
 
-func (s *Service) CreateReview(ctx context.Context, req *CreateReviewRequest) (*Review, error) {
+func (s *Service) CreateReview(ctx context.Context, actor *policy.Actor, req *CreateReviewRequest) (*Review, error) {
+	if err := policy.CreateReview(actor, req.UserID); err != nil {
+		return nil, err
+	}
+
 	review, err := s.repo.CreateReview(ctx, req)
 	if err != nil {
 		return nil, err
@@ -41,14 +48,20 @@ func (s *Service) CreateReview(ctx context.Context, req *CreateReviewRequest) (*
 	return review, nil
 }
 
-func (s *Service) UpdateReview(ctx context.Context, reviewID int, req *UpdateReviewRequest) (*Review, error) {
-	review, err := s.repo.UpdateReview(ctx, reviewID, req)
-	if err != nil {
-		return nil, err
+func (s *Service) UpdateReview(ctx context.Context, actor *policy.Actor, req *UpdateReviewRequest) (*Review, error) {
+	if req.Rating == nil && req.Title == nil && req.Content == nil {
+		return nil, apperrors.BadRequest(fmt.Errorf("no fields to update"))
 	}
 
-	if review.UserID != req.UserID {
-		return nil, apperrors.Forbidden("insufficient permissions")
+	review, err := s.repo.UpdateReview(ctx, req.ReviewID, req, func(current *Review) error {
+		if err := checkUserReview(current, req.UserID); err != nil {
+			return err
+		}
+
+		return policy.UpdateReview(actor, current.UserID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	log.FromContext(ctx).Info("review updated", "review_id", review.ID)
@@ -56,11 +69,27 @@ func (s *Service) UpdateReview(ctx context.Context, reviewID int, req *UpdateRev
 	return review, nil
 }
 
-func (s *Service) DeleteReview(ctx context.Context, reviewID int) error {
-	if err := s.repo.DeleteReview(ctx, reviewID); err != nil {
+func (s *Service) DeleteReview(ctx context.Context, actor *policy.Actor, req *DeleteReviewRequest) error {
+	review, err := s.repo.DeleteReview(ctx, req.ReviewID, func(review *Review) error {
+		if err := checkUserReview(review, req.UserID); err != nil {
+			return err
+		}
+
+		return policy.DeleteReview(actor, review.UserID)
+	})
+	if err != nil {
 		return err
 	}
 
-	log.FromContext(ctx).Info("review deleted", "review_id", reviewID)
+	log.FromContext(ctx).Info("review deleted", "review_id", review.ID)
+	return nil
+}
+
+// checkUserReview accepts the review only if it's written by the user, so that the user id in the path can be trusted.
+func checkUserReview(review *Review, userID int) error {
+	if review.UserID != userID {
+		return apperrors.NotFound("review", "id", review.ID)
+	}
+
 	return nil
 }
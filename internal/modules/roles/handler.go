@@ -0,0 +1,153 @@
+package roles
+
+import (
+	"net/http"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	*Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		Service: service,
+	}
+}
+
+// GetRoles @Summary Get all roles
+// @Description Get all roles with their permissions
+// @ID get-roles
+// @Tags roles
+// @Produce json
+// @Success 200 {array} Role "Roles"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /roles [get]
+func (h *Handler) GetRoles(c echo.Context) error {
+	roles, err := h.Service.GetRoles(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// GetRoleByName @Summary Get role by name
+// @Description Get role by name with its permissions
+// @ID get-role-by-name
+// @Tags roles
+// @Param role path string true "Role name"
+// @Produce json
+// @Success 200 {object} Role "Role"
+// @Failure 400 {object} apperrors.Error "Invalid role name"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Role not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /roles/{role} [get]
+func (h *Handler) GetRoleByName(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetRoleRequest](c)
+	if err != nil {
+		return err
+	}
+
+	role, err := h.Service.GetRoleByName(c.Request().Context(), req.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// GetPermissions @Summary Get all permissions
+// @Description Get all permissions which can be granted to roles
+// @ID get-permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {array} Permission "Permissions"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /permissions [get]
+func (h *Handler) GetPermissions(c echo.Context) error {
+	permissions, err := h.Service.GetPermissions(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, permissions)
+}
+
+// CreateRole @Summary Create new role
+// @Description Create new role with the given permissions
+// @ID create-role
+// @Tags roles
+// @Param role body CreateRoleRequest true "Role"
+// @Produce json
+// @Success 201 {object} Role "Role"
+// @Failure 400 {object} apperrors.Error "Invalid parameter, unknown permission or missing parameter"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 409 {object} apperrors.Error "Role with that name already exists"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /roles [post]
+func (h *Handler) CreateRole(c echo.Context) error {
+	req, err := echox.BindAndValidate[CreateRoleRequest](c)
+	if err != nil {
+		return err
+	}
+
+	role, err := h.Service.CreateRole(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole @Summary Update role
+// @Description Replace the description and the permissions of the role. Access tokens of its users are revoked
+// @ID update-role
+// @Tags roles
+// @Param role path string true "Role name"
+// @Param body body UpdateRoleRequest true "Role"
+// @Produce json
+// @Success 200 {object} Role "Role"
+// @Failure 400 {object} apperrors.Error "Invalid parameter, unknown permission or the admin role"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Role not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /roles/{role} [put]
+func (h *Handler) UpdateRole(c echo.Context) error {
+	req, err := echox.BindAndValidate[UpdateRoleRequest](c)
+	if err != nil {
+		return err
+	}
+
+	role, err := h.Service.UpdateRole(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole @Summary Delete role
+// @Description Delete role which is not built-in and not assigned to any user
+// @ID delete-role
+// @Tags roles
+// @Param role path string true "Role name"
+// @Success 200 "Role deleted"
+// @Failure 400 {object} apperrors.Error "Invalid role name, built-in role or role in use"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Role not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /roles/{role} [delete]
+func (h *Handler) DeleteRole(c echo.Context) error {
+	req, err := echox.BindAndValidate[DeleteRoleRequest](c)
+	if err != nil {
+		return err
+	}
+
+	return h.Service.DeleteRole(c.Request().Context(), req.Name)
+}
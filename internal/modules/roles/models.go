@@ -0,0 +1,47 @@
+package roles
+
+import "time"
+
+const (
+	GenresWritePermission       = "genres.write"
+	StarsWritePermission        = "stars.write"
+	MoviesWritePermission       = "movies.write"
+	ReviewsModeratePermission   = "reviews.moderate"
+	UsersManagePermission       = "users.manage"
+	UsersImpersonatePermission  = "users.impersonate"
+	RolesManagePermission       = "roles.manage"
+	InvitationsManagePermission = "invitations.manage"
+)
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	BuiltIn     bool      `json:"builtIn"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type GetRoleRequest struct {
+	Name string `json:"-" param:"role" validate:"role"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"role"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Name        string   `json:"-" param:"role" validate:"role"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+type DeleteRoleRequest struct {
+	Name string `json:"-" param:"role" validate:"role"`
+}
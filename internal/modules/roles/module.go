@@ -0,0 +1,24 @@
+package roles
+
+import (
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Module struct {
+	Handler    *Handler
+	Service    *Service
+	Repository *Repository
+}
+
+func NewModule(db *pgxpool.Pool, jwtService *jwt.Service) *Module {
+	repo := NewRepository(db)
+	service := NewService(repo, jwtService)
+	handler := NewHandler(service)
+
+	return &Module{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
@@ -0,0 +1,283 @@
+package roles
+
+import (
+	"context"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const rolePermissionsColumn = "COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')"
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) GetRoles(ctx context.Context) ([]*Role, error) {
+	query, args, err := selectRoles().
+		OrderBy("r.created_at", "r.name").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	roles := make([]*Role, 0)
+	for rows.Next() {
+		role, scanErr := scanRole(rows)
+		if scanErr != nil {
+			return nil, apperrors.Internal(scanErr)
+		}
+		roles = append(roles, role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return roles, nil
+}
+
+func (r *Repository) GetRoleByName(ctx context.Context, name string) (*Role, error) {
+	query, args, err := selectRoles().
+		Where(squirrel.Eq{"r.name": name}).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	role, err := scanRole(r.db.QueryRow(ctx, query, args...))
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, apperrors.NotFound("role", "name", name)
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return role, nil
+}
+
+// GetRolePermissions returns the permissions granted by the role, an unknown role grants none.
+func (r *Repository) GetRolePermissions(ctx context.Context, name string) ([]string, error) {
+	query, args, err := dbx.StatementBuilder.Select("permission").
+		From("role_permissions").
+		Where(squirrel.Eq{"role": name}).
+		OrderBy("permission").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	permissions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return permissions, nil
+}
+
+func (r *Repository) GetPermissions(ctx context.Context) ([]*Permission, error) {
+	query, args, err := dbx.StatementBuilder.Select("name, description").
+		From("permissions").
+		OrderBy("name").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	permissions := make([]*Permission, 0)
+	for rows.Next() {
+		var permission Permission
+		if err = rows.Scan(&permission.Name, &permission.Description); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		permissions = append(permissions, &permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return permissions, nil
+}
+
+// GetRoleUserIDs returns ids of the existing users with the role.
+func (r *Repository) GetRoleUserIDs(ctx context.Context, name string) ([]int, error) {
+	query, args, err := dbx.StatementBuilder.Select("id").
+		From("users").
+		Where(squirrel.Eq{"role": name}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return ids, nil
+}
+
+func (r *Repository) CreateRole(ctx context.Context, role *Role) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Insert("roles").
+			Columns("name", "description").
+			Values(role.Name, role.Description).
+			Suffix("RETURNING built_in, created_at").
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		err = tx.QueryRow(ctx, query, args...).Scan(&role.BuiltIn, &role.CreatedAt)
+		switch {
+		case dbx.IsUniqueViolation(err, "roles_pkey"):
+			return apperrors.AlreadyExists("role", "name", role.Name)
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		return r.insertRolePermissions(ctx, role.Name, role.Permissions)
+	})
+}
+
+// UpdateRole replaces the description and the permissions of the role.
+func (r *Repository) UpdateRole(ctx context.Context, role *Role) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("roles").
+			Set("description", role.Description).
+			Where(squirrel.Eq{"name": role.Name}).
+			Suffix("RETURNING built_in, created_at").
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		err = tx.QueryRow(ctx, query, args...).Scan(&role.BuiltIn, &role.CreatedAt)
+		switch {
+		case dbx.IsNoRows(err):
+			return apperrors.NotFound("role", "name", role.Name)
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		query, args, err = dbx.StatementBuilder.Delete("role_permissions").
+			Where(squirrel.Eq{"role": role.Name}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return apperrors.Internal(err)
+		}
+
+		return r.insertRolePermissions(ctx, role.Name, role.Permissions)
+	})
+}
+
+// DeleteRole deletes a role which is neither built-in nor assigned to any user, deleted users included.
+func (r *Repository) DeleteRole(ctx context.Context, name string) error {
+	query, args, err := dbx.StatementBuilder.Delete("roles").
+		Where(squirrel.Eq{"name": name}).
+		Suffix("RETURNING built_in").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		var builtIn bool
+		err = tx.QueryRow(ctx, query, args...).Scan(&builtIn)
+		switch {
+		case dbx.IsNoRows(err):
+			return apperrors.NotFound("role", "name", name)
+		case dbx.IsForeignKeyViolation(err, "users_role_fkey"):
+			return errRoleInUse
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		// Rolls the deletion back
+		if builtIn {
+			return errBuiltInRole
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) insertRolePermissions(ctx context.Context, name string, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	builder := dbx.StatementBuilder.Insert("role_permissions").
+		Columns("role", "permission")
+	for _, permission := range permissions {
+		builder = builder.Values(name, permission)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	_, err = dbx.FromContext(ctx, r.db).Exec(ctx, query, args...)
+	switch {
+	case dbx.IsForeignKeyViolation(err, "role_permissions_permission_fkey"):
+		return errUnknownPermission
+	case err != nil:
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func selectRoles() squirrel.SelectBuilder {
+	return dbx.StatementBuilder.Select("r.name, r.description, r.built_in, r.created_at", rolePermissionsColumn).
+		From("roles r").
+		LeftJoin("role_permissions rp ON rp.role = r.name").
+		GroupBy("r.name")
+}
+
+func scanRole(row pgx.Row) (*Role, error) {
+	var role Role
+	if err := row.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &role.Permissions); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
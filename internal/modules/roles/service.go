@@ -0,0 +1,110 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/DavidMovas/Movies-Reviews/contracts"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/log"
+)
+
+var (
+	errUnknownPermission = apperrors.BadRequest(errors.New("unknown permission"))
+	errBuiltInRole       = apperrors.BadRequest(errors.New("built-in roles can't be deleted"))
+	errAdminRole         = apperrors.BadRequest(errors.New("the admin role always has every permission"))
+	errRoleInUse         = apperrors.BadRequest(errors.New("role is assigned to users"))
+)
+
+type Service struct {
+	repo       *Repository
+	jwtService *jwt.Service
+}
+
+func NewService(repo *Repository, jwtService *jwt.Service) *Service {
+	return &Service{
+		repo:       repo,
+		jwtService: jwtService,
+	}
+}
+
+func (s *Service) GetRoles(ctx context.Context) ([]*Role, error) {
+	return s.repo.GetRoles(ctx)
+}
+
+func (s *Service) GetRoleByName(ctx context.Context, name string) (*Role, error) {
+	return s.repo.GetRoleByName(ctx, name)
+}
+
+func (s *Service) GetRolePermissions(ctx context.Context, name string) ([]string, error) {
+	return s.repo.GetRolePermissions(ctx, name)
+}
+
+func (s *Service) GetPermissions(ctx context.Context) ([]*Permission, error) {
+	return s.repo.GetPermissions(ctx)
+}
+
+func (s *Service) CreateRole(ctx context.Context, req *CreateRoleRequest) (*Role, error) {
+	role := &Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: normalizePermissions(req.Permissions),
+	}
+
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("role created", "role", role.Name, "permissions", role.Permissions)
+	return role, nil
+}
+
+func (s *Service) UpdateRole(ctx context.Context, req *UpdateRoleRequest) (*Role, error) {
+	if req.Name == contracts.AdminRole {
+		return nil, errAdminRole
+	}
+
+	role := &Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: normalizePermissions(req.Permissions),
+	}
+
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		return nil, err
+	}
+
+	// Access tokens carry the permissions, so the old ones must not outlive the change
+	userIDs, err := s.repo.GetRoleUserIDs(ctx, role.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range userIDs {
+		if err = s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+	}
+
+	log.FromContext(ctx).Info("role updated", "role", role.Name, "permissions", role.Permissions)
+	return role, nil
+}
+
+func (s *Service) DeleteRole(ctx context.Context, name string) error {
+	if err := s.repo.DeleteRole(ctx, name); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("role deleted", "role", name)
+	return nil
+}
+
+func normalizePermissions(permissions []string) []string {
+	if len(permissions) == 0 {
+		return make([]string, 0)
+	}
+
+	return slices.Compact(slices.Sorted(slices.Values(permissions)))
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"net/http"
+
+	"github.com/golang/groupcache/singleflight"
+	"github.com/labstack/echo/v4"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+)
+
+type Handler struct {
+	service *Service
+
+	reqGroup singleflight.Group
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Suggest godoc
+// @Summary      Suggest movies, stars and genres
+// @Description  Suggest movies, stars and genres matching what the user is typing: names starting with the query first, then names with a word starting with it, then similar names
+// @ID           search-suggest
+// @Tags         search
+// @Produce      json
+// @Param        q query string true "Query"
+// @Success      200 {array} Suggestion "Suggestions, best matches first"
+// @Failure      400 {object} apperrors.Error "Invalid request, missing or too long query"
+// @Failure      500 {object} apperrors.Error "Internal server error"
+// @Router       /search/suggest [get]
+func (h *Handler) Suggest(c echo.Context) error {
+	res, err := h.reqGroup.Do(c.Request().RequestURI, func() (any, error) {
+		req, err := echox.BindAndValidate[SuggestRequest](c)
+		if err != nil {
+			return nil, err
+		}
+
+		return h.service.Suggest(c.Request().Context(), req.Query)
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
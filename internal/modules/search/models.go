@@ -0,0 +1,22 @@
+package search
+
+const (
+	MovieType = "movie"
+	StarType  = "star"
+	GenreType = "genre"
+)
+
+// Suggestion is a movie, star or genre matching what the user is typing. Movies come with their release year,
+// stars with the title of their best rated movie.
+type Suggestion struct {
+	Type     string  `json:"type"`
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Year     *int    `json:"year,omitempty"`
+	KnownFor *string `json:"knownFor,omitempty"`
+	Score    float64 `json:"-"`
+}
+
+type SuggestRequest struct {
+	Query string `json:"-" query:"q" validate:"min=1,max=100"`
+}
@@ -0,0 +1,28 @@
+package search
+
+import (
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Module struct {
+	Handler    *Handler
+	Service    *Service
+	Repository *Repository
+}
+
+func NewModule(db *pgxpool.Pool, cfg config.SearchConfig) *Module {
+	repo := NewRepository(db)
+	service := NewService(repo, Limits{
+		Movies: cfg.SuggestMovies,
+		Stars:  cfg.SuggestStars,
+		Genres: cfg.SuggestGenres,
+	})
+	handler := NewHandler(service)
+
+	return &Module{
+		Handler:    handler,
+		Service:    service,
+		Repository: repo,
+	}
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+)
+
+const starNameExpr = "(first_name || ' ' || last_name)"
+
+// knownForExpr is the title of the best rated movie of a star.
+const knownForExpr = `(
+	SELECT m.title FROM movie_stars ms
+	JOIN movies m ON m.id = ms.movie_id
+	WHERE ms.star_id = stars.id AND m.deleted_at IS NULL
+	ORDER BY m.avg_rating DESC NULLS LAST, m.release_date DESC
+	LIMIT 1
+)`
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// Limits is the maximum number of suggestions of each type.
+type Limits struct {
+	Movies int
+	Stars  int
+	Genres int
+}
+
+// Suggest returns the best matches of each type, scored by match and ordered by score.
+func (r *Repository) Suggest(ctx context.Context, query string, limits Limits) ([]*Suggestion, error) {
+	moviesQuery := suggestQuery("title", query, limits.Movies).
+		Columns("EXTRACT(YEAR FROM release_date)::int", "NULL::varchar").
+		From("movies").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	starsQuery := suggestQuery(starNameExpr, query, limits.Stars).
+		Columns("NULL::int", knownForExpr).
+		From("stars").
+		Where(squirrel.Eq{"deleted_at": nil})
+
+	genresQuery := suggestQuery("name", query, limits.Genres).
+		Columns("NULL::int", "NULL::varchar").
+		From("genres")
+
+	b := &pgx.Batch{}
+	for _, q := range []squirrel.SelectBuilder{moviesQuery, starsQuery, genresQuery} {
+		if err := dbx.QueryBatchSelect(b, q); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+	}
+
+	br := r.db.SendBatch(ctx, b)
+	defer func() {
+		_ = br.Close()
+	}()
+
+	var suggestions []*Suggestion
+	for _, suggestionType := range []string{MovieType, StarType, GenreType} {
+		rows, err := br.Query()
+		if err != nil {
+			return nil, apperrors.Internal(err)
+		}
+
+		for rows.Next() {
+			suggestion := Suggestion{Type: suggestionType}
+			if err = rows.Scan(&suggestion.ID, &suggestion.Name, &suggestion.Score, &suggestion.Year, &suggestion.KnownFor); err != nil {
+				rows.Close()
+				return nil, apperrors.Internal(err)
+			}
+			suggestions = append(suggestions, &suggestion)
+		}
+
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// suggestQuery selects the id, name and score of the best matches of the name expression. Names starting with the
+// query score highest, then names with a word starting with it, then similar names, which tolerates typos.
+// Every branch of the condition is served by the trigram index on the name expression, ILIKE included.
+func suggestQuery(nameExpr, query string, limit int) squirrel.SelectBuilder {
+	prefix := likeEscaper.Replace(query) + "%"
+
+	return dbx.StatementBuilder.Select("id", nameExpr).
+		Column("CASE WHEN "+nameExpr+" ILIKE ? THEN 1 WHEN "+nameExpr+" ILIKE ? THEN 0.5 ELSE 0 END + word_similarity(?, "+nameExpr+") AS score", prefix, "% "+prefix, query).
+		Where(squirrel.Or{
+			squirrel.Expr(nameExpr+" ILIKE ?", prefix),
+			squirrel.Expr(nameExpr+" ILIKE ?", "% "+prefix),
+			squirrel.Expr("? <% "+nameExpr, query),
+		}).
+		OrderBy("score DESC", "id").
+		Limit(uint64(limit))
+}
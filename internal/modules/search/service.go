@@ -0,0 +1,39 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+)
+
+type Service struct {
+	repo   *Repository
+	limits Limits
+}
+
+func NewService(repo *Repository, limits Limits) *Service {
+	return &Service{
+		repo:   repo,
+		limits: limits,
+	}
+}
+
+// Suggest returns the movies, stars and genres matching the query, best matches first.
+func (s *Service) Suggest(ctx context.Context, query string) ([]*Suggestion, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return []*Suggestion{}, nil
+	}
+
+	suggestions, err := s.repo.Suggest(ctx, query, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(suggestions, func(a, b *Suggestion) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return suggestions, nil
+}
@@ -34,9 +34,9 @@ func NewHandler(service *Service, paginationConfig *config.PaginationConfig) *Ha
 // @ID           get-stars
 // @Tags         stars
 // @Produce      json
-// @Param        request body contracts.PaginatedRequest false "Request, if request body empty, default values will be used"
-// @Success      200 {object} pagination.PaginatedResponse[contracts.Star] "PaginatedResponse of Stars, total number of stars, or nil if none found"
-// @Failure      400 {object} apperrors.Error "Invalid request, invalid parameter or missing parameter"
+// @Param        request body contracts.GetStarsRequest false "Request, if request body empty, default values will be used"
+// @Success      200 {object} pagination.PaginatedResponseOrdered[contracts.Star] "PaginatedResponse of Stars, total number of stars, or nil if none found"
+// @Failure      400 {object} apperrors.Error "Invalid request, invalid parameter, missing parameter or invalid sort field"
 // @Failure      500 {object} apperrors.Error "Internal server error"
 // @Router       /stars [get]
 func (h *Handler) GetStars(c echo.Context) error {
@@ -46,15 +46,24 @@ func (h *Handler) GetStars(c echo.Context) error {
 			return nil, err
 		}
 
-		pagination.SetDefaults(&req.PaginatedRequest, h.paginationConfig)
-		offset, limit := pagination.OffsetLimit(&req.PaginatedRequest)
+		pagination.SetDefaultsOrdered(&req.PaginatedRequestOrdered, h.paginationConfig)
+
+		keys, err := pagination.ParseSort(starsSortKeys, req.Sort, req.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := pagination.NewPage(&req.PaginatedRequest, keys)
+		if err != nil {
+			return nil, err
+		}
 
-		stars, total, err := h.Service.GetStarsPaginated(c.Request().Context(), offset, limit)
+		stars, total, err := h.Service.GetStarsPaginated(c.Request().Context(), page)
 		if err != nil {
 			return nil, err
 		}
 
-		return pagination.Response[*Star](&req.PaginatedRequest, total, stars), nil
+		return pagination.ResponseOrdered(&req.PaginatedRequestOrdered, page, total, stars), nil
 	})
 	if err != nil {
 		return err
@@ -1,6 +1,7 @@
 package stars
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
@@ -25,8 +26,17 @@ type GetStarRequest struct {
 	StarID int `json:"-" param:"starId" validate:"nonzero"`
 }
 
+// starsSortKeys are the fields the stars list can be sorted by.
+var starsSortKeys = pagination.NewSortKeys(map[string]pagination.SortKey[*Star]{
+	"id":        {Expr: "id", Type: "integer", Value: func(s *Star) string { return strconv.Itoa(s.ID) }},
+	"firstName": {Expr: "first_name", Type: "varchar", Value: func(s *Star) string { return s.FirstName }},
+	"lastName":  {Expr: "last_name", Type: "varchar", Value: func(s *Star) string { return s.LastName }},
+	"birthDate": {Expr: "birth_date", Type: "date", Value: func(s *Star) string { return pagination.FormatTime(s.BirthDate) }},
+	"createdAt": {Expr: "created_at", Type: "timestamp", Value: func(s *Star) string { return pagination.FormatTime(s.CreatedAt) }},
+})
+
 type GetStarsRequest struct {
-	pagination.PaginatedRequest
+	pagination.PaginatedRequestOrdered
 }
 
 type MovieStarsRelation struct {
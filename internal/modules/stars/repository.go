@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
 
@@ -71,13 +72,11 @@ func (r *Repository) GetRelationsByMovieID(ctx context.Context, movieID int) ([]
 	return relations, nil
 }
 
-func (r *Repository) GetStarsPaginated(ctx context.Context, offset int, limit int) ([]*Star, int, error) {
+func (r *Repository) GetStarsPaginated(ctx context.Context, page *pagination.Page[*Star]) ([]*Star, int, error) {
 	selectQuery := dbx.StatementBuilder.Select("id, first_name, middle_name, last_name, avatar_url, birth_date, birth_place, death_date, bio, created_at, deleted_at").
 		From("stars").
-		Where(squirrel.Eq{"deleted_at": nil}).
-		OrderBy("id").
-		Limit(uint64(limit)).
-		Offset(uint64(offset))
+		Where(squirrel.Eq{"deleted_at": nil})
+	selectQuery = dbx.Paginate(selectQuery, page)
 
 	countQuery := dbx.StatementBuilder.Select("COUNT(*)").
 		From("stars").
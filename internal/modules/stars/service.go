@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
 )
 
 type Service struct {
@@ -24,8 +25,8 @@ func (s *Service) GetStarByID(ctx context.Context, starID int) (*Star, error) {
 	return s.repo.GetStarByID(ctx, starID)
 }
 
-func (s *Service) GetStarsPaginated(ctx context.Context, offset int, limit int) ([]*Star, int, error) {
-	return s.repo.GetStarsPaginated(ctx, offset, limit)
+func (s *Service) GetStarsPaginated(ctx context.Context, page *pagination.Page[*Star]) ([]*Star, int, error) {
+	return s.repo.GetStarsPaginated(ctx, page)
 }
 
 func (s *Service) CreateStar(ctx context.Context, req *CreateStarRequest) (*Star, error) {
@@ -5,22 +5,74 @@ import (
 
 	"github.com/golang/groupcache/singleflight"
 
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
 	"github.com/DavidMovas/Movies-Reviews/internal/echox"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
 	"github.com/labstack/echo/v4"
 )
 
 type Handler struct {
-	service *Service
+	service          *Service
+	paginationConfig *config.PaginationConfig
 
 	reqGroup singleflight.Group
 }
 
-func NewHandler(service *Service) *Handler {
+func NewHandler(service *Service, paginationConfig *config.PaginationConfig) *Handler {
 	return &Handler{
-		service: service,
+		service:          service,
+		paginationConfig: paginationConfig,
 	}
 }
 
+// GetUsers @Summary Get users
+// @Description Get a page of all users, including deleted ones, with the number of their reviews.
+// @Description Filter by a prefix of the username or email (q), role, created_after, created_before and deleted,
+// @Description sort by createdAt (default, newest first), reviewsCount, username or id
+// @ID get-users
+// @Tags users
+// @Param q query string false "Prefix of the username or email"
+// @Param role query string false "Role"
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Param deleted query bool false "Only deleted (true) or only active (false) users"
+// @Param page query int false "Page"
+// @Param size query int false "Page size"
+// @Param sort query string false "Comma separated id, username, createdAt or reviewsCount, prefixed with - for descending"
+// @Param order query string false "asc or desc"
+// @Produce json
+// @Success 200 {object} pagination.PaginatedResponseOrdered[DirectoryUser] "Users"
+// @Failure 400 {object} apperrors.Error "Invalid filter or sort field"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users [get]
+func (h *Handler) GetUsers(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetUsersRequest](c)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetDefaultsOrderedWith(&req.PaginatedRequestOrdered, h.paginationConfig, "createdAt", "desc")
+
+	keys, err := pagination.ParseSort(usersSortKeys, req.Sort, req.Order)
+	if err != nil {
+		return err
+	}
+
+	page, err := pagination.NewPage(&req.PaginatedRequest, keys)
+	if err != nil {
+		return err
+	}
+
+	users, total, err := h.service.GetUsers(c.Request().Context(), &req.UsersFilter, page)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, pagination.ResponseOrdered(&req.PaginatedRequestOrdered, page, total, users))
+}
+
 // GetExistingUserByID @Summary Get existing user by id
 // @Description Get existing user by id
 // @ID get-existing-user-by-id
@@ -105,7 +157,7 @@ func (h *Handler) UpdateExistingUserByID(c echo.Context) error {
 		return err
 	}
 
-	user, err := h.service.UpdateExistingUserByID(c.Request().Context(), req.UserID, req)
+	user, err := h.service.UpdateExistingUserByID(c.Request().Context(), policy.ActorFromContext(c), req)
 	if err != nil {
 		return err
 	}
@@ -121,8 +173,8 @@ func (h *Handler) UpdateExistingUserByID(c echo.Context) error {
 // @Param role path string true "Role"
 // @Produce json
 // @Success 200 "User role updated"
-// @Failure 400 {object} apperrors.Error "Invalid user id, invalid parameter or missing parameter"
-// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 400 {object} apperrors.Error "Invalid user id, invalid role or own role"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions, including the current or the new role granting permissions the caller lacks"
 // @Failure 404 {object} apperrors.Error "User not found"
 // @Failure 500 {object} apperrors.Error "Internal server error"
 // @Router /users/{userId}/role/{role} [put]
@@ -132,7 +184,7 @@ func (h *Handler) UpdateUserRoleByID(c echo.Context) error {
 		return err
 	}
 
-	if err = h.service.UpdateUserRoleByID(c.Request().Context(), req.UserID, req.Role); err != nil {
+	if err = h.service.UpdateUserRoleByID(c.Request().Context(), policy.ActorFromContext(c), req.UserID, req.Role); err != nil {
 		return err
 	}
 
@@ -159,3 +211,105 @@ func (h *Handler) DeleteExistingUserByID(c echo.Context) error {
 
 	return h.service.DeleteExistingUserByID(c.Request().Context(), req.UserID)
 }
+
+// RestoreUserByID @Summary Restore deleted user by id
+// @Description Restore a softly deleted user. Anonymized users can't be restored
+// @ID restore-user-by-id
+// @Tags users
+// @Param userId path int true "User ID"
+// @Produce json
+// @Success 200 {object} contracts.User "User restored"
+// @Failure 400 {object} apperrors.Error "Invalid user id, invalid parameter or missing parameter"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Deleted user not found"
+// @Failure 409 {object} apperrors.Error "Username or email is taken by another user"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/restore [post]
+func (h *Handler) RestoreUserByID(c echo.Context) error {
+	req, err := echox.BindAndValidate[RestoreUserRequest](c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.service.RestoreUserByID(c.Request().Context(), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser @Summary Suspend user
+// @Description Suspend the user with a reason until expiresAt, or permanently without it. Suspended users can't log in
+// @Description or use their tokens, and their reviews are hidden from the listings
+// @ID suspend-user
+// @Tags users
+// @Param userId path int true "User ID"
+// @Param suspension body contracts.SuspendUserRequest true "Suspension"
+// @Accept json
+// @Produce json
+// @Success 201 {object} contracts.Suspension "Suspension"
+// @Failure 400 {object} apperrors.Error "Invalid parameter, expiration in the past or suspending yourself"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "User not found"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/suspensions [post]
+func (h *Handler) SuspendUser(c echo.Context) error {
+	req, err := echox.BindAndValidate[SuspendUserRequest](c)
+	if err != nil {
+		return err
+	}
+
+	suspension, err := h.service.SuspendUser(c.Request().Context(), policy.ActorFromContext(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, suspension)
+}
+
+// GetSuspensions @Summary Get user suspensions
+// @Description Get all suspensions of the user, including lifted and expired ones, newest first
+// @ID get-suspensions
+// @Tags users
+// @Param userId path int true "User ID"
+// @Produce json
+// @Success 200 {array} contracts.Suspension "Suspensions"
+// @Failure 400 {object} apperrors.Error "Invalid user id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/suspensions [get]
+func (h *Handler) GetSuspensions(c echo.Context) error {
+	req, err := echox.BindAndValidate[GetSuspensionsRequest](c)
+	if err != nil {
+		return err
+	}
+
+	suspensions, err := h.service.GetSuspensions(c.Request().Context(), req.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, suspensions)
+}
+
+// LiftSuspension @Summary Lift user suspension
+// @Description Lift the suspension before it expires
+// @ID lift-suspension
+// @Tags users
+// @Param userId path int true "User ID"
+// @Param suspensionId path int true "Suspension ID"
+// @Success 200 "Suspension lifted"
+// @Failure 400 {object} apperrors.Error "Invalid user id or suspension id"
+// @Failure 403 {object} apperrors.Error "Insufficient permissions"
+// @Failure 404 {object} apperrors.Error "Suspension not found or lifted already"
+// @Failure 500 {object} apperrors.Error "Internal server error"
+// @Router /users/{userId}/suspensions/{suspensionId} [delete]
+func (h *Handler) LiftSuspension(c echo.Context) error {
+	req, err := echox.BindAndValidate[LiftSuspensionRequest](c)
+	if err != nil {
+		return err
+	}
+
+	return h.service.LiftSuspension(c.Request().Context(), policy.ActorFromContext(c), req.UserID, req.SuspensionID)
+}
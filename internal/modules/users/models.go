@@ -1,6 +1,11 @@
 package users
 
-import "time"
+import (
+	"strconv"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
+)
 
 const (
 	AdminRole  = "admin"
@@ -12,15 +17,33 @@ const (
 	DefaultAvatarURL = "https://gravatar.com/avatar/00000000000000000000000000000000?d=mp&f=y"
 )
 
+// reviewsCountExpr is the number of reviews of a user, a column of the users directory.
+const reviewsCountExpr = "(SELECT COUNT(*) FROM reviews WHERE reviews.user_id = users.id AND reviews.deleted_at IS NULL)"
+
+// usersSortKeys are the fields the users directory can be sorted by.
+var usersSortKeys = pagination.NewSortKeys(map[string]pagination.SortKey[*DirectoryUser]{
+	"id":           {Expr: "id", Type: "integer", Value: func(u *DirectoryUser) string { return strconv.Itoa(u.ID) }},
+	"username":     {Expr: "username", Type: "varchar", Value: func(u *DirectoryUser) string { return u.Username }},
+	"createdAt":    {Expr: "created_at", Type: "timestamp", Value: func(u *DirectoryUser) string { return pagination.FormatTime(u.CreatedAt) }},
+	"reviewsCount": {Expr: reviewsCountExpr, Type: "bigint", Value: func(u *DirectoryUser) string { return strconv.Itoa(u.ReviewsCount) }},
+})
+
 type User struct {
-	ID        int        `json:"id"`
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	AvatarURL string     `json:"avatarUrl,omitempty"`
-	Bio       *string    `json:"bio,omitempty"`
-	Role      string     `json:"role"`
-	CreatedAt time.Time  `json:"createdAt"`
-	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	AvatarURL       string     `json:"avatarUrl,omitempty"`
+	Bio             *string    `json:"bio,omitempty"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"`
+}
+
+// DirectoryUser is a user as listed in the admin directory.
+type DirectoryUser struct {
+	User
+	ReviewsCount int `json:"reviewsCount"`
 }
 
 type UserWithPassword struct {
@@ -36,6 +59,22 @@ type GetUserByUsernameRequest struct {
 	Username string `json:"-" param:"username" validate:"min=3,max=24,nonzero"`
 }
 
+// GetUsersRequest lists users sorted by created_at (default, newest first) or reviews_count.
+type GetUsersRequest struct {
+	pagination.PaginatedRequestOrdered
+	UsersFilter
+}
+
+// UsersFilter narrows the users directory. Search matches the start of the username or the email, Deleted
+// selects deleted or active users only, both are listed if it's not set.
+type UsersFilter struct {
+	Search        *string    `query:"q"`
+	Role          *string    `query:"role"`
+	CreatedAfter  *time.Time `query:"created_after"`
+	CreatedBefore *time.Time `query:"created_before"`
+	Deleted       *bool      `query:"deleted"`
+}
+
 type UpdateUserRoleRequest struct {
 	UserID int    `json:"-" param:"userId" validate:"nonzero"`
 	Role   string `json:"-" param:"role" validate:"nonzero,role"`
@@ -44,7 +83,6 @@ type UpdateUserRoleRequest struct {
 type UpdateUserRequest struct {
 	UserID    int     `json:"-" param:"userId" validate:"nonzero"`
 	Username  *string `json:"username,omitempty" validate:"usernameOptional"`
-	Password  *string `json:"password,omitempty" validate:"passwordOptional"`
 	AvatarURL *string `json:"avatarUrl,omitempty"`
 	Bio       *string `json:"bio,omitempty"`
 }
@@ -53,10 +91,46 @@ type DeleteUserRequest struct {
 	UserID int `json:"-" param:"userId" validate:"nonzero"`
 }
 
+type RestoreUserRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+// Suspension keeps a user from logging in and using their tokens until it expires or is lifted.
+// Suspensions without ExpiresAt are permanent.
+type Suspension struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userId"`
+	Reason    string     `json:"reason"`
+	CreatedBy int        `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LiftedAt  *time.Time `json:"liftedAt,omitempty"`
+	LiftedBy  *int       `json:"liftedBy,omitempty"`
+}
+
+type SuspendUserRequest struct {
+	UserID    int        `json:"-" param:"userId" validate:"nonzero"`
+	Reason    string     `json:"reason" validate:"min=1,max=255"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type GetSuspensionsRequest struct {
+	UserID int `json:"-" param:"userId" validate:"nonzero"`
+}
+
+type LiftSuspensionRequest struct {
+	UserID       int `json:"-" param:"userId" validate:"nonzero"`
+	SuspensionID int `json:"-" param:"suspensionId" validate:"nonzero"`
+}
+
 func (u *User) IsDeleted() bool {
 	return u.DeletedAt == nil
 }
 
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 func NewUserWithPassword() *UserWithPassword {
 	return &UserWithPassword{
 		User: &User{},
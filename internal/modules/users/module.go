@@ -1,6 +1,9 @@
 package users
 
 import (
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -10,10 +13,10 @@ type Module struct {
 	Repository *Repository
 }
 
-func NewModule(db *pgxpool.Pool) *Module {
+func NewModule(db *pgxpool.Pool, jwtService *jwt.Service, rolesService *roles.Service, paginationConfig config.PaginationConfig) *Module {
 	repo := NewRepository(db)
-	service := NewService(repo)
-	handler := NewHandler(service)
+	service := NewService(repo, rolesService, jwtService)
+	handler := NewHandler(service, &paginationConfig)
 
 	return &Module{
 		Handler:    handler,
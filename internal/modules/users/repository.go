@@ -2,14 +2,27 @@ package users
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Masterminds/squirrel"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/dbx"
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var errInvalidRole = apperrors.BadRequest(errors.New("invalid role"))
+
+// ErrUsernameTaken is wrapped in the error of creating or restoring a user whose username is used by another user.
+var ErrUsernameTaken = errors.New("username is taken")
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type Repository struct {
 	db *pgxpool.Pool
 }
@@ -22,8 +35,8 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 
 func (r Repository) Create(ctx context.Context, user *UserWithPassword) (err error) {
 	query, args, err := squirrel.Insert("users").
-		Columns("username", "email", "pass_hash", "role, avatar_url").
-		Values(user.Username, user.Email, user.PasswordHash, user.Role, user.AvatarURL).
+		Columns("username", "email", "pass_hash", "role, avatar_url", "email_verified_at").
+		Values(user.Username, user.Email, user.PasswordHash, user.Role, user.AvatarURL, user.EmailVerifiedAt).
 		Suffix("RETURNING id, role, created_at").
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
@@ -31,13 +44,13 @@ func (r Repository) Create(ctx context.Context, user *UserWithPassword) (err err
 		return apperrors.Internal(err)
 	}
 
-	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Role, &user.CreatedAt)
+	err = dbx.FromContext(ctx, r.db).QueryRow(ctx, query, args...).Scan(&user.ID, &user.Role, &user.CreatedAt)
 
 	switch {
 	case dbx.IsUniqueViolation(err, "email"):
 		return apperrors.AlreadyExists("user", "email", user.Email)
 	case dbx.IsUniqueViolation(err, "username"):
-		return apperrors.AlreadyExists("user", "username", user.Username)
+		return apperrors.AlreadyExistsWrapped(ErrUsernameTaken, "user", "username", user.Username)
 	case err != nil:
 		return apperrors.Internal(err)
 	}
@@ -45,8 +58,94 @@ func (r Repository) Create(ctx context.Context, user *UserWithPassword) (err err
 	return nil
 }
 
+// GetUsers returns a page of the users directory and the total number of matching users.
+func (r Repository) GetUsers(ctx context.Context, filter *UsersFilter, page *pagination.Page[*DirectoryUser]) ([]*DirectoryUser, int, error) {
+	selectQuery := dbx.StatementBuilder.Select("id, username, email, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
+		Column(reviewsCountExpr + " AS reviews_count").
+		From("users")
+
+	countQuery := dbx.StatementBuilder.Select("COUNT(*)").
+		From("users")
+
+	for _, cond := range filter.conditions() {
+		selectQuery = selectQuery.Where(cond)
+		countQuery = countQuery.Where(cond)
+	}
+
+	selectQuery = dbx.Paginate(selectQuery, page)
+
+	b := &pgx.Batch{}
+	if err := dbx.QueryBatchSelect(b, selectQuery); err != nil {
+		return nil, 0, apperrors.Internal(err)
+	}
+	if err := dbx.QueryBatchSelect(b, countQuery); err != nil {
+		return nil, 0, apperrors.Internal(err)
+	}
+
+	br := r.db.SendBatch(ctx, b)
+	defer func() {
+		_ = br.Close()
+	}()
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, 0, apperrors.Internal(err)
+	}
+	defer rows.Close()
+
+	users := make([]*DirectoryUser, 0)
+	for rows.Next() {
+		var user DirectoryUser
+		if err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt, &user.ReviewsCount); err != nil {
+			return nil, 0, apperrors.Internal(err)
+		}
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, apperrors.Internal(err)
+	}
+
+	var total int
+	if err = br.QueryRow().Scan(&total); err != nil {
+		return nil, 0, apperrors.Internal(err)
+	}
+
+	return users, total, nil
+}
+
+func (f *UsersFilter) conditions() []squirrel.Sqlizer {
+	var conds []squirrel.Sqlizer
+
+	if f.Search != nil && *f.Search != "" {
+		prefix := likeEscaper.Replace(strings.ToLower(*f.Search)) + "%"
+		conds = append(conds, squirrel.Or{
+			squirrel.Expr("LOWER(username) LIKE ?", prefix),
+			squirrel.Expr("LOWER(email) LIKE ?", prefix),
+		})
+	}
+	if f.Role != nil {
+		conds = append(conds, squirrel.Eq{"role": *f.Role})
+	}
+	if f.CreatedAfter != nil {
+		conds = append(conds, squirrel.GtOrEq{"created_at": *f.CreatedAfter})
+	}
+	if f.CreatedBefore != nil {
+		conds = append(conds, squirrel.Lt{"created_at": *f.CreatedBefore})
+	}
+	if f.Deleted != nil {
+		if *f.Deleted {
+			conds = append(conds, squirrel.NotEq{"deleted_at": nil})
+		} else {
+			conds = append(conds, squirrel.Eq{"deleted_at": nil})
+		}
+	}
+
+	return conds
+}
+
 func (r Repository) GetExistingUserByEmail(ctx context.Context, email string) (*UserWithPassword, error) {
-	query, args, err := squirrel.Select("id, username, email, pass_hash, role, avatar_url, bio, created_at, deleted_at").
+	query, args, err := squirrel.Select("id, username, email, pass_hash, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
 		From("users").
 		Where(squirrel.Eq{"email": email}).
 		Where(squirrel.Eq{"deleted_at": nil}).
@@ -57,7 +156,7 @@ func (r Repository) GetExistingUserByEmail(ctx context.Context, email string) (*
 	}
 
 	user := NewUserWithPassword()
-	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.DeletedAt)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt)
 
 	switch {
 	case dbx.IsNoRows(err):
@@ -69,7 +168,7 @@ func (r Repository) GetExistingUserByEmail(ctx context.Context, email string) (*
 }
 
 func (r Repository) GetExistingUserByID(ctx context.Context, id int) (*User, error) {
-	query, args, err := squirrel.Select("id, username, email, role, avatar_url, bio, created_at, deleted_at").
+	query, args, err := squirrel.Select("id, username, email, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
 		From("users").
 		Where(squirrel.Eq{"id": id}).
 		Where(squirrel.Eq{"deleted_at": nil}).
@@ -80,7 +179,7 @@ func (r Repository) GetExistingUserByID(ctx context.Context, id int) (*User, err
 	}
 
 	var user User
-	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.DeletedAt)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt)
 
 	switch {
 	case dbx.IsNoRows(err):
@@ -92,8 +191,32 @@ func (r Repository) GetExistingUserByID(ctx context.Context, id int) (*User, err
 	return &user, nil
 }
 
+func (r Repository) GetExistingUserWithPasswordByID(ctx context.Context, id int) (*UserWithPassword, error) {
+	query, args, err := squirrel.Select("id, username, email, pass_hash, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
+		From("users").
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	user := NewUserWithPassword()
+	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt)
+
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, apperrors.NotFound("user", "id", id)
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return user, nil
+}
+
 func (r Repository) GetExistingUserByUsername(ctx context.Context, username string) (*UserWithPassword, error) {
-	query, args, err := squirrel.Select("id, username, email, role, avatar_url, bio, created_at, deleted_at").
+	query, args, err := squirrel.Select("id, username, email, pass_hash, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
 		From("users").
 		Where(squirrel.Eq{"username": username}).
 		Where(squirrel.Eq{"deleted_at": nil}).
@@ -104,7 +227,7 @@ func (r Repository) GetExistingUserByUsername(ctx context.Context, username stri
 	}
 
 	user := NewUserWithPassword()
-	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.DeletedAt)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt)
 
 	switch {
 	case dbx.IsNoRows(err):
@@ -116,10 +239,10 @@ func (r Repository) GetExistingUserByUsername(ctx context.Context, username stri
 	return user, nil
 }
 
-func (r Repository) UpdateExistingUserByID(ctx context.Context, id int, req *UpdateUserRequest, newPassword string) (*User, error) {
+func (r Repository) UpdateExistingUserByID(ctx context.Context, id int, req *UpdateUserRequest) (*User, error) {
 	builder := squirrel.Update("users").
 		Where(squirrel.Eq{"id": id}).
-		Suffix("RETURNING id, username, email, role, avatar_url, bio, created_at, deleted_at").
+		Suffix("RETURNING id, username, email, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
 		PlaceholderFormat(squirrel.Dollar)
 
 	hasSet := false
@@ -127,10 +250,6 @@ func (r Repository) UpdateExistingUserByID(ctx context.Context, id int, req *Upd
 		builder = builder.Set("username", *req.Username)
 		hasSet = true
 	}
-	if newPassword != "" {
-		builder = builder.Set("pass_hash", newPassword)
-		hasSet = true
-	}
 	if req.Bio != nil {
 		builder = builder.Set("bio", *req.Bio)
 		hasSet = true
@@ -150,7 +269,7 @@ func (r Repository) UpdateExistingUserByID(ctx context.Context, id int, req *Upd
 	}
 
 	var user User
-	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.DeletedAt)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt)
 	if err != nil {
 		return nil, apperrors.Internal(err)
 	}
@@ -158,10 +277,11 @@ func (r Repository) UpdateExistingUserByID(ctx context.Context, id int, req *Upd
 	return &user, nil
 }
 
-func (r Repository) UpdateUserRoleByID(ctx context.Context, id int, newRole string) error {
+func (r Repository) UpdatePasswordHash(ctx context.Context, id int, passHash string) error {
 	query, args, err := squirrel.Update("users").
-		Set("role", newRole).
+		Set("pass_hash", passHash).
 		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"deleted_at": nil}).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
@@ -180,9 +300,9 @@ func (r Repository) UpdateUserRoleByID(ctx context.Context, id int, newRole stri
 	return nil
 }
 
-func (r Repository) DeleteExistingUserByID(ctx context.Context, id int) error {
+func (r Repository) UpdateUserRoleByID(ctx context.Context, id int, newRole string) error {
 	query, args, err := squirrel.Update("users").
-		Set("deleted_at", squirrel.Expr("NOW()")).
+		Set("role", newRole).
 		Where(squirrel.Eq{"id": id}).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
@@ -191,7 +311,10 @@ func (r Repository) DeleteExistingUserByID(ctx context.Context, id int) error {
 	}
 
 	n, err := r.db.Exec(ctx, query, args...)
-	if err != nil {
+	switch {
+	case dbx.IsForeignKeyViolation(err, "users_role_fkey"):
+		return errInvalidRole
+	case err != nil:
 		return apperrors.Internal(err)
 	}
 
@@ -201,3 +324,225 @@ func (r Repository) DeleteExistingUserByID(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// DeleteExistingUserByID soft-deletes the user, the reviews of the user stop counting in movie ratings.
+func (r Repository) DeleteExistingUserByID(ctx context.Context, id int) error {
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("deleted_at", squirrel.Expr("NOW()")).
+			Where(squirrel.Eq{"id": id}).
+			Where(squirrel.Eq{"deleted_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return apperrors.NotFound("user", "id", id)
+		}
+
+		return r.recalculateReviewedMoviesRatings(ctx, id)
+	})
+}
+
+// RestoreUserByID undoes the soft deletion, unless the user was anonymized. It fails if the username or email
+// was taken in the meantime.
+func (r Repository) RestoreUserByID(ctx context.Context, id int) (*User, error) {
+	var user User
+	err := dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("deleted_at", nil).
+			Where(squirrel.Eq{"id": id}).
+			Where(squirrel.NotEq{"deleted_at": nil}).
+			Where(squirrel.Eq{"anonymized_at": nil}).
+			Suffix("RETURNING id, username, email, role, avatar_url, bio, created_at, email_verified_at, deleted_at").
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		err = tx.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.AvatarURL, &user.Bio, &user.CreatedAt, &user.EmailVerifiedAt, &user.DeletedAt)
+		switch {
+		case dbx.IsNoRows(err):
+			return apperrors.NotFound("deleted user", "id", id)
+		case dbx.IsUniqueViolation(err, "email"):
+			return apperrors.AlreadyExists("user", "email", user.Email)
+		case dbx.IsUniqueViolation(err, "username"):
+			return apperrors.AlreadyExistsWrapped(ErrUsernameTaken, "user", "username", user.Username)
+		case err != nil:
+			return apperrors.Internal(err)
+		}
+
+		return r.recalculateReviewedMoviesRatings(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// AnonymizeUser deletes the user for good: the personal data is scrubbed and the credentials, identities and
+// two-factor settings are deleted. The row stays as an anonymous placeholder the reviews are attributed to,
+// they stop counting in movie ratings.
+func (r Repository) AnonymizeUser(ctx context.Context, id int) error {
+	placeholder := fmt.Sprintf("deleted_%d", id)
+	return dbx.InTransaction(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := dbx.StatementBuilder.Update("users").
+			Set("username", placeholder).
+			Set("email", placeholder+"@anonymized.invalid").
+			Set("pass_hash", "").
+			Set("avatar_url", DefaultAvatarURL).
+			Set("bio", nil).
+			Set("email_verified_at", nil).
+			Set("deleted_at", squirrel.Expr("COALESCE(deleted_at, NOW())")).
+			Set("anonymized_at", squirrel.Expr("NOW()")).
+			Where(squirrel.Eq{"id": id}).
+			Where(squirrel.Eq{"anonymized_at": nil}).
+			ToSql()
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		n, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return apperrors.Internal(err)
+		}
+
+		if n.RowsAffected() == 0 {
+			return apperrors.NotFound("user", "id", id)
+		}
+
+		for _, table := range []string{"refresh_tokens", "personal_access_tokens", "one_time_tokens", "two_factor_recovery_codes", "user_two_factor", "user_identities", "data_exports"} {
+			if _, err = tx.Exec(ctx, "DELETE FROM "+table+" WHERE user_id = $1", id); err != nil {
+				return apperrors.Internal(err)
+			}
+		}
+
+		if _, err = tx.Exec(ctx, "DELETE FROM login_failures WHERE scope = 'user' AND subject = $1", strconv.Itoa(id)); err != nil {
+			return apperrors.Internal(err)
+		}
+
+		return r.recalculateReviewedMoviesRatings(ctx, id)
+	})
+}
+
+func (r Repository) CreateSuspension(ctx context.Context, suspension *Suspension) error {
+	query, args, err := dbx.StatementBuilder.Insert("user_suspensions").
+		Columns("user_id", "reason", "created_by", "expires_at").
+		Values(suspension.UserID, suspension.Reason, suspension.CreatedBy, suspension.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	err = r.db.QueryRow(ctx, query, args...).Scan(&suspension.ID, &suspension.CreatedAt)
+	switch {
+	case dbx.IsForeignKeyViolation(err, "user_id"):
+		return apperrors.NotFound("user", "id", suspension.UserID)
+	case err != nil:
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
+
+func (r Repository) GetSuspensions(ctx context.Context, userID int) ([]*Suspension, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, user_id, reason, created_by, created_at, expires_at, lifted_at, lifted_by").
+		From("user_suspensions").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("id DESC").
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	defer rows.Close()
+
+	suspensions := make([]*Suspension, 0)
+	for rows.Next() {
+		var s Suspension
+		if err = rows.Scan(&s.ID, &s.UserID, &s.Reason, &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt, &s.LiftedAt, &s.LiftedBy); err != nil {
+			return nil, apperrors.Internal(err)
+		}
+		suspensions = append(suspensions, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	return suspensions, nil
+}
+
+// GetActiveSuspension returns the suspension of the user lasting the longest, nil if the user isn't suspended.
+func (r Repository) GetActiveSuspension(ctx context.Context, userID int) (*Suspension, error) {
+	query, args, err := dbx.StatementBuilder.Select("id, user_id, reason, created_by, created_at, expires_at, lifted_at, lifted_by").
+		From("user_suspensions").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"lifted_at": nil}).
+		Where("(expires_at IS NULL OR expires_at > NOW())").
+		OrderBy("expires_at DESC NULLS FIRST").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, apperrors.Internal(err)
+	}
+
+	var s Suspension
+	err = r.db.QueryRow(ctx, query, args...).Scan(&s.ID, &s.UserID, &s.Reason, &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt, &s.LiftedAt, &s.LiftedBy)
+	switch {
+	case dbx.IsNoRows(err):
+		return nil, nil
+	case err != nil:
+		return nil, apperrors.Internal(err)
+	}
+
+	return &s, nil
+}
+
+func (r Repository) LiftSuspension(ctx context.Context, userID, suspensionID, liftedBy int) error {
+	query, args, err := dbx.StatementBuilder.Update("user_suspensions").
+		Set("lifted_at", squirrel.Expr("NOW()")).
+		Set("lifted_by", liftedBy).
+		Where(squirrel.Eq{"id": suspensionID}).
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(squirrel.Eq{"lifted_at": nil}).
+		ToSql()
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	n, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	if n.RowsAffected() == 0 {
+		return apperrors.NotFound("suspension", "id", suspensionID)
+	}
+
+	return nil
+}
+
+// recalculateReviewedMoviesRatings updates the average ratings of the movies the user reviewed.
+func (r Repository) recalculateReviewedMoviesRatings(ctx context.Context, userID int) error {
+	q := dbx.FromContext(ctx, r.db)
+	_, err := q.Exec(ctx, `UPDATE movies SET avg_rating = movie_avg_rating(id) WHERE id IN (SELECT movie_id FROM reviews WHERE user_id = $1)`, userID)
+	if err != nil {
+		return apperrors.Internal(err)
+	}
+
+	return nil
+}
@@ -2,18 +2,37 @@ package users
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
 	"github.com/DavidMovas/Movies-Reviews/internal/log"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
+	"github.com/DavidMovas/Movies-Reviews/internal/pagination"
+	"github.com/DavidMovas/Movies-Reviews/internal/policy"
+)
+
+var (
+	errForbidden                = apperrors.Forbidden("insufficient permissions")
+	errSelfRoleChange           = apperrors.BadRequest(errors.New("users can't change their own role"))
+	errSelfSuspension           = apperrors.BadRequest(errors.New("users can't suspend themselves"))
+	errSuspensionExpiredAlready = apperrors.BadRequest(errors.New("expiration time must be in the future"))
 )
 
 type Service struct {
-	repo *Repository
+	repo         *Repository
+	rolesService *roles.Service
+	jwtService   *jwt.Service
+	suspensions  *suspensionCache
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo *Repository, rolesService *roles.Service, jwtService *jwt.Service) *Service {
 	return &Service{
-		repo: repo,
+		repo:         repo,
+		rolesService: rolesService,
+		jwtService:   jwtService,
+		suspensions:  newSuspensionCache(),
 	}
 }
 
@@ -26,6 +45,10 @@ func (s *Service) Create(ctx context.Context, user *UserWithPassword) error {
 	return nil
 }
 
+func (s *Service) GetUsers(ctx context.Context, filter *UsersFilter, page *pagination.Page[*DirectoryUser]) ([]*DirectoryUser, int, error) {
+	return s.repo.GetUsers(ctx, filter, page)
+}
+
 func (s *Service) GetExistingUserByEmail(ctx context.Context, email string) (*UserWithPassword, error) {
 	return s.repo.GetExistingUserByEmail(ctx, email)
 }
@@ -34,34 +57,72 @@ func (s *Service) GetExistingUserByID(ctx context.Context, userID int) (*User, e
 	return s.repo.GetExistingUserByID(ctx, userID)
 }
 
+func (s *Service) GetExistingUserWithPasswordByID(ctx context.Context, userID int) (*UserWithPassword, error) {
+	return s.repo.GetExistingUserWithPasswordByID(ctx, userID)
+}
+
 func (s *Service) GetExistingUserByUsername(ctx context.Context, username string) (*UserWithPassword, error) {
 	return s.repo.GetExistingUserByUsername(ctx, username)
 }
 
-func (s *Service) UpdateExistingUserByID(ctx context.Context, userID int, req *UpdateUserRequest) (*User, error) {
-	var passHash []byte
-	var err error
-	if req.Password != nil {
-		passHash, err = bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			return nil, err
-		}
+func (s *Service) UpdateExistingUserByID(ctx context.Context, actor *policy.Actor, req *UpdateUserRequest) (*User, error) {
+	current, err := s.repo.GetExistingUserByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
 	}
 
-	user, err := s.repo.UpdateExistingUserByID(ctx, userID, req, string(passHash))
+	if err = policy.UpdateUser(actor, current.ID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.UpdateExistingUserByID(ctx, current.ID, req)
 	if err != nil {
 		return nil, err
 	}
 
-	log.FromContext(ctx).Info("user updated", "user_id", userID)
+	log.FromContext(ctx).Info("user updated", "user_id", user.ID)
 	return user, nil
 }
 
-func (s *Service) UpdateUserRoleByID(ctx context.Context, userID int, role string) error {
-	if err := s.repo.UpdateUserRoleByID(ctx, userID, role); err != nil {
+// UpdatePasswordHash replaces the password hash without changing the password, e.g. to upgrade the hash format.
+func (s *Service) UpdatePasswordHash(ctx context.Context, userID int, passHash string) error {
+	return s.repo.UpdatePasswordHash(ctx, userID, passHash)
+}
+
+// UpdateUserRoleByID gives the user the role. Actors can't change their own role, nor give or take away a role
+// granting permissions they lack.
+func (s *Service) UpdateUserRoleByID(ctx context.Context, actor *policy.Actor, userID int, role string) error {
+	if actor.UserID == userID {
+		return errSelfRoleChange
+	}
+
+	user, err := s.repo.GetExistingUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{user.Role, role} {
+		permissions, err := s.rolesService.GetRolePermissions(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range permissions {
+			if !actor.HasPermission(permission) {
+				return errForbidden
+			}
+		}
+	}
+
+	if err = s.repo.UpdateUserRoleByID(ctx, userID, role); err != nil {
 		return err
 	}
 
+	// Access tokens carry the role, so the old ones must not outlive the change
+	if err := s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return apperrors.Internal(err)
+	}
+
 	log.FromContext(ctx).Info("user role updated", "user_id", userID, "role", role)
 	return nil
 }
@@ -71,6 +132,99 @@ func (s *Service) DeleteExistingUserByID(ctx context.Context, userID int) error
 		return err
 	}
 
+	if err := s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return apperrors.Internal(err)
+	}
+
 	log.FromContext(ctx).Info("user deleted", "user_id", userID)
 	return nil
 }
+
+// RestoreUserByID undoes a soft deletion, the user has to log in again.
+func (s *Service) RestoreUserByID(ctx context.Context, userID int) (*User, error) {
+	user, err := s.repo.RestoreUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("user restored", "user_id", userID)
+	return user, nil
+}
+
+// AnonymizeUser deletes the user for good, keeping the reviews under an anonymous placeholder.
+func (s *Service) AnonymizeUser(ctx context.Context, actor *policy.Actor, userID int) error {
+	if err := policy.AnonymizeUser(actor, userID); err != nil {
+		return err
+	}
+
+	if err := s.repo.AnonymizeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.jwtService.RevokeUserTokens(ctx, userID); err != nil {
+		return apperrors.Internal(err)
+	}
+
+	log.FromContext(ctx).Info("user anonymized", "user_id", userID)
+	return nil
+}
+
+// SuspendUser suspends the user with a reason, until ExpiresAt or permanently. Suspended users can't log in,
+// refresh or use their tokens, and their reviews are hidden from the listings.
+func (s *Service) SuspendUser(ctx context.Context, actor *policy.Actor, req *SuspendUserRequest) (*Suspension, error) {
+	if actor.UserID == req.UserID {
+		return nil, errSelfSuspension
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errSuspensionExpiredAlready
+	}
+
+	suspension := &Suspension{
+		UserID:    req.UserID,
+		Reason:    req.Reason,
+		CreatedBy: actor.UserID,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.repo.CreateSuspension(ctx, suspension); err != nil {
+		return nil, err
+	}
+	s.suspensions.Delete(req.UserID)
+
+	log.FromContext(ctx).Info("user suspended", "user_id", req.UserID, "suspension_id", suspension.ID, "expires_at", req.ExpiresAt)
+	return suspension, nil
+}
+
+func (s *Service) GetSuspensions(ctx context.Context, userID int) ([]*Suspension, error) {
+	return s.repo.GetSuspensions(ctx, userID)
+}
+
+func (s *Service) LiftSuspension(ctx context.Context, actor *policy.Actor, userID, suspensionID int) error {
+	if err := s.repo.LiftSuspension(ctx, userID, suspensionID, actor.UserID); err != nil {
+		return err
+	}
+	s.suspensions.Delete(userID)
+
+	log.FromContext(ctx).Info("user suspension lifted", "user_id", userID, "suspension_id", suspensionID)
+	return nil
+}
+
+// CheckSuspension returns an error telling the reason and the end of the suspension if the user is suspended.
+// It runs for every authenticated request, so the active suspensions are cached for a short time.
+func (s *Service) CheckSuspension(ctx context.Context, userID int) error {
+	suspension, version, ok := s.suspensions.Get(userID)
+	if !ok {
+		var err error
+		if suspension, err = s.repo.GetActiveSuspension(ctx, userID); err != nil {
+			return err
+		}
+		s.suspensions.Set(userID, suspension, version)
+	}
+
+	if suspension != nil {
+		return apperrors.Suspended(suspension.Reason, suspension.ExpiresAt)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package users
+
+import (
+	"sync"
+	"time"
+)
+
+// suspensionCacheTTL bounds how long other instances of the service may keep serving a user after a suspension
+// changes, the instance handling the change drops its entry at once.
+const suspensionCacheTTL = 30 * time.Second
+
+type cachedSuspension struct {
+	suspension *Suspension
+	validUntil time.Time
+}
+
+// suspensionCache keeps the active suspensions of users, nil for users who aren't suspended,
+// so checking authenticated requests doesn't query the database every time.
+type suspensionCache struct {
+	mx      sync.RWMutex
+	entries map[int]cachedSuspension
+	sweepAt time.Time
+	version uint64
+}
+
+func newSuspensionCache() *suspensionCache {
+	return &suspensionCache{
+		entries: make(map[int]cachedSuspension),
+	}
+}
+
+// Get returns the cached suspension of the user. On a miss it returns the version of the cache to pass to Set.
+func (c *suspensionCache) Get(userID int) (*Suspension, uint64, bool) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	entry, ok := c.entries[userID]
+	if !ok || !time.Now().Before(entry.validUntil) {
+		return nil, c.version, false
+	}
+
+	return entry.suspension, c.version, true
+}
+
+// Set caches the suspension of the user, a temporary suspension is cached no longer than it lasts.
+// Nothing is cached if suspensions changed since the version was read, the suspension may be stale already.
+func (c *suspensionCache) Set(userID int, suspension *Suspension, version uint64) {
+	validUntil := time.Now().Add(suspensionCacheTTL)
+	if suspension != nil && suspension.ExpiresAt != nil && suspension.ExpiresAt.Before(validUntil) {
+		validUntil = *suspension.ExpiresAt
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if version != c.version {
+		return
+	}
+
+	c.deleteExpired()
+
+	c.entries[userID] = cachedSuspension{
+		suspension: suspension,
+		validUntil: validUntil,
+	}
+}
+
+func (c *suspensionCache) Delete(userID int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.version++
+	delete(c.entries, userID)
+}
+
+// deleteExpired keeps the cache from growing with every user who ever made a request, it sweeps the entries
+// once per TTL at most. The caller must hold the lock.
+func (c *suspensionCache) deleteExpired() {
+	now := time.Now()
+	if now.Before(c.sweepAt) {
+		return
+	}
+
+	c.sweepAt = now.Add(suspensionCacheTTL)
+	for userID, entry := range c.entries {
+		if !now.Before(entry.validUntil) {
+			delete(c.entries, userID)
+		}
+	}
+}
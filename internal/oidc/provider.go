@@ -0,0 +1,295 @@
+// Package oidc implements the OpenID Connect authorization code flow with PKCE for logging in
+// with external identity providers.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	appjwt "github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+
+	// keysRefetchInterval throttles fetching the keys for unknown key ids, so tokens with made up ids can't make
+	// every login call the provider.
+	keysRefetchInterval = time.Minute
+)
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// Identity is the user as asserted by the identity provider in the ID token.
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// Provider is an OpenID Connect identity provider. Its metadata and keys are discovered on the first use,
+// so an unavailable provider doesn't prevent the service from starting.
+type Provider struct {
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(cfg config.OIDCProviderConfig) (*Provider, error) {
+	if cfg.Name == "" || cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider %q: name, issuer url, client id and redirect url are required", cfg.Name)
+	}
+
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+// NewProviders creates the configured providers by their names.
+func NewProviders(cfgs []config.OIDCProviderConfig) (map[string]*Provider, error) {
+	providers := make(map[string]*Provider, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, ok := providers[cfg.Name]; ok {
+			return nil, fmt.Errorf("duplicate oidc provider: %s", cfg.Name)
+		}
+
+		provider, err := NewProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[cfg.Name] = provider
+	}
+
+	return providers, nil
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL returns the URL of the provider's login page the user is redirected to.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(codeVerifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return md.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems the authorization code and returns the identity from the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err = p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, md, token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+
+	return &Identity{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, md *metadata, idToken string) (*idTokenClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+
+	var claims idTokenClaims
+	_, err := parser.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify id token: %w", err)
+	}
+
+	if claims.Issuer != md.Issuer {
+		return nil, fmt.Errorf("unexpected id token issuer: %s", claims.Issuer)
+	}
+
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, errors.New("id token is not issued for this client")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	return &claims, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.IssuerURL, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var md metadata
+	if err = p.do(req, &md); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", p.cfg.Name, err)
+	}
+
+	if strings.TrimSuffix(md.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discover %s: issuer mismatch: %s", p.cfg.Name, md.Issuer)
+	}
+
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, fmt.Errorf("discover %s: incomplete provider metadata", p.cfg.Name)
+	}
+
+	p.metadata = &md
+	return p.metadata, nil
+}
+
+// key returns the provider's public key by its id. The keys are fetched again for an unknown id, so the provider
+// can rotate them, but not more often than keysRefetchInterval. Failed fetches count too.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < keysRefetchInterval {
+		return nil, errUnknownKey
+	}
+	p.keysFetchedAt = time.Now()
+
+	jwks := appjwt.JWKS{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.metadata.JWKSURI, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.do(req, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		// Keys of unsupported types can't have signed a token we accept
+		if key, keyErr := jwk.PublicKey(); keyErr == nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	p.keys = keys
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, errUnknownKey
+}
+
+func (p *Provider) do(req *http.Request, v any) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", res.StatusCode, body)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
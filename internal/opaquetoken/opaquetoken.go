@@ -0,0 +1,29 @@
+// Package opaquetoken generates random tokens handed to clients, e.g. refresh tokens, one-time tokens and
+// personal access tokens. Only their hashes are ever persisted, so a leaked table cannot be used to sign in.
+package opaquetoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const length = 32
+
+// Generate returns a random token starting with the prefix for the client and its hash for the storage.
+func Generate(prefix string) (raw string, hash string, err error) {
+	b := make([]byte, length)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	raw = prefix + base64.RawURLEncoding.EncodeToString(b)
+	return raw, Hash(raw), nil
+}
+
+// Hash returns the hash of the token to look it up in the storage.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
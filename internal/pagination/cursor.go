@@ -0,0 +1,154 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+)
+
+var errInvalidCursor = apperrors.BadRequest(errors.New("invalid cursor"))
+
+// Cursor points between two rows of a list by the sort key values of the row the page starts after, or ends
+// before when Backward. Sort identifies the keys it was made for.
+type Cursor struct {
+	Sort     uint32   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Page selects rows of a list either by offset, or by the sort keys when Cursor is set, which doesn't skip or
+// repeat rows when the list changes meanwhile. The last key has to be unique, usually it's the id.
+type Page[T any] struct {
+	Keys   []SortKey[T]
+	Cursor *Cursor
+	Offset int
+	Limit  int
+}
+
+// NewPage returns the page of the request, sorted by keys.
+func NewPage[T any](r *PaginatedRequest, keys []SortKey[T]) (*Page[T], error) {
+	page := &Page[T]{
+		Keys:  keys,
+		Limit: r.Size,
+	}
+
+	if r.Cursor == "" {
+		page.Offset = (r.Page - 1) * r.Size
+		return page, nil
+	}
+
+	cursor, err := decodeCursor(r.Cursor)
+	if err != nil || cursor.Sort != page.sortHash() || !validValues(keys, cursor.Values) {
+		return nil, errInvalidCursor
+	}
+
+	page.Cursor = cursor
+	return page, nil
+}
+
+// Backward reports whether the page ends before the cursor, its rows are selected in reverse order then.
+func (p *Page[T]) Backward() bool {
+	return p.Cursor != nil && p.Cursor.Backward
+}
+
+// result trims the rows selected for the page, one more than the limit, and returns them with the cursors of the
+// next and previous pages, empty if there is none.
+func (p *Page[T]) result(items []T) ([]T, string, string) {
+	more := len(items) > p.Limit
+	if more {
+		items = items[:p.Limit]
+	}
+
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	hasNext, hasPrev := more, p.Offset > 0 || p.Cursor != nil
+	if p.Backward() {
+		slices.Reverse(items)
+		hasNext, hasPrev = true, more
+	}
+
+	var next, prev string
+	if hasNext {
+		next = p.cursorAt(items[len(items)-1], false)
+	}
+	if hasPrev {
+		prev = p.cursorAt(items[0], true)
+	}
+
+	return items, next, prev
+}
+
+func (p *Page[T]) cursorAt(item T, backward bool) string {
+	cursor := Cursor{
+		Sort:     p.sortHash(),
+		Values:   make([]string, len(p.Keys)),
+		Backward: backward,
+	}
+	for i, key := range p.Keys {
+		cursor.Values[i] = key.Value(item)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (p *Page[T]) sortHash() uint32 {
+	parts := make([]string, len(p.Keys))
+	for i, key := range p.Keys {
+		parts[i] = key.Expr + " " + strconv.FormatBool(key.Desc)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.Join(parts, ",")))
+	return h.Sum32()
+}
+
+func validValues[T any](keys []SortKey[T], values []string) bool {
+	if len(values) != len(keys) {
+		return false
+	}
+
+	for i, key := range keys {
+		if !typeCheckers[key.Type](values[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// FormatTime formats a time sort key value.
+func FormatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// FormatFloat formats a coalesced float sort key value, nil as 0.
+func FormatFloat(f *float64) string {
+	if f == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
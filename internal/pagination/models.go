@@ -2,9 +2,11 @@ package pagination
 
 import "strconv"
 
+// PaginatedRequest selects a page by number, or by Cursor, one of the cursors of a previous response.
 type PaginatedRequest struct {
-	Page int `json:"page" query:"page"`
-	Size int `json:"size" query:"size"`
+	Page   int    `json:"page" query:"page"`
+	Size   int    `json:"size" query:"size"`
+	Cursor string `json:"cursor,omitempty" query:"cursor"`
 }
 
 type PaginatedRequestOrdered struct {
@@ -13,11 +15,14 @@ type PaginatedRequestOrdered struct {
 	Order string `json:"order" query:"order"`
 }
 
+// PaginatedResponse is a page of a list, Page is 0 when it was selected by cursor.
 type PaginatedResponse[T any] struct {
-	Page  int `json:"page" validate:"min=0"`
-	Size  int `json:"size" validate:"min=0"`
-	Total int `json:"total"`
-	Items []T `json:"items"`
+	Page       int    `json:"page" validate:"min=0"`
+	Size       int    `json:"size" validate:"min=0"`
+	Total      int    `json:"total"`
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
 }
 
 type PaginatedResponseOrdered[T any] struct {
@@ -34,6 +39,9 @@ func (req *PaginatedRequest) ToQueryParams() map[string]string {
 	if req.Size > 0 {
 		params["size"] = strconv.Itoa(req.Size)
 	}
+	if req.Cursor != "" {
+		params["cursor"] = req.Cursor
+	}
 	return params
 }
 
@@ -30,36 +30,40 @@ func SetDefaultsOrdered(r *PaginatedRequestOrdered, cfg *config.PaginationConfig
 	}
 }
 
+// SetDefaultsOrderedWith sorts by sort in the order unless the request is sorted already.
 func SetDefaultsOrderedWith(r *PaginatedRequestOrdered, cfg *config.PaginationConfig, sort string, order string) {
-	r.Sort = sort
-	r.Order = order
+	if r.Sort == "" {
+		r.Sort = sort
+		if r.Order == "" {
+			r.Order = order
+		}
+	}
 	SetDefaultsOrdered(r, cfg)
 }
 
-func OffsetLimit(r *PaginatedRequest) (int, int) {
-	offset := (r.Page - 1) * r.Size
-	limit := r.Size
-	return offset, limit
-}
+// Response returns the page of items, as selected with dbx.Paginate.
+func Response[T any](r *PaginatedRequest, page *Page[T], total int, items []T) *PaginatedResponse[T] {
+	items, next, prev := page.result(items)
 
-func Response[T any](r *PaginatedRequest, total int, items []T) *PaginatedResponse[T] {
-	return &PaginatedResponse[T]{
-		Page:  r.Page,
-		Size:  r.Size,
-		Total: total,
-		Items: items,
+	res := &PaginatedResponse[T]{
+		Page:       r.Page,
+		Size:       r.Size,
+		Total:      total,
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
 	}
+	if page.Cursor != nil {
+		res.Page = 0
+	}
+
+	return res
 }
 
-func ResponseOrdered[T any](r *PaginatedRequestOrdered, total int, items []T) *PaginatedResponseOrdered[T] {
+func ResponseOrdered[T any](r *PaginatedRequestOrdered, page *Page[T], total int, items []T) *PaginatedResponseOrdered[T] {
 	return &PaginatedResponseOrdered[T]{
-		PaginatedResponse: PaginatedResponse[T]{
-			Page:  r.Page,
-			Size:  r.Size,
-			Total: total,
-			Items: items,
-		},
-		Sort:  r.Sort,
-		Order: r.Order,
+		PaginatedResponse: *Response(&r.PaginatedRequest, page, total, items),
+		Sort:              r.Sort,
+		Order:             r.Order,
 	}
 }
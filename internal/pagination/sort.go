@@ -0,0 +1,107 @@
+package pagination
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+)
+
+// SortKey is a column a list of T can be sorted by: its SQL expression with its arguments, the SQL type cursor values
+// are cast to, and the value of an item as text, parseable as Type. Nullable columns are coalesced, keyset
+// comparisons don't match NULLs.
+type SortKey[T any] struct {
+	Expr  string
+	Args  []any
+	Type  string
+	Desc  bool
+	Value func(T) string
+}
+
+// SortKeys are the keys a list of T can be sorted by, by field name. Movies and reviews also take created_at:
+// their sort parameter used to be a column name, and it's the one their clients sent.
+type SortKeys[T any] map[string]SortKey[T]
+
+// NewSortKeys checks the keys when a list is defined: the "id" key has to be there to break ties, and every key
+// needs a Value and a Type cursor values are checked against. It panics otherwise.
+func NewSortKeys[T any](keys map[string]SortKey[T]) SortKeys[T] {
+	if _, ok := keys["id"]; !ok {
+		panic("pagination: sort keys without an id key")
+	}
+
+	for name, key := range keys {
+		if _, ok := typeCheckers[key.Type]; !ok {
+			panic(fmt.Sprintf("pagination: sort key %q has an unsupported type %q", name, key.Type))
+		}
+		if key.Value == nil {
+			panic(fmt.Sprintf("pagination: sort key %q has no value", name))
+		}
+	}
+
+	return keys
+}
+
+// typeCheckers tell whether a cursor value can be cast to the type of its key, so that a tampered or stale cursor
+// is rejected before Postgres fails to cast it.
+var typeCheckers = map[string]func(value string) bool{
+	"integer": func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	},
+	"bigint": func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	},
+	"real": func(value string) bool {
+		_, err := strconv.ParseFloat(value, 32)
+		return err == nil
+	},
+	"date":      isTime,
+	"timestamp": isTime,
+	"varchar": func(value string) bool {
+		return utf8.ValidString(value) && !strings.ContainsRune(value, 0)
+	},
+}
+
+func isTime(value string) bool {
+	_, err := time.Parse(time.RFC3339Nano, value)
+	return err == nil
+}
+
+// ParseSort returns the keys of a sort specification like "-avgRating,title": comma separated fields of keys,
+// descending when prefixed with "-", otherwise in the order. The "id" key follows in the order as tie-breaker,
+// unless it's sorted by already.
+func ParseSort[T any](keys SortKeys[T], sort, order string) ([]SortKey[T], error) {
+	fields := strings.Split(sort, ",")
+	sortKeys := make([]SortKey[T], 0, len(fields)+1)
+	seen := make(map[string]bool, len(fields))
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		name, desc := strings.CutPrefix(field, "-")
+
+		key, ok := keys[name]
+		if !ok {
+			return nil, apperrors.BadRequest(fmt.Errorf("invalid sort field %q, allowed: %s", name, strings.Join(slices.Sorted(maps.Keys(keys)), ", ")))
+		}
+		if seen[key.Expr] {
+			return nil, apperrors.BadRequest(fmt.Errorf("duplicate sort field %q", name))
+		}
+		seen[key.Expr] = true
+
+		key.Desc = desc || order == "desc"
+		sortKeys = append(sortKeys, key)
+	}
+
+	if id := keys["id"]; !seen[id.Expr] {
+		id.Desc = order == "desc"
+		sortKeys = append(sortKeys, id)
+	}
+
+	return sortKeys, nil
+}
@@ -0,0 +1,133 @@
+// Package password hashes passwords with argon2id in the PHC string format:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+//
+// Hashes made by bcrypt before are still verified and reported as needing a rehash.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	saltLength     = 16
+	keyLength      = 32
+)
+
+var (
+	ErrInvalidHash = errors.New("invalid password hash")
+
+	encoding = base64.RawStdEncoding
+)
+
+type params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// weakerThan reports whether any of the parameters is lower than the other's.
+func (p params) weakerThan(other params) bool {
+	return p.memory < other.memory || p.iterations < other.iterations || p.parallelism < other.parallelism
+}
+
+type Hasher struct {
+	params params
+}
+
+func NewHasher(cfg config.PasswordHashConfig) (*Hasher, error) {
+	if cfg.Memory < 8*uint32(cfg.Parallelism) || cfg.Iterations < 1 || cfg.Parallelism < 1 {
+		return nil, fmt.Errorf("invalid argon2id parameters: memory %d KiB, iterations %d, parallelism %d", cfg.Memory, cfg.Iterations, cfg.Parallelism)
+	}
+
+	return &Hasher{
+		params: params{
+			memory:      cfg.Memory,
+			iterations:  cfg.Iterations,
+			parallelism: cfg.Parallelism,
+		},
+	}, nil
+}
+
+// Hash returns the argon2id hash of the password with a random salt and the configured parameters.
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, keyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.memory, p.iterations, p.parallelism,
+		encoding.EncodeToString(salt), encoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether the password matches the hash. If it does, rehash tells the hash is a bcrypt one
+// or has weaker parameters than configured, so it should be replaced with a new Hash of the password.
+func (h *Hasher) Verify(password, hash string) (ok, rehash bool, err error) {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return verifyBcrypt(password, hash)
+	}
+
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return false, false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+
+	return true, p.weakerThan(h.params) || len(key) < keyLength, nil
+}
+
+func verifyBcrypt(password, hash string) (ok, rehash bool, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	switch {
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, false, nil
+	case err != nil:
+		return false, false, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	return true, true, nil
+}
+
+func decode(hash string) (p params, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if salt, err = encoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if key, err = encoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	return p, salt, key, nil
+}
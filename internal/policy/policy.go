@@ -0,0 +1,91 @@
+// Package policy decides whether an actor may act on a resource.
+// The functions don't load anything, services load the resource first and check it before any write.
+package policy
+
+import (
+	"slices"
+
+	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
+	"github.com/labstack/echo/v4"
+)
+
+var errForbidden = apperrors.Forbidden("insufficient permissions")
+
+// Actor is the authenticated user making the request.
+type Actor struct {
+	UserID      int
+	Permissions []string
+}
+
+// NewActor returns the actor of the access token claims, or nil for anonymous requests.
+// Permissions of users who haven't enabled the two-factor authentication required for their role are dropped.
+func NewActor(claims *jwt.AccessClaims) *Actor {
+	if claims == nil {
+		return nil
+	}
+
+	actor := &Actor{UserID: claims.UserID}
+	if !claims.TwoFactorSetupRequired {
+		actor.Permissions = claims.Permissions
+	}
+
+	return actor
+}
+
+// ActorFromContext returns the actor of the request, or nil for anonymous requests.
+func ActorFromContext(c echo.Context) *Actor {
+	return NewActor(jwt.GetClaims(c))
+}
+
+func (a *Actor) HasPermission(permission string) bool {
+	return a != nil && slices.Contains(a.Permissions, permission)
+}
+
+// CreateReview allows users to write reviews in their own name, and moderators in the name of others.
+func CreateReview(actor *Actor, authorID int) error {
+	return ownerOr(actor, authorID, roles.ReviewsModeratePermission)
+}
+
+// UpdateReview allows the author and moderators to update a review.
+func UpdateReview(actor *Actor, authorID int) error {
+	return ownerOr(actor, authorID, roles.ReviewsModeratePermission)
+}
+
+// DeleteReview allows the author and moderators to delete a review.
+func DeleteReview(actor *Actor, authorID int) error {
+	return ownerOr(actor, authorID, roles.ReviewsModeratePermission)
+}
+
+// UpdateUser allows users to update their own profile, and user managers to update any.
+func UpdateUser(actor *Actor, userID int) error {
+	return ownerOr(actor, userID, roles.UsersManagePermission)
+}
+
+// AnonymizeUser allows users to have their own account deleted for good, and user managers any account.
+func AnonymizeUser(actor *Actor, userID int) error {
+	return ownerOr(actor, userID, roles.UsersManagePermission)
+}
+
+// ExportUserData allows users to export their own personal data, and user managers that of anyone.
+func ExportUserData(actor *Actor, userID int) error {
+	return ownerOr(actor, userID, roles.UsersManagePermission)
+}
+
+// ManageAccessTokens allows users to manage their own personal access tokens, and user managers those of anyone.
+func ManageAccessTokens(actor *Actor, ownerID int) error {
+	return ownerOr(actor, ownerID, roles.UsersManagePermission)
+}
+
+func ownerOr(actor *Actor, ownerID int, permission string) error {
+	if actor == nil {
+		return errForbidden
+	}
+
+	if actor.UserID == ownerID || actor.HasPermission(permission) {
+		return nil
+	}
+
+	return errForbidden
+}
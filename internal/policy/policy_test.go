@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
+)
+
+const ownerID = 1
+
+var (
+	owner = &Actor{UserID: ownerID}
+	admin = &Actor{UserID: 2, Permissions: []string{
+		roles.GenresWritePermission,
+		roles.StarsWritePermission,
+		roles.MoviesWritePermission,
+		roles.ReviewsModeratePermission,
+		roles.UsersManagePermission,
+		roles.UsersImpersonatePermission,
+		roles.RolesManagePermission,
+		roles.InvitationsManagePermission,
+	}}
+	moderator = &Actor{UserID: 3, Permissions: []string{roles.ReviewsModeratePermission}}
+	stranger  = &Actor{UserID: 4}
+)
+
+func TestPolicies(t *testing.T) {
+	type actorCase struct {
+		name    string
+		actor   *Actor
+		allowed bool
+	}
+
+	reviewCases := []actorCase{
+		{"owner", owner, true},
+		{"admin", admin, true},
+		{"moderator", moderator, true},
+		{"stranger", stranger, false},
+		{"anonymous", nil, false},
+	}
+
+	userCases := []actorCase{
+		{"owner", owner, true},
+		{"admin", admin, true},
+		{"moderator", moderator, false},
+		{"stranger", stranger, false},
+		{"anonymous", nil, false},
+	}
+
+	policies := []struct {
+		name   string
+		policy func(actor *Actor, ownerID int) error
+		cases  []actorCase
+	}{
+		{"CreateReview", CreateReview, reviewCases},
+		{"UpdateReview", UpdateReview, reviewCases},
+		{"DeleteReview", DeleteReview, reviewCases},
+		{"UpdateUser", UpdateUser, userCases},
+		{"AnonymizeUser", AnonymizeUser, userCases},
+		{"ExportUserData", ExportUserData, userCases},
+		{"ManageAccessTokens", ManageAccessTokens, userCases},
+	}
+
+	for _, p := range policies {
+		for _, cc := range p.cases {
+			t.Run(p.name+": "+cc.name, func(t *testing.T) {
+				err := p.policy(cc.actor, ownerID)
+				if cc.allowed {
+					require.NoError(t, err)
+				} else {
+					require.ErrorIs(t, err, errForbidden)
+				}
+			})
+		}
+	}
+}
+
+func TestOwnerOr(t *testing.T) {
+	cases := []struct {
+		name    string
+		actor   *Actor
+		allowed bool
+	}{
+		{"owner without permissions", owner, true},
+		{"owner with the permission", &Actor{UserID: ownerID, Permissions: []string{roles.UsersManagePermission}}, true},
+		{"other user with the permission", &Actor{UserID: 5, Permissions: []string{roles.UsersManagePermission}}, true},
+		{"other user with another permission", &Actor{UserID: 5, Permissions: []string{roles.ReviewsModeratePermission}}, false},
+		{"other user without permissions", stranger, false},
+		{"anonymous", nil, false},
+	}
+
+	for _, cc := range cases {
+		t.Run(cc.name, func(t *testing.T) {
+			err := ownerOr(cc.actor, ownerID, roles.UsersManagePermission)
+			if cc.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, errForbidden)
+			}
+		})
+	}
+}
+
+func TestNewActor(t *testing.T) {
+	t.Run("anonymous", func(t *testing.T) {
+		require.Nil(t, NewActor(nil))
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		actor := NewActor(&jwt.AccessClaims{UserID: ownerID, Permissions: moderator.Permissions})
+		require.Equal(t, ownerID, actor.UserID)
+		require.True(t, actor.HasPermission(roles.ReviewsModeratePermission))
+	})
+
+	t.Run("two-factor setup required", func(t *testing.T) {
+		actor := NewActor(&jwt.AccessClaims{UserID: ownerID, Permissions: moderator.Permissions, TwoFactorSetupRequired: true})
+		require.Equal(t, ownerID, actor.UserID)
+		require.False(t, actor.HasPermission(roles.ReviewsModeratePermission))
+		require.NoError(t, UpdateReview(actor, ownerID))
+		require.Error(t, UpdateReview(actor, ownerID+1))
+	})
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/reviews"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/search"
 
 	"github.com/DavidMovas/Movies-Reviews/docs"
 
@@ -18,11 +21,17 @@ import (
 	apperrors "github.com/DavidMovas/Movies-Reviews/internal/error"
 	"github.com/DavidMovas/Movies-Reviews/internal/jwt"
 	"github.com/DavidMovas/Movies-Reviews/internal/log"
+	"github.com/DavidMovas/Movies-Reviews/internal/mail"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/accesstokens"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/auth"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/exports"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/genres"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/movies"
+	"github.com/DavidMovas/Movies-Reviews/internal/modules/roles"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/stars"
 	"github.com/DavidMovas/Movies-Reviews/internal/modules/users"
+	"github.com/DavidMovas/Movies-Reviews/internal/oidc"
+	"github.com/DavidMovas/Movies-Reviews/internal/password"
 	"github.com/DavidMovas/Movies-Reviews/internal/validation"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
@@ -48,6 +57,15 @@ func New(ctx context.Context, cfg *config.Config) (*Server, error) {
 	slog.SetDefault(logger)
 
 	validation.SetupValidators()
+	jwt.SetupTimePrecision()
+
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowOrigins, "*") {
+		return nil, errors.New("cors: credentials can't be allowed for any origin")
+	}
+
+	if cfg.Auth.Cookie.SameSite == config.SameSite(http.SameSiteNoneMode) && !cfg.Auth.Cookie.Secure {
+		return nil, errors.New("cookie: SameSite=None requires secure cookies")
+	}
 
 	var closers []func() error
 	db, err := getDB(ctx, cfg.DBUrl)
@@ -60,13 +78,61 @@ func New(ctx context.Context, cfg *config.Config) (*Server, error) {
 		return nil
 	})
 
-	jwtService := jwt.NewService(cfg.JWT.Secret, cfg.JWT.AccessExpiration)
-	usersModule := users.NewModule(db)
-	authModule := auth.NewModule(jwtService, usersModule.Service)
+	denylist, err := jwt.NewDenylist(cfg.JWT.Denylist.Store, db)
+	if err != nil {
+		return nil, withClosers(closers, fmt.Errorf("create token denylist: %w", err))
+	}
+
+	jwtService, err := jwt.NewService(cfg.JWT, denylist)
+	if err != nil {
+		return nil, withClosers(closers, fmt.Errorf("create jwt service: %w", err))
+	}
+
+	if cfg.JWT.Denylist.CleanupInterval > 0 {
+		cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+		go jwtService.RunDenylistCleanup(cleanupCtx, cfg.JWT.Denylist.CleanupInterval)
+
+		closers = append(closers, func() error {
+			stopCleanup()
+			return nil
+		})
+	}
+
+	mailer, err := mail.NewMailer(cfg.Mail)
+	if err != nil {
+		return nil, withClosers(closers, fmt.Errorf("create mailer: %w", err))
+	}
+
+	oidcProviders, err := oidc.NewProviders(cfg.Auth.OIDC.Providers)
+	if err != nil {
+		return nil, withClosers(closers, fmt.Errorf("create oidc providers: %w", err))
+	}
+
+	passwordHasher, err := password.NewHasher(cfg.Auth.PasswordHash)
+	if err != nil {
+		return nil, withClosers(closers, fmt.Errorf("create password hasher: %w", err))
+	}
+
+	rolesModule := roles.NewModule(db, jwtService)
+	usersModule := users.NewModule(db, jwtService, rolesModule.Service, cfg.Pagination)
+	authModule := auth.NewModule(db, jwtService, usersModule.Service, rolesModule.Service, passwordHasher, mailer, oidcProviders, cfg.Auth)
 	genresModule := genres.NewModule(db)
 	starsModule := stars.NewModule(db, cfg.Pagination)
 	moviesModule := movies.NewModule(db, genresModule, starsModule, cfg.Pagination)
 	reviewsModule := reviews.NewModule(db, moviesModule, cfg.Pagination)
+	accessTokensModule := accesstokens.NewModule(db, cfg.Auth.TwoFactor)
+	exportsModule := exports.NewModule(db, cfg.Exports)
+	searchModule := search.NewModule(db, cfg.Search)
+
+	if cfg.Exports.PollInterval > 0 {
+		exportsCtx, stopExports := context.WithCancel(context.Background())
+		go exportsModule.Service.RunWorker(exportsCtx, cfg.Exports.PollInterval)
+
+		closers = append(closers, func() error {
+			stopExports()
+			return nil
+		})
+	}
 
 	if err = createInitialAdminUser(cfg.Admin, authModule.Service); err != nil {
 		return nil, withClosers(closers, fmt.Errorf("create initial admin user: %w", err))
@@ -74,61 +140,122 @@ func New(ctx context.Context, cfg *config.Config) (*Server, error) {
 
 	e := echo.New()
 	e.HTTPErrorHandler = echox.ErrorHandler
+	if cfg.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
 
 	e.Use(middleware.Recover())
 	e.HideBanner = true
 	e.HidePort = true
 
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+	}))
 
 	api := e.Group("/api")
-	api.Use(jwt.NewAuthMiddleware(cfg.JWT.Secret))
+	api.Use(jwt.NewAuthMiddleware(jwtService, usersModule.Service, accessTokensModule.Service))
 	api.Use(echox.Logger)
+	api.Use(auth.CSRF)
 
 	// Swagger routes
 	e.GET("/swagger*", docs.EchoSwaggerHandler)
 
+	// Public keys for verifying access tokens
+	e.GET("/.well-known/jwks.json", jwt.NewJWKSHandler(jwtService))
+
 	// Auth API routes
 	api.POST("/auth/register", authModule.Handler.Register)
 	api.POST("/auth/login", authModule.Handler.Login)
+	api.POST("/auth/login/2fa", authModule.Handler.LoginTwoFactor)
+	api.GET("/auth/oidc/:provider/login", authModule.Handler.StartOIDCLogin)
+	api.GET("/auth/oidc/:provider/callback", authModule.Handler.FinishOIDCLogin)
+	api.POST("/auth/refresh", authModule.Handler.Refresh)
+	api.POST("/auth/logout", authModule.Handler.Logout, auth.Authenticated, auth.Session)
+	api.POST("/auth/password/forgot", authModule.Handler.ForgotPassword)
+	api.POST("/auth/password/reset", authModule.Handler.ResetPassword)
+	api.POST("/auth/password/change", authModule.Handler.ChangePassword, auth.Authenticated, auth.Session, auth.NotImpersonated)
+	api.POST("/auth/email/change", authModule.Handler.ChangeEmail, auth.Authenticated, auth.Session, auth.NotImpersonated)
+	api.POST("/auth/email/confirm", authModule.Handler.ConfirmEmailChange)
+	api.POST("/auth/verify", authModule.Handler.VerifyEmail)
+	api.POST("/auth/verify/resend", authModule.Handler.ResendEmailVerification)
+	api.GET("/auth/lockouts", authModule.Handler.GetLockouts, auth.Require(roles.UsersManagePermission))
+	api.DELETE("/auth/lockouts/:scope/:subject", authModule.Handler.DeleteLockout, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.POST("/auth/2fa/enroll", authModule.Handler.EnrollTwoFactor, auth.Authenticated, auth.Session, auth.NotImpersonated)
+	api.POST("/auth/2fa/confirm", authModule.Handler.ConfirmTwoFactor, auth.Authenticated, auth.Session, auth.NotImpersonated)
+	api.POST("/auth/2fa/disable", authModule.Handler.DisableTwoFactor, auth.Authenticated, auth.Session, auth.NotImpersonated)
+	api.POST("/auth/2fa/recovery-codes", authModule.Handler.RegenerateRecoveryCodes, auth.Authenticated, auth.Session, auth.NotImpersonated)
 
 	// Users API routes
+	api.GET("/users", usersModule.Handler.GetUsers, auth.Require(roles.UsersManagePermission))
 	api.GET("/users/:userId", usersModule.Handler.GetExistingUserByID)
 	api.GET("/users/username/:username", usersModule.Handler.GetExistingUserByUsername)
-	api.PUT("/users/:userId", usersModule.Handler.UpdateExistingUserByID, auth.Self)
-	api.PUT("/users/:userId/role/:role", usersModule.Handler.UpdateUserRoleByID, auth.Admin)
-	api.DELETE("/users/:userId", usersModule.Handler.DeleteExistingUserByID, auth.Admin)
+	api.PUT("/users/:userId", usersModule.Handler.UpdateExistingUserByID, auth.SelfOr(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.PUT("/users/:userId/role/:role", usersModule.Handler.UpdateUserRoleByID, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.DELETE("/users/:userId", usersModule.Handler.DeleteExistingUserByID, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.POST("/users/:userId/suspensions", usersModule.Handler.SuspendUser, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.GET("/users/:userId/suspensions", usersModule.Handler.GetSuspensions, auth.Require(roles.UsersManagePermission))
+	api.DELETE("/users/:userId/suspensions/:suspensionId", usersModule.Handler.LiftSuspension, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.POST("/users/:userId/restore", usersModule.Handler.RestoreUserByID, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.POST("/users/:userId/anonymize", authModule.Handler.AnonymizeUser, auth.SelfOr(roles.UsersManagePermission), auth.Session, auth.NotImpersonated)
+	api.POST("/users/:userId/export", exportsModule.Handler.StartExport, auth.SelfOr(roles.UsersManagePermission), auth.Session, auth.NotImpersonated)
+	api.GET("/users/:userId/export", exportsModule.Handler.GetExport, auth.SelfOr(roles.UsersManagePermission), auth.Session, auth.NotImpersonated)
+	api.GET("/users/:userId/export/archive", exportsModule.Handler.GetExportArchive, auth.SelfOr(roles.UsersManagePermission), auth.Session, auth.NotImpersonated)
+	api.POST("/users/:userId/impersonate", authModule.Handler.ImpersonateUser, auth.Require(roles.UsersImpersonatePermission), auth.Session, auth.NotImpersonated)
+	api.GET("/users/:userId/impersonations", authModule.Handler.GetImpersonations, auth.Require(roles.UsersManagePermission))
+	api.DELETE("/users/:userId/sessions", authModule.Handler.RevokeUserSessions, auth.Require(roles.UsersManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.POST("/users/:userId/tokens", accessTokensModule.Handler.CreateAccessToken, auth.SelfOr(roles.UsersManagePermission), auth.Session, auth.TwoFactorSatisfied, auth.NotImpersonated)
+	api.GET("/users/:userId/tokens", accessTokensModule.Handler.GetAccessTokens, auth.SelfOr(roles.UsersManagePermission), auth.Session)
+	api.DELETE("/users/:userId/tokens/:tokenId", accessTokensModule.Handler.DeleteAccessToken, auth.SelfOr(roles.UsersManagePermission), auth.Session, auth.NotImpersonated)
+
+	// Invitations API routes
+	api.POST("/invitations", authModule.Handler.CreateInvitation, auth.Require(roles.InvitationsManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.GET("/invitations", authModule.Handler.GetInvitations, auth.Require(roles.InvitationsManagePermission))
+	api.DELETE("/invitations/:invitationId", authModule.Handler.RevokeInvitation, auth.Require(roles.InvitationsManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+
+	// Roles API routes
+	api.GET("/permissions", rolesModule.Handler.GetPermissions, auth.Require(roles.RolesManagePermission))
+	api.GET("/roles", rolesModule.Handler.GetRoles, auth.Require(roles.RolesManagePermission))
+	api.GET("/roles/:role", rolesModule.Handler.GetRoleByName, auth.Require(roles.RolesManagePermission))
+	api.POST("/roles", rolesModule.Handler.CreateRole, auth.Require(roles.RolesManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.PUT("/roles/:role", rolesModule.Handler.UpdateRole, auth.Require(roles.RolesManagePermission), auth.Scope(accesstokens.UsersWriteScope))
+	api.DELETE("/roles/:role", rolesModule.Handler.DeleteRole, auth.Require(roles.RolesManagePermission), auth.Scope(accesstokens.UsersWriteScope))
 
 	// Genres API routers
 	api.GET("/genres", genresModule.Handler.GetGenres)
 	api.GET("/genres/:genreId", genresModule.Handler.GetGenreByID)
-	api.POST("/genres", genresModule.Handler.CreateGenre, auth.Editor)
-	api.PUT("/genres/:genreId", genresModule.Handler.UpdateGenreByID, auth.Editor)
-	api.DELETE("/genres/:genreId", genresModule.Handler.DeleteGenreByID, auth.Editor)
+	api.POST("/genres", genresModule.Handler.CreateGenre, auth.Require(roles.GenresWritePermission), auth.Scope(accesstokens.GenresWriteScope))
+	api.PUT("/genres/:genreId", genresModule.Handler.UpdateGenreByID, auth.Require(roles.GenresWritePermission), auth.Scope(accesstokens.GenresWriteScope))
+	api.DELETE("/genres/:genreId", genresModule.Handler.DeleteGenreByID, auth.Require(roles.GenresWritePermission), auth.Scope(accesstokens.GenresWriteScope))
 
 	// Stars API routers
 	api.GET("/stars", starsModule.Handler.GetStars)
 	api.GET("/stars/:starId", starsModule.Handler.GetStarByID)
-	api.POST("/stars", starsModule.Handler.CreateStar, auth.Editor)
-	api.PUT("/stars/:starId", starsModule.Handler.UpdateStarByID, auth.Editor)
-	api.DELETE("/stars/:starId", starsModule.Handler.DeleteStarByID, auth.Editor)
+	api.POST("/stars", starsModule.Handler.CreateStar, auth.Require(roles.StarsWritePermission), auth.Scope(accesstokens.StarsWriteScope))
+	api.PUT("/stars/:starId", starsModule.Handler.UpdateStarByID, auth.Require(roles.StarsWritePermission), auth.Scope(accesstokens.StarsWriteScope))
+	api.DELETE("/stars/:starId", starsModule.Handler.DeleteStarByID, auth.Require(roles.StarsWritePermission), auth.Scope(accesstokens.StarsWriteScope))
 
 	// Movies API routers
 	api.GET("/movies", moviesModule.Handler.GetMovies)
 	api.GET("/movies/:movieId", moviesModule.Handler.GetMovieByID)
 	api.GET("/movies/v2/:movieId", moviesModule.Handler.GetMovieByIDV2)
 	api.GET("/movies/:movieId/stars", moviesModule.Handler.GetStarsByMovieID)
-	api.POST("/movies", moviesModule.Handler.CreateMovie, auth.Editor)
-	api.PUT("/movies/:movieId", moviesModule.Handler.UpdateMovieByID, auth.Editor)
-	api.DELETE("/movies/:movieId", moviesModule.Handler.DeleteMovieByID, auth.Editor)
+	api.POST("/movies", moviesModule.Handler.CreateMovie, auth.Require(roles.MoviesWritePermission), auth.Scope(accesstokens.MoviesWriteScope))
+	api.PUT("/movies/:movieId", moviesModule.Handler.UpdateMovieByID, auth.Require(roles.MoviesWritePermission), auth.Scope(accesstokens.MoviesWriteScope))
+	api.DELETE("/movies/:movieId", moviesModule.Handler.DeleteMovieByID, auth.Require(roles.MoviesWritePermission), auth.Scope(accesstokens.MoviesWriteScope))
 
 	// Reviews API routers
 	api.GET("/movies/:movieId/reviews", reviewsModule.Handler.GetReviewsByMovieID)
 	api.GET("/users/:userId/reviews", reviewsModule.Handler.GetReviewsByUserID)
 	api.GET("/reviews/:reviewId", reviewsModule.Handler.GetReviewByID)
-	api.POST("/users/:userId/reviews", reviewsModule.Handler.CreateReview, auth.Self)
-	api.PUT("/users/:userId/reviews/:reviewId", reviewsModule.Handler.UpdateReviewByID, auth.Self)
-	api.DELETE("/users/:userId/reviews/:reviewId", reviewsModule.Handler.DeleteReviewByID, auth.Self)
+	api.POST("/users/:userId/reviews", reviewsModule.Handler.CreateReview, auth.SelfOr(roles.ReviewsModeratePermission), auth.Verified, auth.Scope(accesstokens.ReviewsWriteScope))
+	api.PUT("/users/:userId/reviews/:reviewId", reviewsModule.Handler.UpdateReviewByID, auth.SelfOr(roles.ReviewsModeratePermission), auth.Scope(accesstokens.ReviewsWriteScope))
+	api.DELETE("/users/:userId/reviews/:reviewId", reviewsModule.Handler.DeleteReviewByID, auth.SelfOr(roles.ReviewsModeratePermission), auth.Scope(accesstokens.ReviewsWriteScope))
+
+	// Search API routers
+	api.GET("/search/suggest", searchModule.Handler.Suggest)
 
 	return &Server{
 		e:       e,
@@ -184,10 +311,12 @@ func createInitialAdminUser(cfg config.AdminConfig, service *auth.Service) error
 	ctx, cancel := context.WithTimeout(context.Background(), adminCreationTime)
 	defer cancel()
 
-	err := service.Register(ctx, &users.User{
-		Username: cfg.Username,
-		Email:    cfg.Email,
-		Role:     contracts.AdminRole,
+	verifiedAt := time.Now()
+	err := service.CreateUser(ctx, &users.User{
+		Username:        cfg.Username,
+		Email:           cfg.Email,
+		Role:            contracts.AdminRole,
+		EmailVerifiedAt: &verifiedAt,
 	}, cfg.Password)
 
 	switch {
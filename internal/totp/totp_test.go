@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890".
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// The RFC lists 8 digit codes, 6 digit ones are their last digits.
+func TestCode(t *testing.T) {
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, cc := range cases {
+		t.Run(time.Unix(cc.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			code, err := Code(rfcSecret, Step(time.Unix(cc.unix, 0)))
+			require.NoError(t, err)
+			require.Equal(t, cc.code, code)
+		})
+	}
+}
+
+func TestCode_InvalidSecret(t *testing.T) {
+	_, err := Code("not base32!", 1)
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	cases := []struct {
+		name string
+		code string
+		step int64
+		ok   bool
+	}{
+		{"current step", "050471", current, true},
+		{"previous step", "081804", current - 1, true},
+		{"wrong code", "123456", 0, false},
+		{"wrong length", "94287082", 0, false},
+		{"empty", "", 0, false},
+	}
+
+	for _, cc := range cases {
+		t.Run(cc.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, cc.code, now)
+			require.Equal(t, cc.ok, ok)
+			require.Equal(t, cc.step, step)
+		})
+	}
+
+	t.Run("outside the skew", func(t *testing.T) {
+		code, err := Code(rfcSecret, current-Skew-1)
+		require.NoError(t, err)
+
+		_, ok := Validate(rfcSecret, code, now)
+		require.False(t, ok)
+	})
+}
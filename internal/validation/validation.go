@@ -3,10 +3,9 @@ package validation
 import (
 	"fmt"
 	"net/mail"
+	"regexp"
 	"strings"
 
-	"github.com/DavidMovas/Movies-Reviews/contracts"
-
 	"gopkg.in/validator.v2"
 )
 
@@ -14,6 +13,7 @@ var (
 	passwordMinLength       = 8
 	emailMaxLength          = 127
 	usernameMinLength       = 3
+	roleNamePattern         = regexp.MustCompile(`^[a-z][a-z0-9_-]{2,31}$`)
 	passwordSpecialChars    = "!#$%&'*+/=?^_`{|}~@"
 	passwordRequiredEntries = []struct {
 		name  string
@@ -161,7 +161,7 @@ func role(v interface{}, _ string) error {
 		return fmt.Errorf("role must be a string")
 	}
 
-	if !contracts.ValidateRole(s) {
+	if !roleNamePattern.MatchString(s) {
 		return fmt.Errorf("invalid role")
 	}
 	return nil
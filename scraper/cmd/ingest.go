@@ -22,6 +22,7 @@ type IngestOptions struct {
 	URL      string
 	Email    string
 	Password string
+	Token    string
 }
 
 func NewIngestCmd(logger *slog.Logger) *cobra.Command {
@@ -39,26 +40,35 @@ func NewIngestCmd(logger *slog.Logger) *cobra.Command {
 	cmd.Flags().StringVarP(&opts.URL, "url", "u", "http://localhost:8000", "API URL")
 	cmd.Flags().StringVarP(&opts.Email, "email", "e", "", "User email")
 	cmd.Flags().StringVarP(&opts.Password, "password", "p", "", "User password")
+	cmd.Flags().StringVarP(&opts.Token, "token", "t", "", "Personal access token, used instead of email and password")
 
 	_ = cmd.MarkFlagRequired("input")
-	_ = cmd.MarkFlagRequired("email")
-	_ = cmd.MarkFlagRequired("password")
+	cmd.MarkFlagsRequiredTogether("email", "password")
+	cmd.MarkFlagsOneRequired("email", "token")
+	cmd.MarkFlagsMutuallyExclusive("email", "token")
 
 	return cmd
 }
 
 func runIngest(opts *IngestOptions, logger *slog.Logger) error {
 	cl := client.New(opts.URL)
-	res, err := cl.LoginUser(&contracts.LoginUserRequest{
-		Email:    opts.Email,
-		Password: opts.Password,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to login ingest user: %w", err)
+	token := opts.Token
+	var err error
+	if token == "" {
+		var res *contracts.LoginUserResponse
+		res, err = cl.LoginUser(&contracts.LoginUserRequest{
+			Email:    opts.Email,
+			Password: opts.Password,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to login ingest user: %w", err)
+		}
+		if res.TwoFactorRequired {
+			return fmt.Errorf("ingest user has two-factor authentication enabled, use a personal access token")
+		}
+		token = res.AccessToken
+		logger.Info("Logged in successfully")
 	}
-	token := res.AccessToken
-	_ = token
-	logger.Info("Logged in successfully")
 
 	var (
 		genres []string
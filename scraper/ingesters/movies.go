@@ -37,7 +37,7 @@ func NewMovieIngester(c *client.Client, token string, genreIngesterConverter fun
 }
 
 func (i *MoviesIngester) Ingest(movies map[string]*models.Movie, casts map[string]*models.Cast) error {
-	existingMovies, err := client.Paginate[*contracts.Movie](&contracts.GetMoviesRequest{}, i.c.GetMovies)
+	existingMovies, err := client.PaginateCursor[*contracts.Movie](&contracts.GetMoviesRequest{}, i.c.GetMovies)
 	if err != nil {
 		return err
 	}
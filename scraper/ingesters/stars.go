@@ -37,7 +37,7 @@ func NewStarIngester(c *client.Client, token string, logger *slog.Logger) *StarI
 }
 
 func (i *StarIngester) Ingest(stars map[string]*models.Star, bios map[string]*models.Bio) error {
-	existingStars, err := client.Paginate(&contracts.GetStarsRequest{}, i.c.GetStars)
+	existingStars, err := client.PaginateCursor(&contracts.GetStarsRequest{}, i.c.GetStars)
 	if err != nil {
 		return fmt.Errorf("get stars: %w", err)
 	}
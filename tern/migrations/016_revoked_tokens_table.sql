@@ -0,0 +1,17 @@
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE revoked_users (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id),
+    revoked_before TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_revoked_users_expires_at ON revoked_users (expires_at);
+---- create above / drop below ----
+DROP INDEX idx_revoked_users_expires_at;
+DROP TABLE revoked_users;
+DROP INDEX idx_revoked_tokens_expires_at;
+DROP TABLE revoked_tokens;
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Accounts created before the email verification was introduced are trusted
+UPDATE users SET email_verified_at = created_at;
+---- create above / drop below ----
+ALTER TABLE users DROP COLUMN email_verified_at;
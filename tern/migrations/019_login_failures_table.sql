@@ -0,0 +1,12 @@
+CREATE TABLE login_failures (
+    scope VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, subject)
+);
+CREATE INDEX idx_login_failures_locked_until ON login_failures (locked_until);
+---- create above / drop below ----
+DROP INDEX idx_login_failures_locked_until;
+DROP TABLE login_failures;
@@ -0,0 +1,20 @@
+CREATE TABLE user_two_factor (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id),
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT
+);
+
+CREATE TABLE two_factor_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    code_hash VARCHAR(64) UNIQUE NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes (user_id);
+---- create above / drop below ----
+DROP INDEX idx_two_factor_recovery_codes_user_id;
+DROP TABLE two_factor_recovery_codes;
+DROP TABLE user_two_factor;
@@ -0,0 +1,57 @@
+CREATE TABLE roles (
+    name VARCHAR(32) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE permissions (
+    name VARCHAR(64) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE role_permissions (
+    role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description, built_in) VALUES
+    ('admin', 'Has every permission', TRUE),
+    ('editor', 'Edits the movies catalog', TRUE),
+    ('user', 'Writes reviews', TRUE);
+
+INSERT INTO permissions (name, description) VALUES
+    ('genres.write', 'Create, update and delete genres'),
+    ('stars.write', 'Create, update and delete stars'),
+    ('movies.write', 'Create, update and delete movies'),
+    ('reviews.moderate', 'Update and delete reviews of other users'),
+    ('users.manage', 'Update and delete other users, change their roles and sessions'),
+    ('roles.manage', 'Create, update and delete roles');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('editor', 'genres.write'),
+    ('editor', 'stars.write'),
+    ('editor', 'movies.write');
+
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(32) USING role::TEXT;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name);
+
+DROP TYPE role;
+---- create above / drop below ----
+CREATE TYPE role AS ENUM ('admin', 'editor', 'user');
+
+ALTER TABLE users DROP CONSTRAINT users_role_fkey;
+UPDATE users SET role = 'user' WHERE role NOT IN ('admin', 'editor', 'user');
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE role USING role::role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
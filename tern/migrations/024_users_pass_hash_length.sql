@@ -0,0 +1,4 @@
+ALTER TABLE users ALTER COLUMN pass_hash TYPE VARCHAR(255);
+---- create above / drop below ----
+-- Fails while argon2id hashes are stored, they can't be turned back into bcrypt ones
+ALTER TABLE users ALTER COLUMN pass_hash TYPE VARCHAR(60);
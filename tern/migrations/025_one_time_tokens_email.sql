@@ -0,0 +1,4 @@
+-- The new address of an email change, the token confirming it is sent there
+ALTER TABLE one_time_tokens ADD COLUMN email VARCHAR(128);
+---- create above / drop below ----
+ALTER TABLE one_time_tokens DROP COLUMN email;
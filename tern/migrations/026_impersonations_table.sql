@@ -0,0 +1,22 @@
+INSERT INTO permissions (name, description) VALUES
+    ('users.impersonate', 'Act as another user for a short time');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'users.impersonate');
+
+CREATE TABLE impersonations (
+    id SERIAL PRIMARY KEY,
+    actor_id INTEGER NOT NULL REFERENCES users(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_impersonations_user_id ON impersonations (user_id);
+---- create above / drop below ----
+DROP INDEX idx_impersonations_user_id;
+DROP TABLE impersonations;
+
+DELETE FROM role_permissions WHERE permission = 'users.impersonate';
+DELETE FROM permissions WHERE name = 'users.impersonate';
@@ -0,0 +1,22 @@
+INSERT INTO permissions (name, description) VALUES
+    ('invitations.manage', 'Create and revoke invitation codes');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'invitations.manage');
+
+CREATE TABLE invitations (
+    id SERIAL PRIMARY KEY,
+    code_hash VARCHAR(64) UNIQUE NOT NULL,
+    role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    max_uses INTEGER NOT NULL DEFAULT 1,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+---- create above / drop below ----
+DROP TABLE invitations;
+
+DELETE FROM role_permissions WHERE permission = 'invitations.manage';
+DELETE FROM permissions WHERE name = 'invitations.manage';
@@ -0,0 +1,7 @@
+CREATE INDEX idx_users_username_lower ON users (LOWER(username) text_pattern_ops);
+CREATE INDEX idx_users_email_lower ON users (LOWER(email) text_pattern_ops);
+CREATE INDEX idx_users_created_at ON users (created_at);
+---- create above / drop below ----
+DROP INDEX idx_users_created_at;
+DROP INDEX idx_users_email_lower;
+DROP INDEX idx_users_username_lower;
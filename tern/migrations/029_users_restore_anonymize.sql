@@ -0,0 +1,30 @@
+ALTER TABLE users ADD COLUMN anonymized_at TIMESTAMP;
+
+-- Usernames and emails of deleted users can be taken again
+ALTER TABLE users DROP CONSTRAINT users_username_key;
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_username_key ON users (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX users_email_key ON users (email) WHERE deleted_at IS NULL;
+
+-- Average rating of the movie, reviews of deleted users don't count
+CREATE FUNCTION movie_avg_rating(movie INTEGER) RETURNS float4 AS $$
+    SELECT AVG(reviews.rating)::float4
+    FROM reviews
+    JOIN users ON users.id = reviews.user_id
+    WHERE reviews.movie_id = movie
+      AND reviews.deleted_at IS NULL
+      AND users.deleted_at IS NULL
+$$ LANGUAGE SQL STABLE;
+
+UPDATE movies SET avg_rating = movie_avg_rating(id);
+---- create above / drop below ----
+DROP FUNCTION movie_avg_rating(INTEGER);
+
+UPDATE movies SET avg_rating = (SELECT AVG(rating) FROM reviews WHERE deleted_at IS NULL AND movie_id = movies.id);
+
+DROP INDEX users_email_key;
+DROP INDEX users_username_key;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE users DROP COLUMN anonymized_at;
@@ -0,0 +1,21 @@
+CREATE TABLE data_exports (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    archive BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports (user_id);
+CREATE INDEX idx_data_exports_status ON data_exports (status);
+
+-- A user has at most one export in progress
+CREATE UNIQUE INDEX data_exports_user_id_unfinished_key ON data_exports (user_id) WHERE status IN ('pending', 'running');
+---- create above / drop below ----
+DROP INDEX data_exports_user_id_unfinished_key;
+DROP INDEX idx_data_exports_status;
+DROP INDEX idx_data_exports_user_id;
+DROP TABLE data_exports;
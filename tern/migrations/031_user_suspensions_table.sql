@@ -0,0 +1,27 @@
+CREATE TABLE user_suspensions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    reason VARCHAR(255) NOT NULL,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP,
+    lifted_at TIMESTAMP,
+    lifted_by INTEGER REFERENCES users(id)
+);
+
+CREATE INDEX idx_user_suspensions_user_id ON user_suspensions (user_id);
+
+-- Whether the user has a suspension which is neither lifted nor expired, permanent ones never expire
+CREATE FUNCTION user_suspended(suspended_user INTEGER) RETURNS boolean AS $$
+    SELECT EXISTS (
+        SELECT 1
+        FROM user_suspensions
+        WHERE user_id = suspended_user
+          AND lifted_at IS NULL
+          AND (expires_at IS NULL OR expires_at > NOW())
+    )
+$$ LANGUAGE SQL STABLE;
+---- create above / drop below ----
+DROP FUNCTION user_suspended(INTEGER);
+DROP INDEX idx_user_suspensions_user_id;
+DROP TABLE user_suspensions;
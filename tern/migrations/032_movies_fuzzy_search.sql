@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Matches misspelled titles with word_similarity (<%)
+CREATE INDEX idx_movies_title_trgm ON movies USING GIN (title gin_trgm_ops);
+---- create above / drop below ----
+DROP INDEX idx_movies_title_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
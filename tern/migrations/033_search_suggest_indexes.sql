@@ -0,0 +1,7 @@
+-- Prefix (ILIKE 'prefix%'), word prefix (ILIKE '% prefix%') and similarity (<%) matches of the search suggestions,
+-- movie titles are indexed by 032 already
+CREATE INDEX idx_stars_name_trgm ON stars USING GIN ((first_name || ' ' || last_name) gin_trgm_ops);
+CREATE INDEX idx_genres_name_trgm ON genres USING GIN (name gin_trgm_ops);
+---- create above / drop below ----
+DROP INDEX idx_genres_name_trgm;
+DROP INDEX idx_stars_name_trgm;